@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// AuthScheme selects how credentials are attached to requests
+type AuthScheme string
+
+const (
+	AuthBearer AuthScheme = "bearer" // Authorization: Bearer <secret>
+	AuthBasic  AuthScheme = "basic"  // Authorization: Basic <username:secret>
+	AuthHeader AuthScheme = "header" // <Header>: <secret>
+)
+
+// SecretSource returns the secret (token or password) used for authentication
+type SecretSource func() (string, error)
+
+// Auth attaches credentials to outgoing requests. The secret is read from
+// Source on first use and cached until SetSecret or Reset is called.
+type Auth struct {
+	Scheme   AuthScheme
+	Username string // Used by AuthBasic
+	Header   string // Header name used by AuthHeader
+	Source   SecretSource
+
+	mu     sync.Mutex
+	secret string
+	loaded bool
+}
+
+// Reauthenticator is implemented by backends whose credentials can be
+// replaced at runtime, e.g. after the user is prompted for a new token
+type Reauthenticator interface {
+	// SetSecret replaces the secret; an empty secret re-reads the configured source
+	SetSecret(secret string)
+}
+
+// WithAuth authenticates every request using a
+func WithAuth(a *Auth) Option {
+	return func(c *Client) {
+		c.Auth = a
+	}
+}
+
+// SetSecret replaces the cached secret; an empty secret makes the next
+// request re-read Source
+func (a *Auth) SetSecret(secret string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.secret = secret
+	a.loaded = secret != ""
+}
+
+// Reset forgets the cached secret so the next request re-reads Source
+func (a *Auth) Reset() {
+	a.SetSecret("")
+}
+
+// getSecret returns the cached secret, loading it from Source if needed
+func (a *Auth) getSecret() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.loaded {
+		return a.secret, nil
+	}
+	if a.Source == nil {
+		return "", nil
+	}
+	secret, err := a.Source()
+	if err != nil {
+		return "", err
+	}
+	a.secret = secret
+	a.loaded = true
+	return secret, nil
+}
+
+// apply attaches credentials to req
+func (a *Auth) apply(req *http.Request) error {
+	secret, err := a.getSecret()
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	switch a.Scheme {
+	case AuthBearer:
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+	case AuthBasic:
+		req.SetBasicAuth(a.Username, secret)
+	case AuthHeader:
+		if a.Header == "" {
+			return fmt.Errorf("auth scheme %q requires a header name", a.Scheme)
+		}
+		req.Header.Set(a.Header, secret)
+	default:
+		return fmt.Errorf("unknown auth scheme %q", a.Scheme)
+	}
+	return nil
+}
+
+// SetSecret replaces the client's credentials, implementing Reauthenticator
+func (c *Client) SetSecret(secret string) {
+	if c.Auth != nil {
+		c.Auth.SetSecret(secret)
+	}
+}
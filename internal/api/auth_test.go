@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// headerServer records the headers of the last request it got
+func headerServer(t *testing.T) (*httptest.Server, *http.Header) {
+	t.Helper()
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("[]"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+func TestAuthHeaders(t *testing.T) {
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("sean:s3cret"))
+	tests := []struct {
+		name   string
+		auth   *Auth
+		header string
+		want   string
+	}{
+		{"bearer", &Auth{Scheme: AuthBearer}, "Authorization", "Bearer s3cret"},
+		{"basic", &Auth{Scheme: AuthBasic, Username: "sean"}, "Authorization", basic},
+		{"header", &Auth{Scheme: AuthHeader, Header: "X-Api-Key"}, "X-Api-Key", "s3cret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := headerServer(t)
+			tt.auth.Source = func() (string, error) { return "s3cret", nil }
+			client := NewClient(srv.URL, WithAuth(tt.auth))
+
+			if _, err := client.GetProjects(context.Background()); err != nil {
+				t.Fatalf("GetProjects: %v", err)
+			}
+			if v := got.Get(tt.header); v != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthSecretCaching(t *testing.T) {
+	srv, got := headerServer(t)
+	reads := 0
+	auth := &Auth{Scheme: AuthBearer, Source: func() (string, error) {
+		reads++
+		return "from-source", nil
+	}}
+	client := NewClient(srv.URL, WithAuth(auth))
+	ctx := context.Background()
+
+	client.GetProjects(ctx)
+	client.GetProjects(ctx)
+	if reads != 1 {
+		t.Errorf("secret read %d times, want it cached after the first", reads)
+	}
+
+	client.SetSecret("typed")
+	client.GetProjects(ctx)
+	if v := got.Get("Authorization"); v != "Bearer typed" || reads != 1 {
+		t.Errorf("after SetSecret, Authorization = %q and %d reads, want the new secret unread", v, reads)
+	}
+
+	auth.Reset()
+	client.GetProjects(ctx)
+	if v := got.Get("Authorization"); v != "Bearer from-source" || reads != 2 {
+		t.Errorf("after Reset, Authorization = %q and %d reads, want the source re-read", v, reads)
+	}
+}
+
+func TestAuthFailures(t *testing.T) {
+	srv, _ := headerServer(t)
+	ctx := context.Background()
+
+	sourceErr := errors.New("pass: no such entry")
+	broken := NewClient(srv.URL, WithAuth(&Auth{Scheme: AuthBearer, Source: func() (string, error) {
+		return "", sourceErr
+	}}))
+	if _, err := broken.GetProjects(ctx); !errors.Is(err, sourceErr) {
+		t.Errorf("failing secret source: err = %v, want it wrapped", err)
+	}
+
+	noHeader := NewClient(srv.URL, WithAuth(&Auth{Scheme: AuthHeader}))
+	if _, err := noHeader.GetProjects(ctx); err == nil {
+		t.Error("header scheme without a header name succeeded")
+	}
+
+	// An empty bearer token sends no Authorization header at all
+	srv2, got := headerServer(t)
+	empty := NewClient(srv2.URL, WithAuth(&Auth{Scheme: AuthBearer}))
+	if _, err := empty.GetProjects(ctx); err != nil {
+		t.Fatalf("GetProjects: %v", err)
+	}
+	if v := got.Get("Authorization"); v != "" {
+		t.Errorf("Authorization = %q, want none without a secret", v)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import "context"
+
+// Backend is the set of project and todo operations the TUI depends on.
+// Client implements it against the projectarium-v2 HTTP API; other stores
+// (local files, in-memory fakes, caches) can be plugged in by implementing it.
+//
+// Every method takes a context so callers can cancel in-flight requests.
+// Full updates take the version of the project or todo they were based on,
+// and fail with ErrConflict if it has changed since; version 0 overwrites
+// whatever is there.
+type Backend interface {
+	GetProjects(ctx context.Context) ([]Project, error)
+	GetProject(ctx context.Context, id int) (*Project, error)
+	CreateProject(ctx context.Context, name, description, path, file, language string, priority int, status string) (*Project, error)
+	UpdateProject(ctx context.Context, id, version int, name, description, path, file, language string, priority int, status string) (*Project, error)
+	UpdateProjectStatus(ctx context.Context, id int, status string) (*Project, error)
+	UpdateProjectPriority(ctx context.Context, id int, priority int) (*Project, error)
+	DeleteProject(ctx context.Context, id int) error
+
+	GetTodos(ctx context.Context) ([]Todo, error)
+	GetTodosByProject(ctx context.Context, projectID int) ([]Todo, error)
+	GetTodo(ctx context.Context, id int) (*Todo, error)
+	CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*Todo, error)
+	UpdateTodo(ctx context.Context, id, version int, description string, priority int, projectID *int) (*Todo, error)
+	UpdateTodoCompleted(ctx context.Context, id int, completed bool) (*Todo, error)
+	UpdateTodoNotes(ctx context.Context, id int, notes string) (*Todo, error)
+	DeleteTodo(ctx context.Context, id int) error
+}
+
+// Syncer is implemented by backends that keep working while the server is
+// unreachable, queueing changes to send once it is back
+type Syncer interface {
+	// SyncStatus reports whether the backend is offline and how many
+	// changes are waiting to be sent
+	SyncStatus() SyncStatus
+	// Sync sends the queued changes in order, or checks that the server is
+	// reachable again if there are none. It stops at the first change the
+	// server can't be reached for.
+	Sync(ctx context.Context) (SyncResult, error)
+}
+
+// SyncStatus is the connection state of a Syncer
+type SyncStatus struct {
+	Offline bool
+	Pending int
+}
+
+// SyncResult is the outcome of a Sync
+type SyncResult struct {
+	// Sent is the number of queued changes the server accepted
+	Sent int
+	// Dropped holds why the server rejected the other changes; they are
+	// not retried
+	Dropped []error
+}
+
+// Ensure Client satisfies Backend and can be re-authenticated
+var (
+	_ Backend         = (*Client)(nil)
+	_ Reauthenticator = (*Client)(nil)
+)
@@ -2,342 +2,378 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultTimeout is used for operations without a configured timeout
+const DefaultTimeout = 10 * time.Second
+
+// Operation identifies a Client method for per-operation configuration
+type Operation string
+
+const (
+	OpGetProjects           Operation = "get_projects"
+	OpGetProject            Operation = "get_project"
+	OpCreateProject         Operation = "create_project"
+	OpUpdateProject         Operation = "update_project"
+	OpUpdateProjectStatus   Operation = "update_project_status"
+	OpUpdateProjectPriority Operation = "update_project_priority"
+	OpDeleteProject         Operation = "delete_project"
+	OpGetTodos              Operation = "get_todos"
+	OpGetTodo               Operation = "get_todo"
+	OpCreateTodo            Operation = "create_todo"
+	OpUpdateTodo            Operation = "update_todo"
+	OpUpdateTodoCompleted   Operation = "update_todo_completed"
+	OpUpdateTodoNotes       Operation = "update_todo_notes"
+	OpDeleteTodo            Operation = "delete_todo"
+)
+
+// Operations lists every operation the client performs
+var Operations = []Operation{
+	OpGetProjects, OpGetProject, OpCreateProject, OpUpdateProject,
+	OpUpdateProjectStatus, OpUpdateProjectPriority, OpDeleteProject,
+	OpGetTodos, OpGetTodo, OpCreateTodo, OpUpdateTodo, OpUpdateTodoCompleted,
+	OpUpdateTodoNotes, OpDeleteTodo,
+}
+
+// describe returns a human readable name for the operation, e.g. "get projects"
+func (op Operation) describe() string {
+	return strings.ReplaceAll(string(op), "_", " ")
+}
+
 // Client is the HTTP client for the projectarium-v2 API
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+	// Timeout bounds each request unless overridden in Timeouts
+	Timeout time.Duration
+	// Timeouts holds per-operation overrides of Timeout
+	Timeouts map[Operation]time.Duration
+	// Retry controls retries of idempotent requests
+	Retry RetryPolicy
+	// IdempotencyKeys makes creates retryable by sending an Idempotency-Key
+	IdempotencyKeys bool
+	// Auth attaches credentials to every request when set
+	Auth *Auth
+
+	budget *retryBudget
 }
 
-// NewClient creates a new API client
-func NewClient(baseURL string) *Client {
-	return &Client{
-		BaseURL: baseURL,
-		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+// Option configures a Client
+type Option func(*Client)
+
+// WithTimeout sets the default per-request timeout
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.Timeout = d
 	}
 }
 
-// GetProjects retrieves all projects
-func (c *Client) GetProjects() ([]Project, error) {
-	url := fmt.Sprintf("%s/projects", c.BaseURL)
-
-	resp, err := c.HTTPClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get projects: %w", err)
+// WithOperationTimeout sets the timeout for a single operation
+func WithOperationTimeout(op Operation, d time.Duration) Option {
+	return func(c *Client) {
+		c.Timeouts[op] = d
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// WithHTTPClient replaces the underlying HTTP client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = hc
 	}
+}
 
-	var projects []Project
-	if err := json.NewDecoder(resp.Body).Decode(&projects); err != nil {
-		return nil, fmt.Errorf("failed to decode projects: %w", err)
-	}
+// NewClient creates a new API client
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{},
+		Timeout:    DefaultTimeout,
+		Timeouts:   make(map[Operation]time.Duration),
+		Retry:      DefaultRetryPolicy,
+		budget:     newRetryBudget(DefaultRetryPolicy.Budget),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
 
-	return projects, nil
+// timeoutFor returns the timeout configured for an operation
+func (c *Client) timeoutFor(op Operation) time.Duration {
+	if d, ok := c.Timeouts[op]; ok && d > 0 {
+		return d
+	}
+	return c.Timeout
 }
 
-// GetProject retrieves a specific project by ID
-func (c *Client) GetProject(id int) (*Project, error) {
-	url := fmt.Sprintf("%s/projects/%d", c.BaseURL, id)
+// do performs a request for op, encoding payload as JSON when non-nil and
+// decoding the response into out when non-nil. Any status other than
+// wantStatus, and any transport failure, is reported as an *APIError.
+//
+// Idempotent requests, and creates sent with an idempotency key, are retried
+// with backoff on transport failures and retryable statuses.
+func (c *Client) do(ctx context.Context, op Operation, method, path string, payload interface{}, wantStatus int, out interface{}) error {
+	return c.doIfMatch(ctx, op, method, path, 0, payload, wantStatus, out)
+}
 
-	resp, err := c.HTTPClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get project: %w", err)
+// doIfMatch performs a request like do, made conditional on the resource
+// still being at version by an If-Match header unless version is 0. A
+// mismatch fails with ErrConflict.
+func (c *Client) doIfMatch(ctx context.Context, op Operation, method, path string, version int, payload interface{}, wantStatus int, out interface{}) error {
+	var jsonData []byte
+	if payload != nil {
+		var err error
+		jsonData, err = json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+
+	header := make(http.Header)
+	if payload != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	if version != 0 {
+		header.Set("If-Match", versionETag(version))
+	}
+	retryable := isIdempotent(method)
+	if method == http.MethodPost && c.IdempotencyKeys {
+		header.Set("Idempotency-Key", newIdempotencyKey())
+		retryable = true
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, op, method, path, header, jsonData, wantStatus, out)
+		if err == nil {
+			c.budget.refund()
+			return nil
+		}
+
+		if !retryable || attempt >= c.Retry.MaxAttempts || ctx.Err() != nil || !shouldRetry(err) {
+			return err
+		}
+		if c.Retry.MaxRetryAfter > 0 && retryAfter > c.Retry.MaxRetryAfter {
+			// Better to report the outage than hang until the server is back
+			return err
+		}
+		if !c.budget.spend() {
+			return err
+		}
+
+		delay := c.Retry.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// shouldRetry reports whether a failed attempt may succeed if repeated
+func shouldRetry(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
 	}
-
-	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode project: %w", err)
+	if apiErr.StatusCode == 0 {
+		return true
 	}
+	return isRetryableStatus(apiErr.StatusCode)
+}
 
-	return &project, nil
+// versionETag is the entity tag the backend gives a resource at version
+func versionETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
 }
 
-// GetTodos retrieves all todos, optionally filtered by project
-func (c *Client) GetTodos() ([]Todo, error) {
-	url := fmt.Sprintf("%s/todos", c.BaseURL)
+// attempt performs a single request, returning the delay requested by the
+// server's Retry-After header alongside any error
+func (c *Client) attempt(ctx context.Context, op Operation, method, path string, header http.Header, jsonData []byte, wantStatus int, out interface{}) (time.Duration, error) {
+	if timeout := c.timeoutFor(op); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if jsonData != nil {
+		body = bytes.NewReader(jsonData)
+	}
 
-	resp, err := c.HTTPClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get todos: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if c.Auth != nil {
+		if err := c.Auth.apply(req); err != nil {
+			return 0, err
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, &APIError{Op: op, Method: method, URL: req.URL.String(), Err: err}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	if resp.StatusCode != wantStatus {
+		respBody, _ := io.ReadAll(resp.Body)
+		return parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), newResponseError(op, req, resp.StatusCode, respBody)
 	}
 
-	var todos []Todo
-	if err := json.NewDecoder(resp.Body).Decode(&todos); err != nil {
-		return nil, fmt.Errorf("failed to decode todos: %w", err)
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("failed to decode response to %s: %w", op.describe(), err)
+		}
 	}
 
-	return todos, nil
+	return 0, nil
 }
 
-// GetTodosByProject retrieves todos for a specific project
-func (c *Client) GetTodosByProject(projectID int) ([]Todo, error) {
-	url := fmt.Sprintf("%s/todos?project_id=%d", c.BaseURL, projectID)
-
-	resp, err := c.HTTPClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get todos: %w", err)
+// GetProjects retrieves all projects
+func (c *Client) GetProjects(ctx context.Context) ([]Project, error) {
+	var projects []Project
+	if err := c.do(ctx, OpGetProjects, http.MethodGet, "/projects", nil, http.StatusOK, &projects); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return projects, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// GetProject retrieves a specific project by ID
+func (c *Client) GetProject(ctx context.Context, id int) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, OpGetProject, http.MethodGet, fmt.Sprintf("/projects/%d", id), nil, http.StatusOK, &project); err != nil {
+		return nil, err
 	}
+	return &project, nil
+}
 
+// GetTodos retrieves all todos, optionally filtered by project
+func (c *Client) GetTodos(ctx context.Context) ([]Todo, error) {
 	var todos []Todo
-	if err := json.NewDecoder(resp.Body).Decode(&todos); err != nil {
-		return nil, fmt.Errorf("failed to decode todos: %w", err)
+	if err := c.do(ctx, OpGetTodos, http.MethodGet, "/todos", nil, http.StatusOK, &todos); err != nil {
+		return nil, err
 	}
-
 	return todos, nil
 }
 
-// GetTodo retrieves a specific todo by ID
-func (c *Client) GetTodo(id int) (*Todo, error) {
-	url := fmt.Sprintf("%s/todos/%d", c.BaseURL, id)
-
-	resp, err := c.HTTPClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get todo: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// GetTodosByProject retrieves todos for a specific project
+func (c *Client) GetTodosByProject(ctx context.Context, projectID int) ([]Todo, error) {
+	var todos []Todo
+	if err := c.do(ctx, OpGetTodos, http.MethodGet, fmt.Sprintf("/todos?project_id=%d", projectID), nil, http.StatusOK, &todos); err != nil {
+		return nil, err
 	}
+	return todos, nil
+}
 
+// GetTodo retrieves a specific todo by ID
+func (c *Client) GetTodo(ctx context.Context, id int) (*Todo, error) {
 	var todo Todo
-	if err := json.NewDecoder(resp.Body).Decode(&todo); err != nil {
-		return nil, fmt.Errorf("failed to decode todo: %w", err)
+	if err := c.do(ctx, OpGetTodo, http.MethodGet, fmt.Sprintf("/todos/%d", id), nil, http.StatusOK, &todo); err != nil {
+		return nil, err
 	}
-
 	return &todo, nil
 }
 
 // UpdateProjectStatus updates a project's status
-func (c *Client) UpdateProjectStatus(id int, status string) (*Project, error) {
-	url := fmt.Sprintf("%s/projects/%d/status", c.BaseURL, id)
-
+func (c *Client) UpdateProjectStatus(ctx context.Context, id int, status string) (*Project, error) {
 	payload := map[string]string{"status": status}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update project status: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode project: %w", err)
+	if err := c.do(ctx, OpUpdateProjectStatus, http.MethodPatch, fmt.Sprintf("/projects/%d/status", id), payload, http.StatusOK, &project); err != nil {
+		return nil, err
 	}
-
 	return &project, nil
 }
 
 // UpdateProjectPriority updates a project's priority
-func (c *Client) UpdateProjectPriority(id int, priority int) (*Project, error) {
-	url := fmt.Sprintf("%s/projects/%d/priority", c.BaseURL, id)
-
+func (c *Client) UpdateProjectPriority(ctx context.Context, id int, priority int) (*Project, error) {
 	payload := map[string]int{"priority": priority}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update project priority: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode project: %w", err)
+	if err := c.do(ctx, OpUpdateProjectPriority, http.MethodPatch, fmt.Sprintf("/projects/%d/priority", id), payload, http.StatusOK, &project); err != nil {
+		return nil, err
 	}
-
 	return &project, nil
 }
 
 // CreateTodo creates a new todo
-func (c *Client) CreateTodo(description string, priority int, projectID *int) (*Todo, error) {
-	url := fmt.Sprintf("%s/todos", c.BaseURL)
-
+func (c *Client) CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*Todo, error) {
 	payload := map[string]interface{}{
 		"description": description,
 		"priority":    priority,
 		"project_id":  projectID,
 	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create todo: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var todo Todo
-	if err := json.NewDecoder(resp.Body).Decode(&todo); err != nil {
-		return nil, fmt.Errorf("failed to decode todo: %w", err)
+	if err := c.do(ctx, OpCreateTodo, http.MethodPost, "/todos", payload, http.StatusCreated, &todo); err != nil {
+		return nil, err
 	}
-
 	return &todo, nil
 }
 
-// UpdateTodo updates an existing todo
-func (c *Client) UpdateTodo(id int, description string, priority int, projectID *int) (*Todo, error) {
-	url := fmt.Sprintf("%s/todos/%d", c.BaseURL, id)
-
+// UpdateTodo updates an existing todo, if it is still at version
+func (c *Client) UpdateTodo(ctx context.Context, id, version int, description string, priority int, projectID *int) (*Todo, error) {
 	payload := map[string]interface{}{
 		"description": description,
 		"priority":    priority,
 		"project_id":  projectID,
 	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update todo: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var todo Todo
-	if err := json.NewDecoder(resp.Body).Decode(&todo); err != nil {
-		return nil, fmt.Errorf("failed to decode todo: %w", err)
+	if err := c.doIfMatch(ctx, OpUpdateTodo, http.MethodPut, fmt.Sprintf("/todos/%d", id), version, payload, http.StatusOK, &todo); err != nil {
+		return nil, err
 	}
-
 	return &todo, nil
 }
 
-// DeleteTodo soft-deletes a todo
-func (c *Client) DeleteTodo(id int) error {
-	url := fmt.Sprintf("%s/todos/%d", c.BaseURL, id)
+// UpdateTodoCompleted marks a todo as done or open again
+func (c *Client) UpdateTodoCompleted(ctx context.Context, id int, completed bool) (*Todo, error) {
+	payload := map[string]bool{"completed": completed}
 
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	var todo Todo
+	if err := c.do(ctx, OpUpdateTodoCompleted, http.MethodPatch, fmt.Sprintf("/todos/%d/completed", id), payload, http.StatusOK, &todo); err != nil {
+		return nil, err
 	}
+	return &todo, nil
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
-	}
-	defer resp.Body.Close()
+// UpdateTodoNotes replaces a todo's notes; empty notes remove them
+func (c *Client) UpdateTodoNotes(ctx context.Context, id int, notes string) (*Todo, error) {
+	payload := map[string]string{"notes": notes}
 
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	var todo Todo
+	if err := c.do(ctx, OpUpdateTodoNotes, http.MethodPatch, fmt.Sprintf("/todos/%d/notes", id), payload, http.StatusOK, &todo); err != nil {
+		return nil, err
 	}
+	return &todo, nil
+}
 
-	return nil
+// DeleteTodo soft-deletes a todo
+func (c *Client) DeleteTodo(ctx context.Context, id int) error {
+	return c.do(ctx, OpDeleteTodo, http.MethodDelete, fmt.Sprintf("/todos/%d", id), nil, http.StatusNoContent, nil)
 }
 
 // DeleteProject deletes a project
-func (c *Client) DeleteProject(id int) error {
-	url := fmt.Sprintf("%s/projects/%d", c.BaseURL, id)
-
-	req, err := http.NewRequest(http.MethodDelete, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to delete project: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+func (c *Client) DeleteProject(ctx context.Context, id int) error {
+	return c.do(ctx, OpDeleteProject, http.MethodDelete, fmt.Sprintf("/projects/%d", id), nil, http.StatusNoContent, nil)
 }
 
 // CreateProject creates a new project
-func (c *Client) CreateProject(name, description, path, file, language string, priority int, status string) (*Project, error) {
-	url := fmt.Sprintf("%s/projects", c.BaseURL)
-
+func (c *Client) CreateProject(ctx context.Context, name, description, path, file, language string, priority int, status string) (*Project, error) {
 	payload := map[string]interface{}{
 		"name":        name,
 		"description": description,
@@ -347,40 +383,16 @@ func (c *Client) CreateProject(name, description, path, file, language string, p
 		"priority":    priority,
 		"status":      status,
 	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create project: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode project: %w", err)
+	if err := c.do(ctx, OpCreateProject, http.MethodPost, "/projects", payload, http.StatusCreated, &project); err != nil {
+		return nil, err
 	}
-
 	return &project, nil
 }
 
-// UpdateProject updates an existing project
-func (c *Client) UpdateProject(id int, name, description, path, file, language string, priority int, status string) (*Project, error) {
-	url := fmt.Sprintf("%s/projects/%d", c.BaseURL, id)
-
+// UpdateProject updates an existing project, if it is still at version
+func (c *Client) UpdateProject(ctx context.Context, id, version int, name, description, path, file, language string, priority int, status string) (*Project, error) {
 	payload := map[string]interface{}{
 		"name":        name,
 		"description": description,
@@ -390,32 +402,10 @@ func (c *Client) UpdateProject(id int, name, description, path, file, language s
 		"priority":    priority,
 		"status":      status,
 	}
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update project: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode project: %w", err)
+	if err := c.doIfMatch(ctx, OpUpdateProject, http.MethodPut, fmt.Sprintf("/projects/%d", id), version, payload, http.StatusOK, &project); err != nil {
+		return nil, err
 	}
-
 	return &project, nil
 }
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for classifying failures with errors.Is
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("backend unavailable")
+	// ErrUnauthorized means the credentials are missing, invalid or expired
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// APIError describes a failed request to the projectarium-v2 API, either a
+// non-2xx response or a transport failure (StatusCode 0, Err set)
+type APIError struct {
+	Op         Operation
+	Method     string
+	URL        string
+	StatusCode int
+	// Code is the backend's machine-readable error code, if it sent one
+	Code string
+	// Message is the backend's error message, or the raw response body
+	Message string
+	// Fields holds per-field validation messages keyed by JSON field name
+	Fields map[string]string
+	// Err is the underlying transport error for requests that got no response
+	Err error
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("failed to %s: %v", e.Op.describe(), e.Err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "API error (status %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, ", code %s", e.Code)
+	}
+	b.WriteString(")")
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying transport error, if any
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches one of the sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnavailable:
+		if e.StatusCode == 0 {
+			// A request cancelled by the caller says nothing about the backend
+			return e.Err != nil && !errors.Is(e.Err, context.Canceled)
+		}
+		return e.StatusCode == http.StatusBadGateway ||
+			e.StatusCode == http.StatusServiceUnavailable ||
+			e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// NotSent reports whether a request failed before it reached the server,
+// because the connection couldn't be made. Such a request can be sent again
+// without repeating it; one that timed out or lost its connection may have
+// been carried out already.
+func NotSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// errorBody is the JSON error envelope returned by the backend
+type errorBody struct {
+	Error   string            `json:"error"`
+	Message string            `json:"message"`
+	Code    string            `json:"code"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// newResponseError builds an APIError from a non-2xx response body
+func newResponseError(op Operation, req *http.Request, statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		Op:         op,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+
+	var eb errorBody
+	if err := json.Unmarshal(body, &eb); err == nil {
+		apiErr.Code = eb.Code
+		apiErr.Fields = eb.Fields
+		switch {
+		case eb.Message != "":
+			apiErr.Message = eb.Message
+		case eb.Error != "":
+			apiErr.Message = eb.Error
+		}
+	}
+
+	return apiErr
+}
+
+// FieldErrors returns the per-field validation messages carried by err, if any
+func FieldErrors(err error) map[string]string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Fields
+	}
+	return nil
+}
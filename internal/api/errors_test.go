@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusSentinels(t *testing.T) {
+	sentinels := []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnavailable, ErrUnauthorized}
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusBadRequest, ErrValidation},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, nil},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusPreconditionFailed, ErrConflict},
+		{http.StatusUnprocessableEntity, ErrValidation},
+		{http.StatusInternalServerError, nil},
+		{http.StatusBadGateway, ErrUnavailable},
+		{http.StatusServiceUnavailable, ErrUnavailable},
+		{http.StatusGatewayTimeout, ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"message": "nope", "code": "E42", "fields": {"name": "required"}}`))
+			}))
+			defer srv.Close()
+			policy := DefaultRetryPolicy
+			policy.MaxAttempts = 1
+			client := NewClient(srv.URL, WithRetryPolicy(policy))
+
+			_, err := client.GetProject(context.Background(), 1)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != "nope" || apiErr.Code != "E42" {
+				t.Errorf("APIError = %+v, want the status and the body's message and code", apiErr)
+			}
+			if FieldErrors(err)["name"] != "required" {
+				t.Errorf("FieldErrors = %v, want the body's fields", FieldErrors(err))
+			}
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(err, %v) = %v", sentinel, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 1
+	client := NewClient(url, WithRetryPolicy(policy))
+
+	_, err := client.GetProjects(context.Background())
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("unreachable server: err = %v, want ErrUnavailable", err)
+	}
+	if !NotSent(err) {
+		t.Errorf("refused connection: NotSent(%v) = false, want true", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetProjects(ctx); errors.Is(err, ErrUnavailable) {
+		t.Errorf("cancelled request: err = %v, want it not to blame the backend", err)
+	}
+}
+
+func TestNonJSONErrorBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream exploded", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = 1
+	client := NewClient(srv.URL, WithRetryPolicy(policy))
+
+	_, err := client.GetProjects(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "upstream exploded" {
+		t.Errorf("err = %v, want the raw body as its message", err)
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles per attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts
+	MaxDelay time.Duration
+	// MaxRetryAfter caps how long a Retry-After header may make a request
+	// wait; a server asking for longer gets the error instead of a retry.
+	// Zero waits as long as asked.
+	MaxRetryAfter time.Duration
+	// Budget is the number of retries that may be spent before retries are
+	// suspended; each successful request refunds a tenth of a retry
+	Budget float64
+}
+
+// DefaultRetryPolicy is used by NewClient
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     200 * time.Millisecond,
+	MaxDelay:      5 * time.Second,
+	MaxRetryAfter: 30 * time.Second,
+	Budget:        10,
+}
+
+// retryCost is the number of budget tokens a retry spends; a successful
+// request refunds one. Counting in whole tokens keeps ten refunds from adding
+// up to slightly less than a retry.
+const retryCost = 10
+
+// WithRetryPolicy sets the retry policy
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = p
+		c.budget = newRetryBudget(p.Budget)
+	}
+}
+
+// WithIdempotencyKeys sends an Idempotency-Key header with create requests,
+// which makes them safe to retry
+func WithIdempotencyKeys() Option {
+	return func(c *Client) {
+		c.IdempotencyKeys = true
+	}
+}
+
+// retryBudget limits retries across all requests so that an outage does not
+// multiply the load on the backend
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens int
+	max    int
+}
+
+// newRetryBudget returns a budget allowing retries retries
+func newRetryBudget(retries float64) *retryBudget {
+	max := int(math.Round(retries * retryCost))
+	return &retryBudget{tokens: max, max: max}
+}
+
+// spend takes the tokens of a retry, reporting whether it is allowed
+func (b *retryBudget) spend() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < retryCost {
+		return false
+	}
+	b.tokens -= retryCost
+	return true
+}
+
+// refund returns a token after a successful request
+func (b *retryBudget) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.max, b.tokens+1)
+}
+
+// isIdempotent reports whether a method can be safely repeated
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response status is worth retrying
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Full jitter spreads retries from many clients apart
+	return time.Duration(mathrand.Int63n(int64(delay) + 1))
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// newIdempotencyKey returns a random key for the Idempotency-Key header
+func newIdempotencyKey() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries retries quickly enough for tests
+var fastRetries = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     time.Millisecond,
+	MaxDelay:      2 * time.Millisecond,
+	MaxRetryAfter: 2 * time.Second,
+	Budget:        10,
+}
+
+// failingServer answers the first failures requests with status and the
+// rest with ok, counting the requests it gets
+func failingServer(t *testing.T, failures int, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			for name, values := range header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		status      int
+		post        bool
+		idempotency bool
+		wantCalls   int32
+		wantErr     error
+	}{
+		{"success", 0, 0, false, false, 1, nil},
+		{"unavailable then ok", 2, http.StatusServiceUnavailable, false, false, 3, nil},
+		{"too many requests then ok", 1, http.StatusTooManyRequests, false, false, 2, nil},
+		{"gives up after max attempts", 10, http.StatusBadGateway, false, false, 4, ErrUnavailable},
+		{"client errors aren't retried", 10, http.StatusNotFound, false, false, 1, ErrNotFound},
+		{"creates aren't retried", 1, http.StatusServiceUnavailable, true, false, 1, ErrUnavailable},
+		{"creates with idempotency keys are", 1, http.StatusServiceUnavailable, true, true, 2, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := failingServer(t, tt.failures, tt.status, nil)
+			opts := []Option{WithRetryPolicy(fastRetries)}
+			if tt.idempotency {
+				opts = append(opts, WithIdempotencyKeys())
+			}
+			client := NewClient(srv.URL, opts...)
+
+			var err error
+			if tt.post {
+				_, err = client.CreateTodo(context.Background(), "todo", 0, nil)
+			} else {
+				_, err = client.GetTodo(context.Background(), 1)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("err = %v, want success", err)
+			} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyKeptAcrossRetries(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL, WithRetryPolicy(fastRetries), WithIdempotencyKeys())
+	if _, err := client.CreateProject(context.Background(), "pj", "", "", "", "", 0, "ready"); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Idempotency-Key headers = %q, want the same key on both attempts", keys)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	srv, calls := failingServer(t, 100, http.StatusServiceUnavailable, nil)
+	policy := fastRetries
+	policy.Budget = 2
+	client := NewClient(srv.URL, WithRetryPolicy(policy))
+
+	// The first request spends the whole budget, so the second isn't retried
+	client.GetProject(context.Background(), 1)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("first request made %d attempts, want 3", got)
+	}
+	client.GetProject(context.Background(), 1)
+	if got := calls.Load(); got != 4 {
+		t.Errorf("second request made %d attempts, want 1", got-3)
+	}
+
+	b := newRetryBudget(1)
+	if !b.spend() || b.spend() {
+		t.Fatal("budget of 1 didn't allow exactly one retry")
+	}
+	for range 10 {
+		b.refund()
+	}
+	if !b.spend() {
+		t.Error("ten successes didn't refund a retry")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{40, time.Second},
+	}
+	for _, tt := range tests {
+		seen := make(map[time.Duration]bool)
+		for range 50 {
+			d := p.backoff(tt.attempt)
+			if d < 0 || d > tt.ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [0, %v]", tt.attempt, d, tt.ceiling)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("backoff(%d) always %v, want it jittered", tt.attempt, p.backoff(tt.attempt))
+		}
+	}
+
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("backoff without delays = %v, want 0", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{"Retry-After": {"1"}}
+	srv, calls := failingServer(t, 1, http.StatusServiceUnavailable, header)
+	client := NewClient(srv.URL, WithRetryPolicy(fastRetries))
+
+	start := time.Now()
+	if _, err := client.GetProject(context.Background(), 1); err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the server's second", elapsed)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+}
+
+func TestRetryAfterCeiling(t *testing.T) {
+	header := http.Header{"Retry-After": {"3600"}}
+	srv, calls := failingServer(t, 1, http.StatusServiceUnavailable, header)
+	client := NewClient(srv.URL, WithRetryPolicy(fastRetries))
+
+	start := time.Now()
+	_, err := client.GetProject(context.Background(), 1)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v for a Retry-After past the ceiling", elapsed)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server got %d requests, want no retry", got)
+	}
+}
@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 // Project represents a kanban project
 // Matches the backend API model in projectarium-v2/internal/models/project.go
 type Project struct {
@@ -11,6 +13,12 @@ type Project struct {
 	Priority    int    `json:"priority"`
 	Status      string `json:"status"`
 	Language    string `json:"language"`
+	// UpdatedAt is when the project last changed; zero if the backend
+	// doesn't report it
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+	// Version is bumped by the backend on every change; zero if it doesn't
+	// track versions
+	Version int `json:"version,omitempty"`
 }
 
 // Todo represents a task/todo item in a kanban board
@@ -21,6 +29,14 @@ type Todo struct {
 	Priority    int    `json:"priority"`
 	Deleted     bool   `json:"deleted"`
 	ProjectID   *int   `json:"project_id"`
+	Completed   bool   `json:"completed"`
+	// Notes is an optional long-form body in markdown
+	Notes string `json:"notes,omitempty"`
+	// CompletedAt is when the todo was marked done; zero while it is open
+	CompletedAt time.Time `json:"completed_at,omitzero"`
+	// Version is bumped by the backend on every change; zero if it doesn't
+	// track versions
+	Version int `json:"version,omitempty"`
 }
 
 // Column represents a kanban column/status for display in the TUI
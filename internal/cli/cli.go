@@ -0,0 +1,224 @@
+// Package cli implements the non-interactive subcommands of pj-tui, for
+// driving projectarium from scripts, git hooks and cron
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+// Exit codes returned by Run
+const (
+	ExitOK           = 0
+	ExitError        = 1 // any other failure
+	ExitUsage        = 2 // bad command line
+	ExitNotFound     = 3 // the project or todo does not exist
+	ExitInvalid      = 4 // the API rejected the request as invalid or conflicting
+	ExitUnavailable  = 5 // the API could not be reached
+	ExitUnauthorized = 6 // the API rejected the credentials
+)
+
+// errUsage is an error in the command line itself
+type errUsage struct {
+	msg string
+}
+
+func (e *errUsage) Error() string {
+	return e.msg
+}
+
+// usageError reports a bad command line
+func usageError(format string, args ...interface{}) error {
+	return &errUsage{msg: fmt.Sprintf(format, args...)}
+}
+
+// env is what a subcommand runs against
+type env struct {
+	ctx     context.Context
+	backend api.Backend
+	config  *config.Config
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// command is a subcommand; run receives the arguments after its name
+type command struct {
+	name string
+	run  func(e *env, args []string) error
+}
+
+// commands returns the subcommands, grouped by the noun they act on
+func commands() map[string][]command {
+	return map[string][]command{
+		"projects": {
+			{"list", projectsList},
+		},
+		"project": {
+			{"list", projectsList},
+			{"show", projectShow},
+			{"add", projectAdd},
+			{"edit", projectEdit},
+			{"move", projectMove},
+			{"priority", projectPriority},
+		},
+		"todos": {
+			{"list", todosList},
+		},
+		"todo": {
+			{"list", todosList},
+			{"add", todoAdd},
+		},
+	}
+}
+
+// IsCommand reports whether args start with a subcommand rather than
+// leaving the TUI to start
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	_, ok := commands()[args[0]]
+	return ok || args[0] == "help" || args[0] == "mock-server"
+}
+
+// Run executes the subcommand in args and returns the process exit code
+func Run(ctx context.Context, backend api.Backend, cfg *config.Config, args []string, stdout, stderr io.Writer) int {
+	e := &env{ctx: ctx, backend: backend, config: cfg, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 || args[0] == "help" {
+		Usage(stdout)
+		return ExitOK
+	}
+
+	// The mock server stands in for the API rather than using it
+	if args[0] == "mock-server" {
+		err := mockServer(e, args[1:])
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "pj-tui mock-server: %v\n", err)
+		}
+		return exitCode(err)
+	}
+
+	group, ok := commands()[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "pj-tui: unknown command %q\n", args[0])
+		Usage(stderr)
+		return ExitUsage
+	}
+
+	// "projects" alone lists them, like "projects list"
+	name := "list"
+	rest := args[1:]
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		name, rest = rest[0], rest[1:]
+	}
+	for _, cmd := range group {
+		if cmd.name == name {
+			err := cmd.run(e, rest)
+			if err != nil && !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(stderr, "pj-tui %s %s: %v\n", args[0], name, err)
+			}
+			return exitCode(err)
+		}
+	}
+
+	fmt.Fprintf(stderr, "pj-tui: unknown command %q\n", args[0]+" "+name)
+	Usage(stderr)
+	return ExitUsage
+}
+
+// exitCode maps an error to the exit code scripts can act on
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, new(*errUsage)):
+		return ExitUsage
+	case errors.Is(err, api.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, api.ErrValidation), errors.Is(err, api.ErrConflict):
+		return ExitInvalid
+	case errors.Is(err, api.ErrUnavailable):
+		return ExitUnavailable
+	case errors.Is(err, api.ErrUnauthorized):
+		return ExitUnauthorized
+	}
+	return ExitError
+}
+
+// Usage prints the list of subcommands
+func Usage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  pj-tui [flags]                      start the interactive board
+  pj-tui [flags] <command> [args]     run a command and exit
+
+Commands:
+  projects list [--status S] [--filter FILTER]   (also: project list)
+  project show PROJECT
+  project add NAME [--description D] [--path P] [--file F] [--language L] [--priority N] [--status S]
+  project edit PROJECT [--name N] [--description D] [--path P] [--file F] [--language L] [--priority N] [--status S]
+  project move PROJECT STATUS
+  project priority PROJECT N
+  todos list [--project PROJECT]                 (also: todo list)
+  todo add [--project PROJECT] [--priority N] TEXT
+  mock-server [--addr ADDR] [--fixture FILE]...  serve a fake API for development
+
+PROJECT is a project ID or name; todo add without --project puts the todo in
+the inbox. FILTER is a filter named in the config file or an expression such
+as 'lang:go prio:>=2 -status:finished'.
+
+Commands printing projects or todos accept --json, or --format with a Go
+template such as '{{.ID}} {{.Name}}'.
+
+Exit codes: 0 ok, 1 error, 2 usage, 3 not found, 4 rejected as invalid,
+5 API unavailable, 6 unauthorized.
+`)
+}
+
+// newFlagSet creates the flag set of a subcommand, with errors returned
+// rather than exiting
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("pj-tui "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after
+// positional arguments, returning the positional ones
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError("%v", err)
+		}
+		rest := fs.Args()
+		// Everything after "--" is positional, even if it looks like a flag
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// wantArgs checks the number of positional arguments
+func wantArgs(args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usageError("expected %s", strings.Join(names, " "))
+	}
+	return nil
+}
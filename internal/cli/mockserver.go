@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/mockserver"
+)
+
+// mockServer serves the fake projectarium API until interrupted
+func mockServer(e *env, args []string) error {
+	fs := newFlagSet(e, "mock-server")
+	addr := fs.String("addr", ":8888", "address to listen on")
+	var fixtures stringList
+	fs.Var(&fixtures, "fixture", "JSON file of projects and todos to start with, repeatable (default: demo data)")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(rest); err != nil {
+		return err
+	}
+
+	fixture := mockserver.Demo()
+	if len(fixtures) > 0 {
+		if fixture, err = mockserver.LoadFixture(fixtures...); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: mockserver.New(fixture)}
+	go func() {
+		<-e.ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	url := fmt.Sprintf("http://%s/api", listener.Addr())
+	if tcp, ok := listener.Addr().(*net.TCPAddr); ok && tcp.IP.IsUnspecified() {
+		url = fmt.Sprintf("http://localhost:%d/api", tcp.Port)
+	}
+	fmt.Fprintf(e.stdout, "Mock projectarium API serving %d projects and %d todos at %s\n",
+		len(fixture.Projects), len(fixture.Todos), url)
+	fmt.Fprintln(e.stdout, "Press Ctrl+C to stop")
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
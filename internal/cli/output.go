@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"text/template"
+)
+
+// outputFlags are the --json and --format flags shared by commands that
+// print projects or todos
+type outputFlags struct {
+	json   bool
+	format string
+}
+
+// register defines the output flags on fs
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.json, "json", false, "print JSON")
+	fs.StringVar(&o.format, "format", "", "print each item with a Go template, e.g. '{{.ID}} {{.Name}}'")
+}
+
+// printer writes items as JSON, through a template, or as a table
+type printer struct {
+	w        io.Writer
+	json     bool
+	template *template.Template
+}
+
+// printer validates the output flags and returns a printer for them
+func (o *outputFlags) printer(w io.Writer) (*printer, error) {
+	p := &printer{w: w, json: o.json}
+	if o.format != "" {
+		if o.json {
+			return nil, usageError("--json and --format are mutually exclusive")
+		}
+		tmpl, err := template.New("format").Parse(o.format)
+		if err != nil {
+			return nil, usageError("invalid --format: %v", err)
+		}
+		p.template = tmpl
+	}
+	return p, nil
+}
+
+// printList prints items, with header and row used for the table
+func printList[T any](p *printer, items []T, header string, row func(T) string) error {
+	switch {
+	case p.json:
+		if items == nil {
+			items = []T{}
+		}
+		return p.encode(items)
+	case p.template != nil:
+		for _, item := range items {
+			if err := p.execute(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, header)
+	for _, item := range items {
+		fmt.Fprintln(tw, row(item))
+	}
+	return tw.Flush()
+}
+
+// printItem prints a single item, with text used when no format is chosen
+func printItem[T any](p *printer, item T, text string) error {
+	switch {
+	case p.json:
+		return p.encode(item)
+	case p.template != nil:
+		return p.execute(item)
+	}
+	_, err := fmt.Fprintln(p.w, text)
+	return err
+}
+
+func (p *printer) encode(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p *printer) execute(item interface{}) error {
+	if err := p.template.Execute(p.w, item); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(p.w)
+	return err
+}
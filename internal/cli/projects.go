@@ -0,0 +1,332 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+	"github.com/sean-obeirne/projectarium-tui/internal/filter"
+)
+
+// projectRow formats a project for the table output
+func projectRow(workflow config.Workflow) func(api.Project) string {
+	return func(p api.Project) string {
+		return fmt.Sprintf("%d\t%s\t%s\t%d\t%s", p.ID, p.Name, workflow.DisplayName(p.Status), p.Priority, p.Language)
+	}
+}
+
+const projectHeader = "ID\tNAME\tSTATUS\tPRIORITY\tLANGUAGE"
+
+func projectsList(e *env, args []string) error {
+	fs := newFlagSet(e, "projects list")
+	var out outputFlags
+	out.register(fs)
+	status := fs.String("status", "", "only list projects in this status or column")
+	filterRef := fs.String("filter", "", "only list projects matching a named filter or filter expression")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+	var f *filter.Filter
+	if *filterRef != "" {
+		if f, err = filter.Resolve(*filterRef, e.config.Filters, e.config.Workflow); err != nil {
+			return usageError("invalid --filter: %v", err)
+		}
+	}
+
+	projects, err := e.backend.GetProjects(e.ctx)
+	if err != nil {
+		return err
+	}
+	if *status != "" {
+		want, err := resolveStatus(e.config.Workflow, *status)
+		if err != nil {
+			return err
+		}
+		column := e.config.Workflow.ColumnIndex(want)
+		var filtered []api.Project
+		for _, project := range projects {
+			if e.config.Workflow.ColumnIndex(project.Status) == column {
+				filtered = append(filtered, project)
+			}
+		}
+		projects = filtered
+	}
+	projects = f.Apply(projects)
+	return printList(p, projects, projectHeader, projectRow(e.config.Workflow))
+}
+
+func projectShow(e *env, args []string) error {
+	fs := newFlagSet(e, "project show")
+	var out outputFlags
+	out.register(fs)
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, "PROJECT"); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	project, err := findProject(e, args[0])
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("%s (#%d)\nStatus:      %s\nPriority:    %d\nLanguage:    %s\nPath:        %s\nFile:        %s\nDescription: %s",
+		project.Name, project.ID, e.config.Workflow.DisplayName(project.Status), project.Priority,
+		project.Language, project.Path, project.File, project.Description)
+	return printItem(p, *project, text)
+}
+
+// projectFields are the flags setting a project's fields
+type projectFields struct {
+	name, description, path, file, language, status string
+	priority                                        int
+}
+
+func (f *projectFields) register(fs *flag.FlagSet, withName bool) {
+	if withName {
+		fs.StringVar(&f.name, "name", "", "name")
+	}
+	fs.StringVar(&f.description, "description", "", "description")
+	fs.StringVar(&f.path, "path", "", "project directory")
+	fs.StringVar(&f.file, "file", "", "default file")
+	fs.StringVar(&f.language, "language", "", "language")
+	fs.IntVar(&f.priority, "priority", -1, "priority, 0-3")
+	fs.StringVar(&f.status, "status", "", "status or column name")
+}
+
+func projectAdd(e *env, args []string) error {
+	fs := newFlagSet(e, "project add")
+	var out outputFlags
+	out.register(fs)
+	var f projectFields
+	f.register(fs, false)
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, "NAME"); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	priority := e.config.Defaults.ProjectPriority
+	if f.priority >= 0 {
+		priority = f.priority
+	}
+	if err := checkPriority(priority); err != nil {
+		return err
+	}
+	status := e.config.Defaults.ProjectStatus
+	if status == "" {
+		status = e.config.Workflow.Columns[0].Status
+	}
+	if f.status != "" {
+		if status, err = resolveStatus(e.config.Workflow, f.status); err != nil {
+			return err
+		}
+	}
+
+	project, err := e.backend.CreateProject(e.ctx, args[0], f.description, f.path, f.file, f.language, priority, status)
+	if err != nil {
+		return err
+	}
+	return printItem(p, *project, fmt.Sprintf("Created project #%d %q", project.ID, project.Name))
+}
+
+func projectEdit(e *env, args []string) error {
+	fs := newFlagSet(e, "project edit")
+	var out outputFlags
+	out.register(fs)
+	var f projectFields
+	f.register(fs, true)
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, "PROJECT"); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	project, err := findProject(e, args[0])
+	if err != nil {
+		return err
+	}
+
+	// Only the flags given change the project
+	updated := *project
+	var problem error
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "name":
+			updated.Name = f.name
+		case "description":
+			updated.Description = f.description
+		case "path":
+			updated.Path = f.path
+		case "file":
+			updated.File = f.file
+		case "language":
+			updated.Language = f.language
+		case "priority":
+			updated.Priority = f.priority
+			if err := checkPriority(f.priority); err != nil {
+				problem = err
+			}
+		case "status":
+			status, err := resolveStatus(e.config.Workflow, f.status)
+			if err != nil {
+				problem = err
+			}
+			updated.Status = status
+		}
+	})
+	if problem != nil {
+		return problem
+	}
+	if updated.Name == "" {
+		return usageError("name must not be empty")
+	}
+
+	// Conditional on the version read above, so a concurrent edit isn't lost
+	result, err := e.backend.UpdateProject(e.ctx, updated.ID, updated.Version, updated.Name, updated.Description,
+		updated.Path, updated.File, updated.Language, updated.Priority, updated.Status)
+	if err != nil {
+		return err
+	}
+	return printItem(p, *result, fmt.Sprintf("Updated project #%d %q", result.ID, result.Name))
+}
+
+func projectMove(e *env, args []string) error {
+	fs := newFlagSet(e, "project move")
+	var out outputFlags
+	out.register(fs)
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, "PROJECT", "STATUS"); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	status, err := resolveStatus(e.config.Workflow, args[1])
+	if err != nil {
+		return err
+	}
+	project, err := findProject(e, args[0])
+	if err != nil {
+		return err
+	}
+	result, err := e.backend.UpdateProjectStatus(e.ctx, project.ID, status)
+	if err != nil {
+		return err
+	}
+	return printItem(p, *result, fmt.Sprintf("Moved project #%d %q to %s",
+		result.ID, result.Name, e.config.Workflow.DisplayName(result.Status)))
+}
+
+func projectPriority(e *env, args []string) error {
+	fs := newFlagSet(e, "project priority")
+	var out outputFlags
+	out.register(fs)
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args, "PROJECT", "N"); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	priority, err := strconv.Atoi(args[1])
+	if err != nil {
+		return usageError("priority must be a number, got %q", args[1])
+	}
+	if err := checkPriority(priority); err != nil {
+		return err
+	}
+	project, err := findProject(e, args[0])
+	if err != nil {
+		return err
+	}
+	result, err := e.backend.UpdateProjectPriority(e.ctx, project.ID, priority)
+	if err != nil {
+		return err
+	}
+	return printItem(p, *result, fmt.Sprintf("Set priority of project #%d %q to %d", result.ID, result.Name, result.Priority))
+}
+
+// findProject looks a project up by ID, or else by case-insensitive name
+func findProject(e *env, ref string) (*api.Project, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return e.backend.GetProject(e.ctx, id)
+	}
+
+	projects, err := e.backend.GetProjects(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []api.Project
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, ref) {
+			matches = append(matches, project)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("project %q: %w", ref, api.ErrNotFound)
+	case 1:
+		return &matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, project := range matches {
+		ids[i] = strconv.Itoa(project.ID)
+	}
+	return nil, usageError("%d projects are named %q; use an ID (%s)", len(matches), ref, strings.Join(ids, ", "))
+}
+
+// resolveStatus accepts a status, alias or column name and returns the
+// status of its column
+func resolveStatus(workflow config.Workflow, s string) (string, error) {
+	if status, ok := workflow.Resolve(s); ok {
+		return status, nil
+	}
+	return "", usageError("unknown status %q (want one of %s)", s, strings.Join(workflow.Statuses(), ", "))
+}
+
+// checkPriority validates a priority given on the command line
+func checkPriority(priority int) error {
+	if priority < 0 || priority > 3 {
+		return usageError("priority must be between 0 and 3, got %d", priority)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+const todoHeader = "ID\tPRIORITY\tDONE\tPROJECT\tDESCRIPTION"
+
+func todosList(e *env, args []string) error {
+	fs := newFlagSet(e, "todos list")
+	var out outputFlags
+	out.register(fs)
+	projectRef := fs.String("project", "", "only list todos of this project")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := wantArgs(args); err != nil {
+		return err
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	var todos []api.Todo
+	names := make(map[int]string)
+	if *projectRef != "" {
+		project, err := findProject(e, *projectRef)
+		if err != nil {
+			return err
+		}
+		names[project.ID] = project.Name
+		if todos, err = e.backend.GetTodosByProject(e.ctx, project.ID); err != nil {
+			return err
+		}
+	} else {
+		if todos, err = e.backend.GetTodos(e.ctx); err != nil {
+			return err
+		}
+		// Project names are only needed for the table
+		if !out.json && out.format == "" {
+			projects, err := e.backend.GetProjects(e.ctx)
+			if err != nil {
+				return err
+			}
+			for _, project := range projects {
+				names[project.ID] = project.Name
+			}
+		}
+	}
+
+	// Deleted todos are kept by the API, but are gone as far as users go
+	live := todos[:0]
+	for _, t := range todos {
+		if !t.Deleted {
+			live = append(live, t)
+		}
+	}
+
+	return printList(p, live, todoHeader, func(t api.Todo) string {
+		project := "-"
+		if t.ProjectID != nil {
+			project = names[*t.ProjectID]
+		}
+		done := "no"
+		if t.Completed {
+			done = "yes"
+		}
+		return fmt.Sprintf("%d\t%d\t%s\t%s\t%s", t.ID, t.Priority, done, project, t.Description)
+	})
+}
+
+func todoAdd(e *env, args []string) error {
+	fs := newFlagSet(e, "todo add")
+	var out outputFlags
+	out.register(fs)
+	projectRef := fs.String("project", "", "project ID or name; without it the todo goes to the inbox")
+	priority := fs.Int("priority", -1, "priority, 0-3")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return usageError("expected TEXT")
+	}
+	p, err := out.printer(e.stdout)
+	if err != nil {
+		return err
+	}
+
+	// Unquoted words are joined, so `todo add --project x fix the build` works
+	description := strings.Join(args, " ")
+	if *priority < 0 {
+		*priority = e.config.Defaults.TodoPriority
+	}
+	if err := checkPriority(*priority); err != nil {
+		return err
+	}
+
+	if *projectRef == "" {
+		todo, err := e.backend.CreateTodo(e.ctx, description, *priority, nil)
+		if err != nil {
+			return err
+		}
+		return printItem(p, *todo, fmt.Sprintf("Added todo #%d to the inbox", todo.ID))
+	}
+
+	project, err := findProject(e, *projectRef)
+	if err != nil {
+		return err
+	}
+	todo, err := e.backend.CreateTodo(e.ctx, description, *priority, &project.ID)
+	if err != nil {
+		return err
+	}
+	return printItem(p, *todo, fmt.Sprintf("Added todo #%d to %q", todo.ID, project.Name))
+}
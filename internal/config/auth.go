@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretEnv is the environment variable holding the auth secret
+// when no other source is configured
+const DefaultSecretEnv = "PROJECTARIUM_AUTH_TOKEN"
+
+// AuthConfig describes how to authenticate with the API
+type AuthConfig struct {
+	// Scheme is "bearer", "basic" or "header"; empty disables authentication
+	Scheme string
+	// Username is sent with basic auth
+	Username string
+	// Header is the header name used by the "header" scheme
+	Header string
+	// SecretEnv names the environment variable holding the secret
+	SecretEnv string
+	// SecretFile is a file whose contents are the secret
+	SecretFile string
+	// SecretCommand is a shell command printing the secret, e.g. "pass show api/projectarium"
+	SecretCommand string
+}
+
+// Enabled reports whether authentication is configured
+func (a AuthConfig) Enabled() bool {
+	return a.Scheme != ""
+}
+
+// Validate checks that the scheme is known and has what it needs
+func (a AuthConfig) Validate() error {
+	switch a.Scheme {
+	case "", "bearer", "basic":
+		return nil
+	case "header":
+		if a.Header == "" {
+			return fmt.Errorf("the header scheme needs a header name")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown auth scheme %q (want bearer, basic or header)", a.Scheme)
+}
+
+// Secret reads the secret from the configured command, file or environment
+// variable, in that order of preference
+func (a AuthConfig) Secret() (string, error) {
+	switch {
+	case a.SecretCommand != "":
+		cmd := exec.Command("sh", "-c", a.SecretCommand)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("secret command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		// Like pass, the secret is the first line of output
+		line, _, _ := strings.Cut(string(out), "\n")
+		return strings.TrimSpace(line), nil
+
+	case a.SecretFile != "":
+		data, err := os.ReadFile(expandHome(a.SecretFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	default:
+		name := a.SecretEnv
+		if name == "" {
+			name = DefaultSecretEnv
+		}
+		return os.Getenv(name), nil
+	}
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
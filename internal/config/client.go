@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/filestore"
+)
+
+// Backend returns where projects and todos are kept: the local data file if
+// one is configured, otherwise the projectarium API
+func (c *Config) Backend() (api.Backend, error) {
+	if c.DataFile != "" {
+		return filestore.Open(c.DataFile)
+	}
+	return api.NewClient(c.APIBaseURL, c.ClientOptions()...), nil
+}
+
+// ClientOptions returns the API client options for the configured timeouts,
+// retries and authentication
+func (c *Config) ClientOptions() []api.Option {
+	opts := []api.Option{}
+	if c.APITimeout > 0 {
+		opts = append(opts, api.WithTimeout(c.APITimeout))
+	}
+	for op, timeout := range c.APITimeouts {
+		opts = append(opts, api.WithOperationTimeout(api.Operation(op), timeout))
+	}
+	if c.APIMaxAttempts > 0 {
+		policy := api.DefaultRetryPolicy
+		policy.MaxAttempts = c.APIMaxAttempts
+		opts = append(opts, api.WithRetryPolicy(policy))
+	}
+	if c.APIIdempotencyKeys {
+		opts = append(opts, api.WithIdempotencyKeys())
+	}
+	if c.Auth.Enabled() {
+		opts = append(opts, api.WithAuth(&api.Auth{
+			Scheme:   api.AuthScheme(c.Auth.Scheme),
+			Username: c.Auth.Username,
+			Header:   c.Auth.Header,
+			Source:   c.Auth.Secret,
+		}))
+	}
+	return opts
+}
@@ -1,67 +1,467 @@
 package config
 
 import (
+	"flag"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/filestore"
+	"github.com/sean-obeirne/projectarium-tui/internal/filter"
 )
 
+// DefaultAPIURL is the projectarium-v2 endpoint used when none is configured
+const DefaultAPIURL = "http://localhost:8888/api"
+
 // Config holds the application configuration
 type Config struct {
+	// Path is the config file that was loaded, empty if there was none
+	Path string
+	// DataFile is the local file projects and todos are kept in instead of
+	// the projectarium API; empty uses the API
+	DataFile   string
 	APIBaseURL string
+	// APITimeout is the default timeout for a single API request
+	APITimeout time.Duration
+	// APITimeouts overrides APITimeout per operation (e.g. "get_projects")
+	APITimeouts map[string]time.Duration
+	// APIMaxAttempts is the number of attempts for retryable requests (0 = default)
+	APIMaxAttempts int
+	// APIIdempotencyKeys sends Idempotency-Key headers so creates can be retried
+	APIIdempotencyKeys bool
+	// Offline caches what the TUI reads and queues changes while the API
+	// is unreachable
+	Offline bool
+	// Auth configures API authentication
+	Auth AuthConfig
+	// Workflow defines the kanban columns and their statuses
+	Workflow Workflow
+	// Keys configures keybindings
+	Keys KeysConfig
+	// Theme selects the color theme
+	Theme ThemeConfig
+	// Defaults are the initial values for new projects and todos
+	Defaults Defaults
+	// Filters are named filter expressions selectable on the board
+	Filters map[string]string
+	// Launch holds the commands run for projects from the board
+	Launch LaunchConfig
+	// Warnings are the settings that were ignored, like unknown keys
+	Warnings []Problem
+}
+
+// LaunchConfig holds the shell commands the launch action runs in a
+// project's directory
+type LaunchConfig struct {
+	// Command is run for projects without a command of their own; empty
+	// leaves them with nothing to launch
+	Command string
+	// Projects maps project names to their own commands
+	Projects map[string]string
+}
+
+// CommandFor returns the launch command for the named project, empty if
+// there is none
+func (l LaunchConfig) CommandFor(project string) string {
+	if command, ok := l.Projects[project]; ok {
+		return command
+	}
+	return l.Command
+}
+
+// KeysConfig selects a keybinding preset and per-action overrides
+type KeysConfig struct {
+	// Preset is the name of the base keymap; empty uses the default
+	Preset string
+	// Bindings maps action names to the keys that trigger them
+	Bindings map[string][]string
+}
+
+// ThemeConfig selects a built-in theme or a theme file
+type ThemeConfig struct {
+	// Name is a built-in theme name; empty uses the default
+	Name string
+	// File is a theme file that takes precedence over Name
+	File string
+	// Palette is the contents of File, filled in by Load
+	Palette *ThemePalette
+}
+
+// Defaults are the initial values of fields when creating items
+type Defaults struct {
+	ProjectPriority int
+	// ProjectStatus must be one of the workflow's statuses; empty uses the
+	// first column
+	ProjectStatus string
+	TodoPriority  int
+	// Filter is the named filter or filter expression applied to the board
+	// on startup; empty shows every project
+	Filter string
+	// Sort is the board's sort mode until another is picked on the board
+	Sort string
+	// HideCompleted leaves completed todos out of the todo list until they
+	// are shown from the list
+	HideCompleted bool
+}
+
+// Flags are command-line overrides applied on top of the config file and
+// environment
+type Flags struct {
+	ConfigPath string
+	Backend    string
+	APIURL     string
+	Timeout    time.Duration
+	Theme      string
+	Keys       string
+	Filter     string
+}
+
+// Register defines the override flags on fs
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.ConfigPath, "config", "", "config file (default "+DefaultPath()+")")
+	fs.StringVar(&f.Backend, "backend", "", `where projects and todos are kept: "api", "file" or "file:PATH"`)
+	fs.StringVar(&f.APIURL, "api-url", "", "projectarium API endpoint")
+	fs.DurationVar(&f.Timeout, "timeout", 0, "default API request timeout")
+	fs.StringVar(&f.Theme, "theme", "", "color theme")
+	fs.StringVar(&f.Keys, "keys", "", "keybinding preset")
+	fs.StringVar(&f.Filter, "filter", "", "named filter or filter expression for the board")
+}
+
+// legacyEnvFile is the KEY=VALUE file read before the TOML config existed
+const legacyEnvFile = ".config/pj-tui.env"
+
+// Keys recognised in the legacy env file
+var configKeys = map[string]bool{
+	"PROJECTARIUM_BACKEND":              true,
+	"PROJECTARIUM_API_URL":              true,
+	"PROJECTARIUM_API_TIMEOUT":          true,
+	"PROJECTARIUM_API_TIMEOUTS":         true,
+	"PROJECTARIUM_API_RETRIES":          true,
+	"PROJECTARIUM_API_IDEMPOTENCY_KEYS": true,
+	"PROJECTARIUM_OFFLINE":              true,
+	"PROJECTARIUM_AUTH":                 true,
+	"PROJECTARIUM_AUTH_USER":            true,
+	"PROJECTARIUM_AUTH_HEADER":          true,
+	"PROJECTARIUM_AUTH_SECRET_ENV":      true,
+	"PROJECTARIUM_AUTH_SECRET_FILE":     true,
+	"PROJECTARIUM_AUTH_SECRET_CMD":      true,
+	"PROJECTARIUM_COLUMNS":              true,
+	"PROJECTARIUM_THEME":                true,
+	"PROJECTARIUM_KEYS":                 true,
+	"PROJECTARIUM_FILTER":               true,
+	"PROJECTARIUM_LAUNCH_CMD":           true,
 }
 
-// Load loads configuration from environment variables
-func Load() *Config {
-	// Try config file first
-	configFile := os.Getenv("HOME") + "/.config/pj-tui.env"
-	if _, err := os.Stat(configFile); err == nil {
-		// Read config file
-		if data, err := os.ReadFile(configFile); err == nil {
-			// Simple parsing of KEY=VALUE format
-			for _, line := range splitLines(string(data)) {
-				if len(line) > 0 && line[0] != '#' {
-					if idx := findChar(line, '='); idx != -1 {
-						key := line[:idx]
-						value := line[idx+1:]
-						if key == "PROJECTARIUM_API_URL" {
-							os.Setenv(key, value)
-						}
-					}
+// Load builds the configuration from the defaults, the config file, the
+// environment and flags, each layer overriding the one before. It always
+// returns a usable config; if any setting was invalid the error is an
+// *Error listing every problem. Ignored settings are listed in Warnings.
+func Load(flags Flags) (*Config, error) {
+	cfg := &Config{
+		APIBaseURL:  DefaultAPIURL,
+		APITimeouts: make(map[string]time.Duration),
+		Workflow:    DefaultWorkflow(),
+		Keys:        KeysConfig{Bindings: make(map[string][]string)},
+		Filters:     make(map[string]string),
+		Launch:      LaunchConfig{Projects: make(map[string]string)},
+	}
+	var problems problemList
+
+	var lines map[string]int
+	if flags.ConfigPath != "" {
+		lines = loadFile(cfg, flags.ConfigPath, true, &problems)
+	} else if path := DefaultPath(); path != "" {
+		lines = loadFile(cfg, path, false, &problems)
+	}
+
+	loadLegacyEnvFile(&problems)
+	loadEnv(cfg, &problems)
+	applyFlags(cfg, flags, &problems)
+
+	// The auth settings can come from different layers, so they are checked
+	// together once they are final
+	if err := cfg.Auth.Validate(); err != nil {
+		// Blame whoever set the header if the problem is its name, and
+		// whoever chose the scheme otherwise
+		source, line, key, ok := authSource(cfg.Path, lines, "header")
+		if cfg.Auth.Scheme != "header" || !ok {
+			source, line, key, _ = authSource(cfg.Path, lines, "scheme")
+		}
+		problems.add(source, line, key, err.Error())
+	}
+
+	if cfg.Theme.File != "" {
+		cfg.Theme.Palette = loadThemeFile(cfg.Theme.File, &problems)
+	}
+
+	// The default status can only be checked once the columns are final
+	if status := cfg.Defaults.ProjectStatus; status != "" && !cfg.Workflow.HasStatus(status) {
+		const key = "defaults.project_status"
+		problems.add(cfg.Path, lines[key], key, fmt.Sprintf("%q is not a status of any column", status))
+	}
+
+	// So can filters, whose status terms refer to columns
+	for _, name := range sortedKeys(cfg.Filters) {
+		if _, err := filter.Parse(cfg.Filters[name], cfg.Workflow); err != nil {
+			key := "filters." + name
+			problems.add(cfg.Path, lines[key], key, err.Error())
+		}
+	}
+	if ref := cfg.Defaults.Filter; ref != "" {
+		if _, ok := cfg.Filters[ref]; !ok {
+			if _, err := filter.Parse(ref, cfg.Workflow); err != nil {
+				// Blame the layer the filter came from
+				source, line, key := cfg.Path, lines["defaults.filter"], "defaults.filter"
+				if flags.Filter != "" {
+					source, line, key = "flags", 0, "-filter"
+				} else if _, ok := lookupEnv("PROJECTARIUM_FILTER"); ok {
+					source, line, key = "environment", 0, "PROJECTARIUM_FILTER"
 				}
+				problems.add(source, line, key, fmt.Sprintf("%q is not a named filter or a valid expression: %v", ref, err))
 			}
 		}
 	}
 
-	apiURL := os.Getenv("PROJECTARIUM_API_URL")
-	if apiURL == "" {
-		// Default to localhost
-		apiURL = "http://localhost:8888/api"
+	cfg.Warnings = problems.warnings()
+	return cfg, problems.err()
+}
+
+// authEnvKeys maps auth settings to the environment variables setting them
+var authEnvKeys = map[string]string{
+	"scheme": "PROJECTARIUM_AUTH",
+	"header": "PROJECTARIUM_AUTH_HEADER",
+}
+
+// authSource finds the layer that last set an auth setting, either the
+// environment or the config file at path
+func authSource(path string, lines map[string]int, setting string) (source string, line int, key string, ok bool) {
+	if envKey := authEnvKeys[setting]; envKey != "" {
+		if _, set := lookupEnv(envKey); set {
+			return "environment", 0, envKey, true
+		}
 	}
+	key = "api.auth." + setting
+	if line, set := lines[key]; set {
+		return path, line, key, true
+	}
+	return "", 0, "", false
+}
 
-	return &Config{
-		APIBaseURL: apiURL,
+// loadLegacyEnvFile exports the settings in ~/.config/pj-tui.env so they are
+// picked up by the environment layer. Variables already in the environment
+// win over the file, and unknown keys are skipped with a warning.
+func loadLegacyEnvFile(problems *problemList) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	path := home + "/" + legacyEnvFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
 	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			problems.add(path, i+1, "", "expected KEY=VALUE")
+			continue
+		}
+		if !configKeys[key] {
+			problems.warn(path, i+1, key, "unknown key, ignored")
+			continue
+		}
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+// loadEnv applies PROJECTARIUM_* environment variables on top of cfg
+func loadEnv(cfg *Config, problems *problemList) {
+	bad := func(key string, err error) {
+		problems.add("environment", 0, key, err.Error())
+	}
+
+	if v, ok := lookupEnv("PROJECTARIUM_BACKEND"); ok {
+		if path, err := parseBackend(v); err != nil {
+			bad("PROJECTARIUM_BACKEND", err)
+		} else {
+			cfg.DataFile = path
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_API_URL"); ok {
+		if err := validateURL(v); err != nil {
+			bad("PROJECTARIUM_API_URL", err)
+		} else {
+			cfg.APIBaseURL = v
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_API_TIMEOUT"); ok {
+		if d, err := parseDuration(v); err != nil {
+			bad("PROJECTARIUM_API_TIMEOUT", err)
+		} else {
+			cfg.APITimeout = d
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_API_TIMEOUTS"); ok {
+		if timeouts, err := parseTimeouts(v); err != nil {
+			bad("PROJECTARIUM_API_TIMEOUTS", err)
+		} else {
+			for op, d := range timeouts {
+				cfg.APITimeouts[op] = d
+			}
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_API_RETRIES"); ok {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 {
+			bad("PROJECTARIUM_API_RETRIES", fmt.Errorf("must be a number of at least 1, got %q", v))
+		} else {
+			cfg.APIMaxAttempts = n
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_API_IDEMPOTENCY_KEYS"); ok {
+		if b, err := strconv.ParseBool(v); err != nil {
+			bad("PROJECTARIUM_API_IDEMPOTENCY_KEYS", fmt.Errorf("must be true or false, got %q", v))
+		} else {
+			cfg.APIIdempotencyKeys = b
+		}
+	}
+	if v, ok := lookupEnv("PROJECTARIUM_OFFLINE"); ok {
+		if b, err := strconv.ParseBool(v); err != nil {
+			bad("PROJECTARIUM_OFFLINE", fmt.Errorf("must be true or false, got %q", v))
+		} else {
+			cfg.Offline = b
+		}
+	}
+
+	if v, ok := lookupEnv("PROJECTARIUM_AUTH"); ok {
+		cfg.Auth.Scheme = strings.ToLower(v)
+	}
+	envString(&cfg.Auth.Username, "PROJECTARIUM_AUTH_USER")
+	envString(&cfg.Auth.Header, "PROJECTARIUM_AUTH_HEADER")
+	envString(&cfg.Auth.SecretEnv, "PROJECTARIUM_AUTH_SECRET_ENV")
+	envString(&cfg.Auth.SecretFile, "PROJECTARIUM_AUTH_SECRET_FILE")
+	envString(&cfg.Auth.SecretCommand, "PROJECTARIUM_AUTH_SECRET_CMD")
+
+	if v, ok := lookupEnv("PROJECTARIUM_COLUMNS"); ok {
+		if w, err := parseColumns(v); err != nil {
+			bad("PROJECTARIUM_COLUMNS", err)
+		} else {
+			cfg.Workflow = w
+		}
+	}
+
+	envString(&cfg.Theme.Name, "PROJECTARIUM_THEME")
+	envString(&cfg.Keys.Preset, "PROJECTARIUM_KEYS")
+	envString(&cfg.Defaults.Filter, "PROJECTARIUM_FILTER")
+	envString(&cfg.Launch.Command, "PROJECTARIUM_LAUNCH_CMD")
 }
 
-func splitLines(s string) []string {
-	var lines []string
-	start := 0
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\n' {
-			lines = append(lines, s[start:i])
-			start = i + 1
+// applyFlags applies command-line overrides on top of cfg
+func applyFlags(cfg *Config, flags Flags, problems *problemList) {
+	if flags.Backend != "" {
+		if path, err := parseBackend(flags.Backend); err != nil {
+			problems.add("flags", 0, "-backend", err.Error())
+		} else {
+			cfg.DataFile = path
 		}
 	}
-	if start < len(s) {
-		lines = append(lines, s[start:])
+	if flags.APIURL != "" {
+		if err := validateURL(flags.APIURL); err != nil {
+			problems.add("flags", 0, "-api-url", err.Error())
+		} else {
+			cfg.APIBaseURL = flags.APIURL
+		}
+	}
+	if flags.Timeout < 0 {
+		problems.add("flags", 0, "-timeout", "must not be negative")
+	} else if flags.Timeout > 0 {
+		cfg.APITimeout = flags.Timeout
+	}
+	if flags.Theme != "" {
+		// An explicit theme name beats a theme file from lower layers
+		cfg.Theme = ThemeConfig{Name: flags.Theme}
 	}
-	return lines
+	if flags.Keys != "" {
+		cfg.Keys.Preset = flags.Keys
+	}
+	if flags.Filter != "" {
+		cfg.Defaults.Filter = flags.Filter
+	}
+}
+
+// lookupEnv returns a non-empty, trimmed environment variable
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
 }
 
-func findChar(s string, c byte) int {
-	for i := 0; i < len(s); i++ {
-		if s[i] == c {
-			return i
+// envString overrides dst when the environment variable is set
+func envString(dst *string, key string) {
+	if v, ok := lookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// validateURL checks that s is an absolute http(s) URL
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", s)
+	}
+	return nil
+}
+
+// parseBackend parses a backend setting: "api" for the projectarium API,
+// "file" for the default data file or "file:PATH". It returns the data file,
+// empty for the API.
+func parseBackend(s string) (string, error) {
+	kind, path, hasPath := strings.Cut(strings.TrimSpace(s), ":")
+	switch {
+	case kind == "api" && !hasPath:
+		return "", nil
+	case kind != "file":
+		return "", fmt.Errorf(`must be "api", "file" or "file:PATH", got %q`, s)
+	case !hasPath:
+		if path = filestore.DefaultPath(); path == "" {
+			return "", fmt.Errorf("no home directory for the data file; use file:PATH")
+		}
+		return path, nil
+	case strings.TrimSpace(path) == "":
+		return "", fmt.Errorf("file: needs a path")
+	}
+	return expandHome(strings.TrimSpace(path)), nil
+}
+
+// parseTimeouts parses "op=duration" pairs separated by commas,
+// e.g. "get_projects=5s,create_todo=20s"
+func parseTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		op, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected op=duration, got %q", pair)
+		}
+		op = strings.TrimSpace(op)
+		if !validOperation(op) {
+			return nil, fmt.Errorf("unknown operation %q", op)
+		}
+		d, err := parseDuration(value)
+		if err != nil {
+			return nil, err
 		}
+		timeouts[op] = d
 	}
-	return -1
+	return timeouts, nil
 }
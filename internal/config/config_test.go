@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadLegacyEnvFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	data := "PROJECTARIUM_API_URL=http://legacy:8888/api\nPROJECTARIUM_THEME=light\nPJ_COLOUR=red\n"
+	if err := os.MkdirAll(filepath.Join(home, ".config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, legacyEnvFile), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROJECTARIUM_API_URL", "http://real:8888/api")
+	t.Setenv("PROJECTARIUM_THEME", "")
+	os.Unsetenv("PROJECTARIUM_THEME")
+
+	var problems problemList
+	loadLegacyEnvFile(&problems)
+
+	if err := problems.err(); err != nil {
+		t.Fatalf("unknown key failed the load: %v", err)
+	}
+	if got := os.Getenv("PROJECTARIUM_API_URL"); got != "http://real:8888/api" {
+		t.Errorf("PROJECTARIUM_API_URL = %q, want the environment to win over the file", got)
+	}
+	if got := os.Getenv("PROJECTARIUM_THEME"); got != "light" {
+		t.Errorf("PROJECTARIUM_THEME = %q, want it taken from the file", got)
+	}
+	if w := problems.warnings(); len(w) != 1 || w[0].Key != "PJ_COLOUR" || w[0].Line != 3 {
+		t.Errorf("warnings = %v, want one for PJ_COLOUR on line 3", w)
+	}
+}
+
+// loadAuth loads a config file holding auth settings, with only the given
+// environment variables set
+func loadAuth(t *testing.T, auth string, env map[string]string) (*Config, string, error) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "PROJECTARIUM_") {
+			t.Setenv(key, "")
+		}
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[api.auth]\n"+auth), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(Flags{ConfigPath: path})
+	return cfg, path, err
+}
+
+func TestLoadAuthValidatedOnce(t *testing.T) {
+	_, path, err := loadAuth(t, "scheme = \"oauth\"\n", nil)
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) || len(cfgErr.Problems) != 1 {
+		t.Fatalf("err = %v, want one problem", err)
+	}
+	if p := cfgErr.Problems[0]; p.Source != path || p.Line != 2 || p.Key != "api.auth.scheme" {
+		t.Errorf("problem = %+v, want it blamed on the file's scheme", p)
+	}
+
+	_, _, err = loadAuth(t, "scheme = \"bearer\"\n", map[string]string{"PROJECTARIUM_AUTH": "oauth"})
+	if !errors.As(err, &cfgErr) || len(cfgErr.Problems) != 1 {
+		t.Fatalf("err = %v, want one problem", err)
+	}
+	if p := cfgErr.Problems[0]; p.Source != "environment" || p.Key != "PROJECTARIUM_AUTH" {
+		t.Errorf("problem = %+v, want it blamed on PROJECTARIUM_AUTH", p)
+	}
+}
+
+func TestLoadAuthAcrossLayers(t *testing.T) {
+	cfg, _, err := loadAuth(t, "scheme = \"header\"\n", map[string]string{"PROJECTARIUM_AUTH_HEADER": "X-Api-Key"})
+	if err != nil {
+		t.Fatalf("header name from the environment not used: %v", err)
+	}
+	if cfg.Auth.Scheme != "header" || cfg.Auth.Header != "X-Api-Key" {
+		t.Errorf("auth = %+v, want the header scheme with X-Api-Key", cfg.Auth)
+	}
+
+	_, path, err := loadAuth(t, "scheme = \"header\"\nheader = \"\"\n", nil)
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) || len(cfgErr.Problems) != 1 {
+		t.Fatalf("err = %v, want one problem", err)
+	}
+	if p := cfgErr.Problems[0]; p.Source != path || p.Line != 3 || p.Key != "api.auth.header" {
+		t.Errorf("problem = %+v, want it blamed on the file's empty header", p)
+	}
+}
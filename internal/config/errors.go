@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Problem is a single invalid or unknown setting
+type Problem struct {
+	// Source is the file the setting came from, or "environment"/"flags"
+	Source string
+	// Line is the line in Source, 0 when unknown
+	Line int
+	// Key is the setting, e.g. "api.timeout" or "PROJECTARIUM_API_URL"
+	Key     string
+	Message string
+	// Warning is set for problems that were ignored rather than failing
+	// the load, like unknown keys
+	Warning bool
+}
+
+// String formats the problem like a compiler diagnostic
+func (p Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.Source)
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d", p.Line)
+	}
+	b.WriteString(": ")
+	if p.Key != "" {
+		b.WriteString(p.Key + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Error reports every problem found while loading the configuration
+type Error struct {
+	Problems []Problem
+}
+
+// Error lists the problems, one per line
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// problemList collects problems while the layers are applied
+type problemList []Problem
+
+func (l *problemList) add(source string, line int, key, message string) {
+	*l = append(*l, Problem{Source: source, Line: line, Key: key, Message: message})
+}
+
+// warn records a problem that doesn't fail the load
+func (l *problemList) warn(source string, line int, key, message string) {
+	*l = append(*l, Problem{Source: source, Line: line, Key: key, Message: message, Warning: true})
+}
+
+// err returns the collected problems as an *Error, or nil if there were none
+func (l problemList) err() error {
+	errs := l.sorted(false)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{Problems: errs}
+}
+
+// warnings returns the problems that were only warned about
+func (l problemList) warnings() []Problem {
+	return l.sorted(true)
+}
+
+// sorted returns the warnings or the errors, keeping sources in the order
+// they were applied and lines in file order
+func (l problemList) sorted(warnings bool) []Problem {
+	var problems []Problem
+	order := make(map[string]int)
+	for _, p := range l {
+		if _, ok := order[p.Source]; !ok {
+			order[p.Source] = len(order)
+		}
+		if p.Warning == warnings {
+			problems = append(problems, p)
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Source != problems[j].Source {
+			return order[problems[i].Source] < order[problems[j].Source]
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
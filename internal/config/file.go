@@ -0,0 +1,363 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// fileConfig is the schema of config.toml. Optional scalars are pointers so
+// that settings left out of the file don't override the defaults.
+type fileConfig struct {
+	Backend *string `toml:"backend"`
+
+	API struct {
+		URL             *string           `toml:"url"`
+		Timeout         *string           `toml:"timeout"`
+		Timeouts        map[string]string `toml:"timeouts"`
+		Retries         *int              `toml:"retries"`
+		IdempotencyKeys *bool             `toml:"idempotency_keys"`
+		Offline         *bool             `toml:"offline"`
+		Auth            struct {
+			Scheme        *string `toml:"scheme"`
+			Username      *string `toml:"username"`
+			Header        *string `toml:"header"`
+			SecretEnv     *string `toml:"secret_env"`
+			SecretFile    *string `toml:"secret_file"`
+			SecretCommand *string `toml:"secret_command"`
+		} `toml:"auth"`
+	} `toml:"api"`
+
+	Keys struct {
+		Preset   *string             `toml:"preset"`
+		Bindings map[string][]string `toml:"bindings"`
+	} `toml:"keys"`
+
+	Theme struct {
+		Name *string `toml:"name"`
+		File *string `toml:"file"`
+	} `toml:"theme"`
+
+	Columns []struct {
+		Name    string   `toml:"name"`
+		Status  string   `toml:"status"`
+		Aliases []string `toml:"aliases"`
+		Color   string   `toml:"color"`
+	} `toml:"columns"`
+
+	Defaults struct {
+		ProjectPriority *int    `toml:"project_priority"`
+		ProjectStatus   *string `toml:"project_status"`
+		TodoPriority    *int    `toml:"todo_priority"`
+		Filter          *string `toml:"filter"`
+		Sort            *string `toml:"sort"`
+		HideCompleted   *bool   `toml:"hide_completed"`
+	} `toml:"defaults"`
+
+	Filters map[string]string `toml:"filters"`
+
+	Launch struct {
+		Command  *string           `toml:"command"`
+		Projects map[string]string `toml:"projects"`
+	} `toml:"launch"`
+}
+
+// DefaultPath returns the config file location, following the XDG base
+// directory spec: $XDG_CONFIG_HOME/pj-tui/config.toml, falling back to
+// ~/.config/pj-tui/config.toml
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "pj-tui", "config.toml")
+}
+
+// loadFile applies the config file at path on top of cfg, returning the line
+// of each key it set. A missing file is not an error unless required is set.
+func loadFile(cfg *Config, path string, required bool, problems *problemList) map[string]int {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		problems.add(path, 0, "", err.Error())
+		return nil
+	}
+	cfg.Path = path
+
+	var file fileConfig
+	err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(&file)
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		// Unknown keys are skipped with a warning; the rest still applies
+		for _, e := range strictErr.Errors {
+			row, _ := e.Position()
+			problems.warn(path, row, strings.Join(e.Key(), "."), "unknown key, ignored")
+		}
+		file = fileConfig{}
+		err = toml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, _ := decodeErr.Position()
+			problems.add(path, row, strings.Join(decodeErr.Key(), "."), decodeMessage(decodeErr))
+		} else {
+			problems.add(path, 0, "", err.Error())
+		}
+		return nil
+	}
+
+	lines := keyLines(data)
+	bad := func(key, format string, args ...interface{}) {
+		problems.add(path, lines[key], key, fmt.Sprintf(format, args...))
+	}
+
+	if v := file.Backend; v != nil {
+		if path, err := parseBackend(*v); err != nil {
+			bad("backend", "%v", err)
+		} else {
+			cfg.DataFile = path
+		}
+	}
+	if v := file.API.URL; v != nil {
+		if err := validateURL(*v); err != nil {
+			bad("api.url", "%v", err)
+		} else {
+			cfg.APIBaseURL = *v
+		}
+	}
+	if v := file.API.Timeout; v != nil {
+		if d, err := parseDuration(*v); err != nil {
+			bad("api.timeout", "%v", err)
+		} else {
+			cfg.APITimeout = d
+		}
+	}
+	for _, op := range sortedKeys(file.API.Timeouts) {
+		value := file.API.Timeouts[op]
+		key := "api.timeouts." + op
+		if !validOperation(op) {
+			bad(key, "unknown operation %q", op)
+			continue
+		}
+		if d, err := parseDuration(value); err != nil {
+			bad(key, "%v", err)
+		} else {
+			cfg.APITimeouts[op] = d
+		}
+	}
+	if v := file.API.Retries; v != nil {
+		if *v < 1 {
+			bad("api.retries", "must be at least 1")
+		} else {
+			cfg.APIMaxAttempts = *v
+		}
+	}
+	if v := file.API.IdempotencyKeys; v != nil {
+		cfg.APIIdempotencyKeys = *v
+	}
+	if v := file.API.Offline; v != nil {
+		cfg.Offline = *v
+	}
+
+	auth := file.API.Auth
+	setString(&cfg.Auth.Scheme, auth.Scheme)
+	setString(&cfg.Auth.Username, auth.Username)
+	setString(&cfg.Auth.Header, auth.Header)
+	setString(&cfg.Auth.SecretEnv, auth.SecretEnv)
+	setString(&cfg.Auth.SecretFile, auth.SecretFile)
+	setString(&cfg.Auth.SecretCommand, auth.SecretCommand)
+
+	setString(&cfg.Keys.Preset, file.Keys.Preset)
+	for _, action := range sortedKeys(file.Keys.Bindings) {
+		keys := file.Keys.Bindings[action]
+		if len(keys) == 0 {
+			bad("keys.bindings."+action, "must list at least one key")
+			continue
+		}
+		cfg.Keys.Bindings[action] = keys
+	}
+
+	setString(&cfg.Theme.Name, file.Theme.Name)
+	setString(&cfg.Theme.File, file.Theme.File)
+
+	if len(file.Columns) > 0 {
+		var w Workflow
+		for _, col := range file.Columns {
+			w.Columns = append(w.Columns, Column(col))
+		}
+		if err := w.Validate(); err != nil {
+			bad("columns", "%v", err)
+		} else {
+			cfg.Workflow = w
+		}
+	}
+
+	if v := file.Defaults.ProjectPriority; v != nil {
+		if err := validatePriority(*v); err != nil {
+			bad("defaults.project_priority", "%v", err)
+		} else {
+			cfg.Defaults.ProjectPriority = *v
+		}
+	}
+	setString(&cfg.Defaults.ProjectStatus, file.Defaults.ProjectStatus)
+	if v := file.Defaults.TodoPriority; v != nil {
+		if err := validatePriority(*v); err != nil {
+			bad("defaults.todo_priority", "%v", err)
+		} else {
+			cfg.Defaults.TodoPriority = *v
+		}
+	}
+	setString(&cfg.Defaults.Filter, file.Defaults.Filter)
+	setString(&cfg.Defaults.Sort, file.Defaults.Sort)
+	if v := file.Defaults.HideCompleted; v != nil {
+		cfg.Defaults.HideCompleted = *v
+	}
+
+	// Filter expressions are checked by Load once the columns are final
+	for name, expr := range file.Filters {
+		cfg.Filters[name] = expr
+	}
+
+	setString(&cfg.Launch.Command, file.Launch.Command)
+	for name, command := range file.Launch.Projects {
+		cfg.Launch.Projects[name] = command
+	}
+	return lines
+}
+
+// sortedKeys returns the keys of m in order, so problems are reported
+// deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setString overrides dst when the file sets the value
+func setString(dst *string, v *string) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// decodeMessage turns a TOML decode error into a short description
+func decodeMessage(err *toml.DecodeError) string {
+	msg := strings.TrimPrefix(err.Error(), "toml: ")
+	// Type mismatches name Go struct types; report just the TOML side
+	if rest, ok := strings.CutPrefix(msg, "cannot decode TOML "); ok {
+		kind, _, _ := strings.Cut(rest, " ")
+		return fmt.Sprintf("unexpected %s value", kind)
+	}
+	return msg
+}
+
+// validOperation reports whether op names an API operation
+func validOperation(op string) bool {
+	for _, known := range api.Operations {
+		if string(known) == op {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePriority checks that a priority is in the 0-3 range used by the API
+func validatePriority(p int) error {
+	if p < 0 || p > 3 {
+		return fmt.Errorf("priority must be between 0 and 3, got %d", p)
+	}
+	return nil
+}
+
+// keyLines maps the dotted path of every key in a TOML document to the line
+// it is defined on, so that values rejected after decoding can be reported
+// with a position. Entries of arrays of tables are numbered from 0, e.g.
+// "columns.1.status". Only the simple layouts used by config.toml are
+// understood; keys it can't place are reported without a line.
+func keyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	arrayIndex := make(map[string]int)
+	prefix := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[["):
+			name := tableName(strings.TrimPrefix(line, "[["), "]]")
+			index, seen := arrayIndex[name]
+			if seen {
+				index++
+			}
+			arrayIndex[name] = index
+			prefix = name + "." + strconv.Itoa(index)
+			lines[prefix] = row
+			if _, ok := lines[name]; !ok {
+				lines[name] = row
+			}
+		case strings.HasPrefix(line, "["):
+			prefix = tableName(strings.TrimPrefix(line, "["), "]")
+			lines[prefix] = row
+		default:
+			key, _, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			key = unquoteKey(key)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			lines[key] = row
+		}
+	}
+	return lines
+}
+
+// tableName extracts the name from a table header such as "[api.auth]"
+func tableName(header, closing string) string {
+	name, _, _ := strings.Cut(header, closing)
+	return unquoteKey(name)
+}
+
+// unquoteKey normalises a possibly dotted and quoted TOML key
+func unquoteKey(key string) string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseDuration parses a non-negative duration such as "5s"
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration %q must not be negative", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadFileUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `[api]
+url = "http://nas:8888/api"
+tiemouts = "10s"
+timeout = "5s"
+
+[colours]
+name = "light"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{APITimeouts: make(map[string]time.Duration)}
+	var problems problemList
+	loadFile(cfg, path, true, &problems)
+
+	if err := problems.err(); err != nil {
+		t.Fatalf("unknown keys failed the load: %v", err)
+	}
+	if cfg.APIBaseURL != "http://nas:8888/api" || cfg.APITimeout != 5*time.Second {
+		t.Errorf("known keys not applied: url %q, timeout %v", cfg.APIBaseURL, cfg.APITimeout)
+	}
+	want := []Problem{
+		{Source: path, Line: 3, Key: "api.tiemouts", Message: "unknown key, ignored", Warning: true},
+		{Source: path, Line: 6, Key: "colours", Message: "unknown key, ignored", Warning: true},
+	}
+	got := problems.warnings()
+	if len(got) != len(want) {
+		t.Fatalf("warnings = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("warning %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadFileInvalidValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[api]\nretries = \"three\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var problems problemList
+	loadFile(&Config{}, path, true, &problems)
+	if problems.err() == nil {
+		t.Error("invalid value didn't fail the load")
+	}
+}
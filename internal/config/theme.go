@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// ThemePalette is a custom theme read from a theme file. Colors are ANSI
+// numbers ("63") or hex ("#5f5fff"); empty fields keep the base theme's.
+type ThemePalette struct {
+	// Base is the built-in theme the palette starts from; empty uses the default
+	Base       string `toml:"base"`
+	Accent     string `toml:"accent"`
+	Text       string `toml:"text"`
+	HeaderText string `toml:"header_text"`
+	Muted      string `toml:"muted"`
+	Border     string `toml:"border"`
+	Selection  string `toml:"selection"`
+	Success    string `toml:"success"`
+	Warning    string `toml:"warning"`
+	Error      string `toml:"error"`
+	// Columns are accents for columns without a configured color
+	Columns []string `toml:"columns"`
+	// Priority are card borders and todo markers for priorities 0-3
+	Priority []string `toml:"priority"`
+	// PrioritySelected are the borders of the selected card by priority
+	PrioritySelected []string `toml:"priority_selected"`
+}
+
+// loadThemeFile reads and validates the theme file at path
+func loadThemeFile(path string, problems *problemList) *ThemePalette {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		problems.add(path, 0, "", err.Error())
+		return nil
+	}
+
+	var palette ThemePalette
+	decoder := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+	if err := decoder.Decode(&palette); err != nil {
+		var strictErr *toml.StrictMissingError
+		var decodeErr *toml.DecodeError
+		switch {
+		case errors.As(err, &strictErr):
+			for _, e := range strictErr.Errors {
+				row, _ := e.Position()
+				problems.add(path, row, strings.Join(e.Key(), "."), "unknown key")
+			}
+		case errors.As(err, &decodeErr):
+			row, _ := decodeErr.Position()
+			problems.add(path, row, strings.Join(decodeErr.Key(), "."), decodeMessage(decodeErr))
+		default:
+			problems.add(path, 0, "", err.Error())
+		}
+		return nil
+	}
+
+	lines := keyLines(data)
+	valid := true
+	check := func(key, color string) {
+		if color != "" && !ValidColor(color) {
+			problems.add(path, lines[key], key, fmt.Sprintf("invalid color %q", color))
+			valid = false
+		}
+	}
+	check("accent", palette.Accent)
+	check("text", palette.Text)
+	check("header_text", palette.HeaderText)
+	check("muted", palette.Muted)
+	check("border", palette.Border)
+	check("selection", palette.Selection)
+	check("success", palette.Success)
+	check("warning", palette.Warning)
+	check("error", palette.Error)
+	for _, c := range palette.Columns {
+		check("columns", c)
+	}
+	levels := map[string][]string{
+		"priority":          palette.Priority,
+		"priority_selected": palette.PrioritySelected,
+	}
+	for _, key := range sortedKeys(levels) {
+		colors := levels[key]
+		if len(colors) != 0 && len(colors) != 4 {
+			problems.add(path, lines[key], key, fmt.Sprintf("needs 4 colors, one per priority, got %d", len(colors)))
+			valid = false
+		}
+		for _, c := range colors {
+			check(key, c)
+		}
+	}
+
+	if !valid {
+		return nil
+	}
+	return &palette
+}
+
+// ValidColor reports whether s is an ANSI color number (0-255) or a hex
+// color such as "#5f5fff" or "#fff"
+func ValidColor(s string) bool {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) != 3 && len(hex) != 6 {
+			return false
+		}
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column defines a kanban column and the project statuses it holds
+type Column struct {
+	// Name is the display name, e.g. "In Progress"
+	Name string
+	// Status is the value sent to the API for projects in this column
+	Status string
+	// Aliases are other status values that also belong in this column
+	Aliases []string
+	// Color is the ANSI or hex color of the column accent; empty uses the
+	// theme's
+	Color string
+}
+
+// Workflow is the ordered set of kanban columns. Progressing a project moves
+// it to the next column, regressing moves it to the previous one.
+type Workflow struct {
+	Columns []Column
+}
+
+// DefaultWorkflow returns the Ready → In Progress → Finished workflow used by
+// projectarium-v2
+func DefaultWorkflow() Workflow {
+	return Workflow{Columns: []Column{
+		{Name: "Ready", Status: "ready", Aliases: []string{""}},
+		{Name: "In Progress", Status: "in_progress", Aliases: []string{"in progress"}},
+		{Name: "Finished", Status: "finished", Aliases: []string{"done"}},
+	}}
+}
+
+// ColumnIndex returns the column holding projects with the given status.
+// Statuses and aliases match case-insensitively; unknown statuses belong in
+// the first column.
+func (w Workflow) ColumnIndex(status string) int {
+	for i, col := range w.Columns {
+		if strings.EqualFold(col.Status, status) {
+			return i
+		}
+		for _, alias := range col.Aliases {
+			if strings.EqualFold(alias, status) {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// Resolve returns the status of the column whose name, status or alias is
+// s, ignoring case
+func (w Workflow) Resolve(s string) (string, bool) {
+	for _, col := range w.Columns {
+		if strings.EqualFold(col.Name, s) || strings.EqualFold(col.Status, s) {
+			return col.Status, true
+		}
+		for _, alias := range col.Aliases {
+			if alias != "" && strings.EqualFold(alias, s) {
+				return col.Status, true
+			}
+		}
+	}
+	return "", false
+}
+
+// DisplayName returns the name of the column holding the given status
+func (w Workflow) DisplayName(status string) string {
+	if len(w.Columns) == 0 {
+		return status
+	}
+	return w.Columns[w.ColumnIndex(status)].Name
+}
+
+// Statuses returns the API status of every column, in order
+func (w Workflow) Statuses() []string {
+	statuses := make([]string, len(w.Columns))
+	for i, col := range w.Columns {
+		statuses[i] = col.Status
+	}
+	return statuses
+}
+
+// HasStatus reports whether status is the API status of a column
+func (w Workflow) HasStatus(status string) bool {
+	for _, col := range w.Columns {
+		if col.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the workflow has columns with unique statuses
+func (w Workflow) Validate() error {
+	if len(w.Columns) == 0 {
+		return fmt.Errorf("workflow must have at least one column")
+	}
+	seen := make(map[string]string)
+	for _, col := range w.Columns {
+		if col.Name == "" {
+			return fmt.Errorf("column with status %q has no name", col.Status)
+		}
+		if col.Status == "" {
+			return fmt.Errorf("column %q has no status", col.Name)
+		}
+		if col.Color != "" && !ValidColor(col.Color) {
+			return fmt.Errorf("column %q has invalid color %q", col.Name, col.Color)
+		}
+		for _, status := range append([]string{col.Status}, col.Aliases...) {
+			key := strings.ToLower(status)
+			if other, ok := seen[key]; ok && other != col.Name {
+				return fmt.Errorf("status %q is used by both %q and %q", status, other, col.Name)
+			}
+			seen[key] = col.Name
+		}
+	}
+	return nil
+}
+
+// parseColumns parses the compact column syntax used in the env file:
+// columns separated by ";", each "Name=status[|alias...][@color]", e.g.
+// "Backlog=backlog|todo@63;Blocked=blocked@196;Shipped=shipped|done@70"
+func parseColumns(s string) (Workflow, error) {
+	var w Workflow
+	for _, spec := range strings.Split(s, ";") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		name, rest, ok := strings.Cut(spec, "=")
+		if !ok {
+			return Workflow{}, fmt.Errorf("column %q: expected Name=status", spec)
+		}
+		statuses, color, _ := strings.Cut(rest, "@")
+		parts := strings.Split(statuses, "|")
+
+		col := Column{
+			Name:   strings.TrimSpace(name),
+			Status: strings.TrimSpace(parts[0]),
+			Color:  strings.TrimSpace(color),
+		}
+		for _, alias := range parts[1:] {
+			col.Aliases = append(col.Aliases, strings.TrimSpace(alias))
+		}
+		w.Columns = append(w.Columns, col)
+	}
+
+	if err := w.Validate(); err != nil {
+		return Workflow{}, err
+	}
+	return w, nil
+}
@@ -0,0 +1,464 @@
+// Package filestore keeps projects and todos in a local JSON file, so pj-tui
+// can be used without a projectarium-v2 server. Its Backend behaves like the
+// API: todos are soft-deleted, every change bumps a version that full
+// updates are checked against, and partial updates touch only their field.
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// data is the contents of the file
+type data struct {
+	Projects []api.Project `json:"projects"`
+	Todos    []api.Todo    `json:"todos"`
+	// LastProjectID and LastTodoID are the last IDs handed out, so IDs of
+	// deleted items aren't reused
+	LastProjectID int `json:"last_project_id"`
+	LastTodoID    int `json:"last_todo_id"`
+}
+
+// Backend stores projects and todos in a file. The file is re-read for every
+// operation, so changes made by another pj-tui (say, a script using the
+// subcommands while the TUI is open) are picked up. Changes hold a lock on a
+// file next to it from reading to rewriting the file, so processes sharing
+// it don't lose each other's writes; on systems without advisory locks only
+// changes within one process are serialized. It is safe for concurrent use.
+type Backend struct {
+	path string
+	mu   sync.Mutex // serializes changes within the process
+}
+
+// Ensure Backend can stand in for the client
+var _ api.Backend = (*Backend)(nil)
+
+// DefaultPath returns the data file location, following the XDG base
+// directory spec: $XDG_DATA_HOME/pj-tui/data.json, falling back to
+// ~/.local/share/pj-tui/data.json
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "pj-tui", "data.json")
+}
+
+// Open returns a Backend for the file at path, which is created on the first
+// change if it doesn't exist. It fails if the file can't be read or parsed,
+// rather than risk overwriting it.
+func Open(path string) (*Backend, error) {
+	b := &Backend{path: path}
+	if _, err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load reads the file; a missing file holds nothing yet
+func (b *Backend) load() (*data, error) {
+	raw, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &data{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d data
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("%s: %w", b.path, err)
+	}
+	return &d, nil
+}
+
+// save writes the file, replacing it atomically so a crash can't leave it
+// half-written
+func (b *Backend) save(d *data) error {
+	raw, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".data-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+// lock takes the lock guarding changes to the file against other processes,
+// returning the function releasing it
+func (b *Backend) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(b.path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", b.path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// view runs read against the current contents of the file. Reads need no
+// lock, since the file is only ever replaced whole.
+func view[T any](ctx context.Context, b *Backend, read func(*data) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, err := b.load()
+	if err != nil {
+		return zero, err
+	}
+	return read(d)
+}
+
+// change runs modify against the current contents of the file and saves the
+// result, unless modify fails. The file is locked throughout, so no other
+// change can slip in between reading and rewriting it.
+func change[T any](ctx context.Context, b *Backend, modify func(*data) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	unlock, err := b.lock()
+	if err != nil {
+		return zero, err
+	}
+	defer unlock()
+	d, err := b.load()
+	if err != nil {
+		return zero, err
+	}
+	v, err := modify(d)
+	if err != nil {
+		return zero, err
+	}
+	if err := b.save(d); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// GetProjects implements api.Backend
+func (b *Backend) GetProjects(ctx context.Context) ([]api.Project, error) {
+	return view(ctx, b, func(d *data) ([]api.Project, error) {
+		return append([]api.Project{}, d.Projects...), nil
+	})
+}
+
+// GetProject implements api.Backend
+func (b *Backend) GetProject(ctx context.Context, id int) (*api.Project, error) {
+	return view(ctx, b, func(d *data) (*api.Project, error) {
+		project, err := d.project(id)
+		if err != nil {
+			return nil, err
+		}
+		found := *project
+		return &found, nil
+	})
+}
+
+// CreateProject implements api.Backend
+func (b *Backend) CreateProject(ctx context.Context, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	if err := required("project name", name); err != nil {
+		return nil, err
+	}
+	return change(ctx, b, func(d *data) (*api.Project, error) {
+		d.LastProjectID++
+		project := api.Project{
+			ID: d.LastProjectID, Name: name, Description: description, Path: path, File: file,
+			Language: language, Priority: priority, Status: status,
+			UpdatedAt: time.Now(), Version: 1,
+		}
+		d.Projects = append(d.Projects, project)
+		return &project, nil
+	})
+}
+
+// UpdateProject implements api.Backend
+func (b *Backend) UpdateProject(ctx context.Context, id, version int, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	if err := required("project name", name); err != nil {
+		return nil, err
+	}
+	return updateProject(ctx, b, id, func(project *api.Project) error {
+		if stale(version, project.Version) {
+			return fmt.Errorf("project %d: %w", id, api.ErrConflict)
+		}
+		project.Name, project.Description, project.Path, project.File = name, description, path, file
+		project.Language, project.Priority, project.Status = language, priority, status
+		return nil
+	})
+}
+
+// UpdateProjectStatus implements api.Backend
+func (b *Backend) UpdateProjectStatus(ctx context.Context, id int, status string) (*api.Project, error) {
+	return updateProject(ctx, b, id, func(project *api.Project) error {
+		project.Status = status
+		return nil
+	})
+}
+
+// UpdateProjectPriority implements api.Backend
+func (b *Backend) UpdateProjectPriority(ctx context.Context, id int, priority int) (*api.Project, error) {
+	return updateProject(ctx, b, id, func(project *api.Project) error {
+		project.Priority = priority
+		return nil
+	})
+}
+
+// DeleteProject implements api.Backend. The project's todos are
+// soft-deleted with it.
+func (b *Backend) DeleteProject(ctx context.Context, id int) error {
+	_, err := change(ctx, b, func(d *data) (struct{}, error) {
+		i := d.projectIndex(id)
+		if i < 0 {
+			return struct{}{}, notFound("project", id)
+		}
+		d.Projects = append(d.Projects[:i], d.Projects[i+1:]...)
+		for i := range d.Todos {
+			todo := &d.Todos[i]
+			if todo.ProjectID != nil && *todo.ProjectID == id && !todo.Deleted {
+				todo.Deleted = true
+				todo.Version++
+			}
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// GetTodos implements api.Backend. Like the API, it includes soft-deleted
+// todos.
+func (b *Backend) GetTodos(ctx context.Context) ([]api.Todo, error) {
+	return view(ctx, b, func(d *data) ([]api.Todo, error) {
+		return append([]api.Todo{}, d.Todos...), nil
+	})
+}
+
+// GetTodosByProject implements api.Backend
+func (b *Backend) GetTodosByProject(ctx context.Context, projectID int) ([]api.Todo, error) {
+	return view(ctx, b, func(d *data) ([]api.Todo, error) {
+		todos := []api.Todo{}
+		for _, todo := range d.Todos {
+			if todo.ProjectID != nil && *todo.ProjectID == projectID {
+				todos = append(todos, todo)
+			}
+		}
+		return todos, nil
+	})
+}
+
+// GetTodo implements api.Backend. Soft-deleted todos can still be read.
+func (b *Backend) GetTodo(ctx context.Context, id int) (*api.Todo, error) {
+	return view(ctx, b, func(d *data) (*api.Todo, error) {
+		i := d.todoIndex(id)
+		if i < 0 {
+			return nil, notFound("todo", id)
+		}
+		todo := d.Todos[i]
+		return &todo, nil
+	})
+}
+
+// CreateTodo implements api.Backend
+func (b *Backend) CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*api.Todo, error) {
+	if err := required("todo description", description); err != nil {
+		return nil, err
+	}
+	return change(ctx, b, func(d *data) (*api.Todo, error) {
+		if err := d.checkProject(projectID); err != nil {
+			return nil, err
+		}
+		d.LastTodoID++
+		todo := api.Todo{ID: d.LastTodoID, Description: description, Priority: priority, ProjectID: copyID(projectID), Version: 1}
+		d.Todos = append(d.Todos, todo)
+		return &todo, nil
+	})
+}
+
+// UpdateTodo implements api.Backend
+func (b *Backend) UpdateTodo(ctx context.Context, id, version int, description string, priority int, projectID *int) (*api.Todo, error) {
+	if err := required("todo description", description); err != nil {
+		return nil, err
+	}
+	return updateTodo(ctx, b, id, func(d *data, todo *api.Todo) error {
+		if stale(version, todo.Version) {
+			return fmt.Errorf("todo %d: %w", id, api.ErrConflict)
+		}
+		if err := d.checkProject(projectID); err != nil {
+			return err
+		}
+		todo.Description, todo.Priority, todo.ProjectID = description, priority, copyID(projectID)
+		return nil
+	})
+}
+
+// UpdateTodoCompleted implements api.Backend
+func (b *Backend) UpdateTodoCompleted(ctx context.Context, id int, completed bool) (*api.Todo, error) {
+	return updateTodo(ctx, b, id, func(_ *data, todo *api.Todo) error {
+		if todo.Completed == completed {
+			return nil
+		}
+		todo.Completed, todo.CompletedAt = completed, time.Time{}
+		if completed {
+			todo.CompletedAt = time.Now()
+		}
+		return nil
+	})
+}
+
+// UpdateTodoNotes implements api.Backend
+func (b *Backend) UpdateTodoNotes(ctx context.Context, id int, notes string) (*api.Todo, error) {
+	return updateTodo(ctx, b, id, func(_ *data, todo *api.Todo) error {
+		todo.Notes = notes
+		return nil
+	})
+}
+
+// DeleteTodo implements api.Backend. The todo is soft-deleted: it stays in
+// the file, marked deleted.
+func (b *Backend) DeleteTodo(ctx context.Context, id int) error {
+	_, err := updateTodo(ctx, b, id, func(_ *data, todo *api.Todo) error {
+		todo.Deleted = true
+		return nil
+	})
+	return err
+}
+
+// updateProject applies edit to a project and bumps its version
+func updateProject(ctx context.Context, b *Backend, id int, edit func(*api.Project) error) (*api.Project, error) {
+	return change(ctx, b, func(d *data) (*api.Project, error) {
+		project, err := d.project(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := edit(project); err != nil {
+			return nil, err
+		}
+		project.UpdatedAt = time.Now()
+		project.Version++
+		updated := *project
+		return &updated, nil
+	})
+}
+
+// updateTodo applies edit to a todo that isn't deleted and bumps its version
+func updateTodo(ctx context.Context, b *Backend, id int, edit func(*data, *api.Todo) error) (*api.Todo, error) {
+	return change(ctx, b, func(d *data) (*api.Todo, error) {
+		i := d.todoIndex(id)
+		if i < 0 || d.Todos[i].Deleted {
+			return nil, notFound("todo", id)
+		}
+		todo := &d.Todos[i]
+		if err := edit(d, todo); err != nil {
+			return nil, err
+		}
+		todo.Version++
+		updated := *todo
+		return &updated, nil
+	})
+}
+
+// project returns the project with the given ID
+func (d *data) project(id int) (*api.Project, error) {
+	i := d.projectIndex(id)
+	if i < 0 {
+		return nil, notFound("project", id)
+	}
+	return &d.Projects[i], nil
+}
+
+// projectIndex returns the index of the project with the given ID, or -1
+func (d *data) projectIndex(id int) int {
+	for i, project := range d.Projects {
+		if project.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// todoIndex returns the index of the todo with the given ID, or -1
+func (d *data) todoIndex(id int) int {
+	for i, todo := range d.Todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkProject fails if a todo would be assigned to a project that doesn't
+// exist; nil is the inbox. Like the API, this rejects the request as invalid
+// rather than reporting the todo as not found.
+func (d *data) checkProject(projectID *int) error {
+	if projectID == nil || d.projectIndex(*projectID) >= 0 {
+		return nil
+	}
+	return fmt.Errorf("project %d does not exist: %w", *projectID, api.ErrValidation)
+}
+
+// stale reports whether an edit based on version would overwrite a newer
+// one, the way the API refuses it
+func stale(version, current int) bool {
+	return version != 0 && version != current
+}
+
+// required fails if a mandatory field is blank
+func required(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s must not be empty: %w", field, api.ErrValidation)
+	}
+	return nil
+}
+
+// copyID returns a copy of a project reference, so the stored todo doesn't
+// share it with the caller
+func copyID(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
+
+// notFound is the error for a project or todo missing from the file
+func notFound(kind string, id int) error {
+	return fmt.Errorf("%s %d: %w", kind, id, api.ErrNotFound)
+}
@@ -0,0 +1,246 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// open returns a backend over a data file in a fresh directory
+func open(t *testing.T) (*Backend, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pj-tui", "data.json")
+	b, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return b, path
+}
+
+func TestProjectCRUD(t *testing.T) {
+	ctx := context.Background()
+	b, path := open(t)
+
+	created, err := b.CreateProject(ctx, "pj", "tui", "~/src/pj", "main.go", "go", 2, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if created.ID != 1 || created.Version != 1 || created.Name != "pj" {
+		t.Errorf("CreateProject = %+v, want ID 1 at version 1", created)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("file not created on the first change: %v", err)
+	}
+
+	updated, err := b.UpdateProject(ctx, created.ID, created.Version, "pj-tui", "tui", "~/src/pj", "main.go", "go", 3, "in_progress")
+	if err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if updated.Name != "pj-tui" || updated.Status != "in_progress" || updated.Version != 2 {
+		t.Errorf("UpdateProject = %+v, want renamed at version 2", updated)
+	}
+
+	status, err := b.UpdateProjectStatus(ctx, created.ID, "finished")
+	if err != nil {
+		t.Fatalf("UpdateProjectStatus: %v", err)
+	}
+	if status.Status != "finished" || status.Priority != 3 || status.Version != 3 {
+		t.Errorf("UpdateProjectStatus = %+v, want only the status changed", status)
+	}
+
+	// A second backend over the same file sees the changes
+	other, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open again: %v", err)
+	}
+	got, err := other.GetProject(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if got.Name != "pj-tui" || got.Status != "finished" {
+		t.Errorf("GetProject from the file = %+v", got)
+	}
+
+	if err := b.DeleteProject(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	if _, err := b.GetProject(ctx, created.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetProject after delete: err = %v, want ErrNotFound", err)
+	}
+	if err := b.DeleteProject(ctx, created.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("second DeleteProject: err = %v, want ErrNotFound", err)
+	}
+
+	// IDs of deleted projects aren't handed out again
+	next, err := b.CreateProject(ctx, "next", "", "", "", "", 0, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if next.ID != 2 {
+		t.Errorf("new project got ID %d, want 2", next.ID)
+	}
+}
+
+func TestTodoCRUD(t *testing.T) {
+	ctx := context.Background()
+	b, _ := open(t)
+
+	project, err := b.CreateProject(ctx, "pj", "", "", "", "", 0, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	created, err := b.CreateTodo(ctx, "write tests", 1, &project.ID)
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	if created.ID != 1 || created.Version != 1 || created.ProjectID == nil || *created.ProjectID != project.ID {
+		t.Errorf("CreateTodo = %+v, want ID 1 in project %d", created, project.ID)
+	}
+
+	updated, err := b.UpdateTodo(ctx, created.ID, created.Version, "write more tests", 3, nil)
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if updated.Description != "write more tests" || updated.ProjectID != nil || updated.Version != 2 {
+		t.Errorf("UpdateTodo = %+v, want moved to the inbox at version 2", updated)
+	}
+
+	done, err := b.UpdateTodoCompleted(ctx, created.ID, true)
+	if err != nil {
+		t.Fatalf("UpdateTodoCompleted: %v", err)
+	}
+	if !done.Completed || done.CompletedAt.IsZero() {
+		t.Errorf("UpdateTodoCompleted = %+v, want completed with a time", done)
+	}
+	noted, err := b.UpdateTodoNotes(ctx, created.ID, "# Plan")
+	if err != nil {
+		t.Fatalf("UpdateTodoNotes: %v", err)
+	}
+	if noted.Notes != "# Plan" || !noted.Completed {
+		t.Errorf("UpdateTodoNotes = %+v, want notes set and still completed", noted)
+	}
+
+	missing := 99
+	if _, err := b.CreateTodo(ctx, "orphan", 0, &missing); !errors.Is(err, api.ErrValidation) {
+		t.Errorf("CreateTodo in a missing project: err = %v, want ErrValidation", err)
+	}
+	if _, err := b.CreateTodo(ctx, " ", 0, nil); !errors.Is(err, api.ErrValidation) {
+		t.Errorf("CreateTodo without a description: err = %v, want ErrValidation", err)
+	}
+	if _, err := b.GetTodo(ctx, 99); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetTodo of a missing todo: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	ctx := context.Background()
+	b, _ := open(t)
+
+	project, err := b.CreateProject(ctx, "pj", "", "", "", "", 0, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	// Someone else changes the project, bumping its version
+	if _, err := b.UpdateProjectPriority(ctx, project.ID, 2); err != nil {
+		t.Fatalf("UpdateProjectPriority: %v", err)
+	}
+	if _, err := b.UpdateProject(ctx, project.ID, project.Version, "stale", "", "", "", "", 0, "ready"); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("UpdateProject with a stale version: err = %v, want ErrConflict", err)
+	}
+	// Version 0 overwrites
+	if _, err := b.UpdateProject(ctx, project.ID, 0, "forced", "", "", "", "", 0, "ready"); err != nil {
+		t.Errorf("UpdateProject without a version: %v", err)
+	}
+
+	todo, err := b.CreateTodo(ctx, "first", 0, &project.ID)
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	if _, err := b.UpdateTodoCompleted(ctx, todo.ID, true); err != nil {
+		t.Fatalf("UpdateTodoCompleted: %v", err)
+	}
+	if _, err := b.UpdateTodo(ctx, todo.ID, todo.Version, "stale", 0, todo.ProjectID); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("UpdateTodo with a stale version: err = %v, want ErrConflict", err)
+	}
+	if got, _ := b.GetTodo(ctx, todo.ID); got.Description != "first" {
+		t.Errorf("todo after a conflicting update = %+v, want it unchanged", got)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	b, _ := open(t)
+
+	project, err := b.CreateProject(ctx, "pj", "", "", "", "", 0, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	first, err := b.CreateTodo(ctx, "first", 0, &project.ID)
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	second, err := b.CreateTodo(ctx, "second", 0, &project.ID)
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+
+	if err := b.DeleteTodo(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+	todo, err := b.GetTodo(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetTodo of a deleted todo: %v", err)
+	}
+	if !todo.Deleted || todo.Version != 2 {
+		t.Errorf("GetTodo = %+v, want it marked deleted at version 2", todo)
+	}
+	if err := b.DeleteTodo(ctx, first.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("second DeleteTodo: err = %v, want ErrNotFound", err)
+	}
+	if _, err := b.UpdateTodoNotes(ctx, first.ID, "too late"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("UpdateTodoNotes of a deleted todo: err = %v, want ErrNotFound", err)
+	}
+
+	// Like the API, listings include deleted todos
+	todos, err := b.GetTodosByProject(ctx, project.ID)
+	if err != nil {
+		t.Fatalf("GetTodosByProject: %v", err)
+	}
+	if len(todos) != 2 || !todos[0].Deleted || todos[1].Deleted {
+		t.Errorf("GetTodosByProject = %+v, want both todos with the first marked deleted", todos)
+	}
+
+	// Deleting a project soft-deletes its todos
+	if err := b.DeleteProject(ctx, project.ID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	all, err := b.GetTodos(ctx)
+	if err != nil {
+		t.Fatalf("GetTodos: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("GetTodos returned %d todos, want 2", len(all))
+	}
+	for _, todo := range all {
+		if !todo.Deleted {
+			t.Errorf("todo %d of the deleted project is not deleted", todo.ID)
+		}
+		if todo.ID == second.ID && todo.Version != 2 {
+			t.Errorf("todo %d deleted with its project at version %d, want 2", todo.ID, todo.Version)
+		}
+	}
+}
+
+func TestOpenCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open of a corrupt file succeeded, risking overwriting it")
+	}
+}
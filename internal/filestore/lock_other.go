@@ -0,0 +1,16 @@
+//go:build !unix
+
+package filestore
+
+import "os"
+
+// lockFile is a no-op where advisory locks aren't available; only changes
+// made by one process are serialized there
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile releases the lock taken by lockFile
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package filter implements the filter expressions that narrow down the
+// projects shown on the board, e.g.
+//
+//	lang:go prio:>=2 path:~/work -status:finished
+//
+// An expression is a list of terms that must all match. A term is
+// field:value, optionally negated with a leading "-"; a bare word matches the
+// name or description. Values may be quoted ("path:\"~/my work\"") and may
+// list alternatives separated by commas outside quotes (lang:go,rust).
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// Columns resolves status terms to kanban columns; config.Workflow
+// implements it
+type Columns interface {
+	// Resolve returns the status of the column with the given name,
+	// status or alias
+	Resolve(s string) (string, bool)
+	// ColumnIndex returns the column holding a status
+	ColumnIndex(status string) int
+	// Statuses returns the status of every column
+	Statuses() []string
+}
+
+// fields are the recognised term fields, with their aliases
+var fields = map[string]string{
+	"lang":        "lang",
+	"language":    "lang",
+	"prio":        "prio",
+	"priority":    "prio",
+	"path":        "path",
+	"status":      "status",
+	"name":        "name",
+	"desc":        "desc",
+	"description": "desc",
+}
+
+// Filter is a parsed filter expression
+type Filter struct {
+	// Name is the name of the filter in the config, empty for an ad-hoc
+	// expression
+	Name   string
+	source string
+	terms  []term
+}
+
+// term is one condition of an expression
+type term struct {
+	negate bool
+	match  func(api.Project) bool
+}
+
+// Parse parses a filter expression, resolving status terms against columns
+func Parse(expr string, columns Columns) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	f := &Filter{source: strings.TrimSpace(expr)}
+	for _, tok := range tokens {
+		t, err := parseTerm(tok, columns)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tok, err)
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// Resolve returns the named filter ref, or parses ref as an expression if no
+// filter has that name
+func Resolve(ref string, named map[string]string, columns Columns) (*Filter, error) {
+	if expr, ok := named[ref]; ok {
+		f, err := Parse(expr, columns)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", ref, err)
+		}
+		f.Name = ref
+		return f, nil
+	}
+	return Parse(ref, columns)
+}
+
+// String returns the expression the filter was parsed from
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.source
+}
+
+// Label names the filter for display: its name, or else its expression
+func (f *Filter) Label() string {
+	if f == nil {
+		return ""
+	}
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.source
+}
+
+// Match reports whether a project satisfies every term. A nil filter
+// matches everything.
+func (f *Filter) Match(p api.Project) bool {
+	if f == nil {
+		return true
+	}
+	for _, t := range f.terms {
+		if t.match(p) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the projects matching the filter, in their original order
+func (f *Filter) Apply(projects []api.Project) []api.Project {
+	if f == nil || len(f.terms) == 0 {
+		return projects
+	}
+	matched := make([]api.Project, 0, len(projects))
+	for _, p := range projects {
+		if f.Match(p) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// tokenize splits an expression on whitespace, keeping quoted strings
+// together. The quotes are kept so commas and colons inside them can be told
+// apart; unquote drops them.
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inQuote, inToken := false, false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			cur.WriteRune(r)
+			inQuote = !inQuote
+			inToken = true
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			if inToken {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in %q", expr)
+	}
+	if inToken {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens, nil
+}
+
+// parseTerm parses a single [-]field:value or bare word
+func parseTerm(tok string, columns Columns) (term, error) {
+	var t term
+	if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+		t.negate = true
+		tok = tok[1:]
+	}
+
+	name, value, ok := cutUnquoted(tok, ':')
+	if !ok {
+		word := strings.ToLower(unquote(tok))
+		t.match = func(p api.Project) bool {
+			return strings.Contains(strings.ToLower(p.Name), word) ||
+				strings.Contains(strings.ToLower(p.Description), word)
+		}
+		return t, nil
+	}
+
+	field, known := fields[strings.ToLower(unquote(name))]
+	if !known {
+		return t, fmt.Errorf("unknown field %q (want lang, prio, path, status, name or desc)", name)
+	}
+	if value == "" {
+		return t, fmt.Errorf("missing value")
+	}
+	var matchers []func(api.Project) bool
+	for _, alt := range splitUnquoted(value, ',') {
+		alt = unquote(alt)
+		if alt == "" {
+			return t, fmt.Errorf("empty alternative in %q", value)
+		}
+		m, err := parseValue(field, alt, columns)
+		if err != nil {
+			return t, err
+		}
+		matchers = append(matchers, m)
+	}
+	t.match = func(p api.Project) bool {
+		for _, m := range matchers {
+			if m(p) {
+				return true
+			}
+		}
+		return false
+	}
+	return t, nil
+}
+
+// cutUnquoted slices s around the first sep outside quotes
+func cutUnquoted(s string, sep rune) (before, after string, found bool) {
+	parts := splitUnquoted(s, sep)
+	if len(parts) == 1 {
+		return s, "", false
+	}
+	return parts[0], s[len(parts[0])+1:], true
+}
+
+// splitUnquoted splits s on every sep outside quotes
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	start, inQuote := 0, false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == sep && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote drops the quotes from a token or part of one
+func unquote(s string) string {
+	return strings.ReplaceAll(s, `"`, "")
+}
+
+// parseValue builds the matcher for one alternative of a field's value
+func parseValue(field, value string, columns Columns) (func(api.Project) bool, error) {
+	switch field {
+	case "lang":
+		return func(p api.Project) bool { return strings.EqualFold(p.Language, value) }, nil
+
+	case "name", "desc":
+		word := strings.ToLower(value)
+		return func(p api.Project) bool {
+			text := p.Name
+			if field == "desc" {
+				text = p.Description
+			}
+			return strings.Contains(strings.ToLower(text), word)
+		}, nil
+
+	case "prio":
+		op := strings.TrimRight(value, "0123456789")
+		n, err := strconv.Atoi(value[len(op):])
+		if err != nil {
+			return nil, fmt.Errorf("priority must be a number, optionally after <, <=, >, >= or =, got %q", value)
+		}
+		cmp, ok := comparisons[op]
+		if !ok {
+			return nil, fmt.Errorf("unknown comparison %q (want <, <=, >, >= or =)", op)
+		}
+		return func(p api.Project) bool { return cmp(p.Priority, n) }, nil
+
+	case "path":
+		dir := expandHome(value)
+		return func(p api.Project) bool { return underPath(expandHome(p.Path), dir) }, nil
+
+	case "status":
+		status, ok := columns.Resolve(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q (want one of %s)", value, strings.Join(columns.Statuses(), ", "))
+		}
+		column := columns.ColumnIndex(status)
+		return func(p api.Project) bool { return columns.ColumnIndex(p.Status) == column }, nil
+	}
+	return nil, fmt.Errorf("unknown field %q", field)
+}
+
+// comparisons are the operators allowed before a priority
+var comparisons = map[string]func(a, b int) bool{
+	"":   func(a, b int) bool { return a == b },
+	"=":  func(a, b int) bool { return a == b },
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	">":  func(a, b int) bool { return a > b },
+	">=": func(a, b int) bool { return a >= b },
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// underPath reports whether path is dir or inside it
+func underPath(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
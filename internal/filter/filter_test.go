@@ -0,0 +1,166 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// testColumns is a board of three columns; "ready" also takes "todo"
+type testColumns struct{}
+
+var testStatuses = []string{"ready", "in_progress", "finished"}
+
+func (testColumns) Resolve(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if s == "todo" {
+		return "ready", true
+	}
+	for _, status := range testStatuses {
+		if s == status {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+func (testColumns) ColumnIndex(status string) int {
+	for i, s := range testStatuses {
+		if strings.EqualFold(s, status) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (testColumns) Statuses() []string {
+	return testStatuses
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"  lang:go\tprio:>=2\n", []string{"lang:go", "prio:>=2"}, false},
+		{`path:"~/my work" -status:finished`, []string{`path:"~/my work"`, "-status:finished"}, false},
+		{`"two words"`, []string{`"two words"`}, false},
+		{`path:"a,b",c`, []string{`path:"a,b",c`}, false},
+		{`name:"open`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := tokenize(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokens = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTerm(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	goProject := api.Project{Name: "pj-tui", Description: "Kanban for projects", Language: "Go", Priority: 2, Status: "in_progress", Path: filepath.Join(home, "src", "pj")}
+	commaProject := api.Project{Name: "a,b", Language: "rust", Priority: 0, Status: "ready", Path: "/srv/a,b"}
+
+	tests := []struct {
+		tok     string
+		match   []bool // for goProject, commaProject
+		wantErr string
+	}{
+		{tok: "kanban", match: []bool{true, false}},
+		{tok: `"for projects"`, match: []bool{true, false}},
+		{tok: `"a:b"`, match: []bool{false, false}},
+		{tok: "lang:go", match: []bool{true, false}},
+		{tok: "language:go,rust", match: []bool{true, true}},
+		{tok: "-lang:go", match: []bool{false, true}},
+		{tok: "prio:2", match: []bool{true, false}},
+		{tok: "prio:>=1", match: []bool{true, false}},
+		{tok: "priority:<1,>1", match: []bool{true, true}},
+		{tok: "path:~/src", match: []bool{true, false}},
+		{tok: `path:"/srv/a,b"`, match: []bool{false, true}},
+		{tok: `path:"/srv/a,b",~/src`, match: []bool{true, true}},
+		{tok: "path:/srv/a", match: []bool{false, false}},
+		{tok: "status:todo", match: []bool{false, true}},
+		{tok: "status:in_progress,finished", match: []bool{true, false}},
+		{tok: `name:"a,b"`, match: []bool{false, true}},
+		{tok: "name:a,b", match: []bool{false, true}},
+		{tok: "desc:kanban", match: []bool{true, false}},
+		{tok: "colour:red", wantErr: `unknown field "colour"`},
+		{tok: "lang:", wantErr: "missing value"},
+		{tok: "lang:go,", wantErr: "empty alternative"},
+		{tok: "prio:high", wantErr: "priority must be a number"},
+		{tok: "prio:!2", wantErr: `unknown comparison "!"`},
+		{tok: "status:blocked", wantErr: `unknown status "blocked"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tok, func(t *testing.T) {
+			term, err := parseTerm(tt.tok, testColumns{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			for i, p := range []api.Project{goProject, commaProject} {
+				if got := term.match(p) != term.negate; got != tt.match[i] {
+					t.Errorf("match(%q) = %v, want %v", p.Name, got, tt.match[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	projects := []api.Project{
+		{ID: 1, Name: "pj-tui", Language: "go", Priority: 2, Status: "in_progress"},
+		{ID: 2, Name: "site", Language: "typescript", Priority: 1, Status: "ready"},
+		{ID: 3, Name: "old", Language: "go", Priority: 0, Status: "finished"},
+	}
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"", []int{1, 2, 3}},
+		{"lang:go", []int{1, 3}},
+		{"lang:go -status:finished", []int{1}},
+		{"prio:>=1", []int{1, 2}},
+		{"lang:go prio:>=1 name:site", nil},
+		{"lang:go,typescript status:todo,in_progress", []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr, testColumns{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, p := range f.Apply(projects) {
+				got = append(got, p.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	var none *Filter
+	if !none.Match(projects[0]) {
+		t.Error("nil filter doesn't match everything")
+	}
+}
@@ -0,0 +1,49 @@
+package mockserver
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// Fixture is the data a server starts with. Its JSON form is also the
+// format of the file backend's data file, so a board kept in a file can be
+// served as is.
+type Fixture struct {
+	Projects []api.Project `json:"projects"`
+	Todos    []api.Todo    `json:"todos"`
+}
+
+//go:embed demo.json
+var demoJSON []byte
+
+// Demo returns a few sample projects and todos in every column of the
+// default workflow
+func Demo() Fixture {
+	var fixture Fixture
+	if err := json.Unmarshal(demoJSON, &fixture); err != nil {
+		panic(fmt.Sprintf("mockserver: bad demo fixture: %v", err))
+	}
+	return fixture
+}
+
+// LoadFixture reads the fixture files at paths and combines them, in order
+func LoadFixture(paths ...string) (Fixture, error) {
+	var fixture Fixture
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Fixture{}, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return Fixture{}, fmt.Errorf("%s: %w", path, err)
+		}
+		fixture.Projects = append(fixture.Projects, f.Projects...)
+		fixture.Todos = append(fixture.Todos, f.Todos...)
+	}
+	return fixture, nil
+}
@@ -0,0 +1,554 @@
+// Package mockserver is a fake projectarium-v2 API for development and
+// tests. It serves every endpoint api.Client calls from memory, with the
+// real server's status codes, soft-deleted todos and versioned updates
+// checked against If-Match.
+//
+// In Go tests it runs under httptest:
+//
+//	srv := httptest.NewServer(mockserver.New(mockserver.Demo()))
+//	defer srv.Close()
+//	client := api.NewClient(srv.URL + "/api")
+package mockserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// Server is the fake API. It implements http.Handler, serving the API under
+// /api, and is safe for concurrent use.
+type Server struct {
+	mux *http.ServeMux
+
+	mu            sync.Mutex // guards the fields below
+	projects      []api.Project
+	todos         []api.Todo
+	lastProjectID int
+	lastTodoID    int
+}
+
+// New creates a server holding the projects and todos of fixture. Items
+// without a version start at version 1.
+func New(fixture Fixture) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	for _, project := range fixture.Projects {
+		project.Version = max(project.Version, 1)
+		s.projects = append(s.projects, project)
+		s.lastProjectID = max(s.lastProjectID, project.ID)
+	}
+	for _, todo := range fixture.Todos {
+		todo.Version = max(todo.Version, 1)
+		s.todos = append(s.todos, todo)
+		s.lastTodoID = max(s.lastTodoID, todo.ID)
+	}
+
+	s.mux.HandleFunc("GET /api/projects", s.getProjects)
+	s.mux.HandleFunc("POST /api/projects", s.createProject)
+	s.mux.HandleFunc("GET /api/projects/{id}", s.getProject)
+	s.mux.HandleFunc("PUT /api/projects/{id}", s.updateProject)
+	s.mux.HandleFunc("PATCH /api/projects/{id}/status", s.updateProjectStatus)
+	s.mux.HandleFunc("PATCH /api/projects/{id}/priority", s.updateProjectPriority)
+	s.mux.HandleFunc("DELETE /api/projects/{id}", s.deleteProject)
+	s.mux.HandleFunc("GET /api/todos", s.getTodos)
+	s.mux.HandleFunc("POST /api/todos", s.createTodo)
+	s.mux.HandleFunc("GET /api/todos/{id}", s.getTodo)
+	s.mux.HandleFunc("PUT /api/todos/{id}", s.updateTodo)
+	s.mux.HandleFunc("PATCH /api/todos/{id}/completed", s.updateTodoCompleted)
+	s.mux.HandleFunc("PATCH /api/todos/{id}/notes", s.updateTodoNotes)
+	s.mux.HandleFunc("DELETE /api/todos/{id}", s.deleteTodo)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Snapshot returns the server's current projects and todos, including
+// soft-deleted todos, for tests to inspect
+func (s *Server) Snapshot() Fixture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Fixture{
+		Projects: append([]api.Project{}, s.projects...),
+		Todos:    append([]api.Todo{}, s.todos...),
+	}
+}
+
+// httpError is a failed request: its status and the error body sent back
+type httpError struct {
+	status  int
+	code    string
+	message string
+	fields  map[string]string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// notFound is the error for a missing project or todo
+func notFound(kind string, id int) *httpError {
+	return &httpError{status: http.StatusNotFound, code: "not_found", message: fmt.Sprintf("%s %d not found", kind, id)}
+}
+
+// invalid is the error for a request body the server refuses
+func invalid(field, problem string) *httpError {
+	return &httpError{
+		status:  http.StatusBadRequest,
+		code:    "validation_failed",
+		message: fmt.Sprintf("%s %s", field, problem),
+		fields:  map[string]string{field: problem},
+	}
+}
+
+// respond writes v as JSON with the given status. Projects and todos are
+// sent with their version as ETag.
+func respond(w http.ResponseWriter, status int, v any) {
+	switch item := v.(type) {
+	case api.Project:
+		w.Header().Set("ETag", etag(item.Version))
+	case api.Todo:
+		w.Header().Set("ETag", etag(item.Version))
+	}
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// fail writes err as the API's JSON error body, with its status
+func fail(w http.ResponseWriter, err error) {
+	var he *httpError
+	if !errors.As(err, &he) {
+		he = &httpError{status: http.StatusInternalServerError, code: "internal", message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(he.status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":  he.message,
+		"code":   he.code,
+		"fields": he.fields,
+	})
+}
+
+// etag is the entity tag of a project or todo at version, as api.Client
+// sends it in If-Match
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// checkVersion fails with 412 Precondition Failed if the request's If-Match
+// header doesn't name the current version. Requests without one overwrite.
+func checkVersion(r *http.Request, kind string, id, version int) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			return nil
+		}
+	}
+	return &httpError{
+		status:  http.StatusPreconditionFailed,
+		code:    "version_mismatch",
+		message: fmt.Sprintf("%s %d has changed; it is at version %d", kind, id, version),
+	}
+}
+
+// pathID parses the {id} of the request path
+func pathID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, invalid("id", "must be a number")
+	}
+	return id, nil
+}
+
+// decode reads the JSON request body into v
+func decode(r *http.Request, v any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &httpError{status: http.StatusBadRequest, code: "bad_request", message: "invalid JSON body: " + err.Error()}
+	}
+	return nil
+}
+
+// required fails if a mandatory field is blank
+func required(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return invalid(field, "is required")
+	}
+	return nil
+}
+
+// projectBody is the body of project creates and updates
+type projectBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Path        string `json:"path"`
+	File        string `json:"file"`
+	Language    string `json:"language"`
+	Priority    int    `json:"priority"`
+	Status      string `json:"status"`
+}
+
+// todoBody is the body of todo creates and updates
+type todoBody struct {
+	Description string `json:"description"`
+	Priority    int    `json:"priority"`
+	ProjectID   *int   `json:"project_id"`
+}
+
+func (s *Server) getProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	respond(w, http.StatusOK, append([]api.Project{}, s.projects...))
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	project, err := s.project(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	respond(w, http.StatusOK, *project)
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var body projectBody
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := required("name", body.Name); err != nil {
+		fail(w, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastProjectID++
+	project := api.Project{
+		ID: s.lastProjectID, Name: body.Name, Description: body.Description, Path: body.Path,
+		File: body.File, Language: body.Language, Priority: body.Priority, Status: body.Status,
+		UpdatedAt: time.Now(), Version: 1,
+	}
+	s.projects = append(s.projects, project)
+	respond(w, http.StatusCreated, project)
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	var body projectBody
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := required("name", body.Name); err != nil {
+		fail(w, err)
+		return
+	}
+	s.changeProject(w, r, func(project *api.Project) error {
+		if err := checkVersion(r, "project", project.ID, project.Version); err != nil {
+			return err
+		}
+		project.Name, project.Description, project.Path, project.File = body.Name, body.Description, body.Path, body.File
+		project.Language, project.Priority, project.Status = body.Language, body.Priority, body.Status
+		return nil
+	})
+}
+
+func (s *Server) updateProjectStatus(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := required("status", body.Status); err != nil {
+		fail(w, err)
+		return
+	}
+	s.changeProject(w, r, func(project *api.Project) error {
+		project.Status = body.Status
+		return nil
+	})
+}
+
+func (s *Server) updateProjectPriority(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Priority *int `json:"priority"`
+	}
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if body.Priority == nil {
+		fail(w, invalid("priority", "is required"))
+		return
+	}
+	s.changeProject(w, r, func(project *api.Project) error {
+		project.Priority = *body.Priority
+		return nil
+	})
+}
+
+// deleteProject removes a project and soft-deletes its todos
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	project, err := s.project(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	id := project.ID
+	for i := range s.projects {
+		if s.projects[i].ID == id {
+			s.projects = append(s.projects[:i], s.projects[i+1:]...)
+			break
+		}
+	}
+	for i := range s.todos {
+		todo := &s.todos[i]
+		if todo.ProjectID != nil && *todo.ProjectID == id && !todo.Deleted {
+			todo.Deleted = true
+			todo.Version++
+		}
+	}
+	respond(w, http.StatusNoContent, nil)
+}
+
+// getTodos lists every todo, soft-deleted ones included, optionally only
+// those of ?project_id
+func (s *Server) getTodos(w http.ResponseWriter, r *http.Request) {
+	projectID := -1
+	if v := r.URL.Query().Get("project_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			fail(w, invalid("project_id", "must be a number"))
+			return
+		}
+		projectID = id
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todos := []api.Todo{}
+	for _, todo := range s.todos {
+		if projectID < 0 || (todo.ProjectID != nil && *todo.ProjectID == projectID) {
+			todos = append(todos, todo)
+		}
+	}
+	respond(w, http.StatusOK, todos)
+}
+
+// getTodo returns a todo, even a soft-deleted one
+func (s *Server) getTodo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, err := pathID(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	for _, todo := range s.todos {
+		if todo.ID == id {
+			respond(w, http.StatusOK, todo)
+			return
+		}
+	}
+	fail(w, notFound("todo", id))
+}
+
+func (s *Server) createTodo(w http.ResponseWriter, r *http.Request) {
+	var body todoBody
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := required("description", body.Description); err != nil {
+		fail(w, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkProject(body.ProjectID); err != nil {
+		fail(w, err)
+		return
+	}
+	s.lastTodoID++
+	todo := api.Todo{ID: s.lastTodoID, Description: body.Description, Priority: body.Priority, ProjectID: body.ProjectID, Version: 1}
+	s.todos = append(s.todos, todo)
+	respond(w, http.StatusCreated, todo)
+}
+
+func (s *Server) updateTodo(w http.ResponseWriter, r *http.Request) {
+	var body todoBody
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if err := required("description", body.Description); err != nil {
+		fail(w, err)
+		return
+	}
+	s.changeTodo(w, r, func(todo *api.Todo) error {
+		if err := checkVersion(r, "todo", todo.ID, todo.Version); err != nil {
+			return err
+		}
+		if err := s.checkProject(body.ProjectID); err != nil {
+			return err
+		}
+		todo.Description, todo.Priority, todo.ProjectID = body.Description, body.Priority, body.ProjectID
+		return nil
+	})
+}
+
+func (s *Server) updateTodoCompleted(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Completed *bool `json:"completed"`
+	}
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	if body.Completed == nil {
+		fail(w, invalid("completed", "is required"))
+		return
+	}
+	s.changeTodo(w, r, func(todo *api.Todo) error {
+		if todo.Completed == *body.Completed {
+			return nil
+		}
+		todo.Completed, todo.CompletedAt = *body.Completed, time.Time{}
+		if todo.Completed {
+			todo.CompletedAt = time.Now()
+		}
+		return nil
+	})
+}
+
+func (s *Server) updateTodoNotes(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	if err := decode(r, &body); err != nil {
+		fail(w, err)
+		return
+	}
+	s.changeTodo(w, r, func(todo *api.Todo) error {
+		todo.Notes = body.Notes
+		return nil
+	})
+}
+
+// deleteTodo soft-deletes a todo: it stays listed, marked deleted
+func (s *Server) deleteTodo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, err := s.liveTodo(r)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	todo.Deleted = true
+	todo.Version++
+	respond(w, http.StatusNoContent, nil)
+}
+
+// changeProject applies edit to the project of the request path, bumps its
+// version and responds with it
+func (s *Server) changeProject(w http.ResponseWriter, r *http.Request, edit func(*api.Project) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	project, err := s.project(r)
+	if err == nil {
+		err = edit(project)
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	project.UpdatedAt = time.Now()
+	project.Version++
+	respond(w, http.StatusOK, *project)
+}
+
+// changeTodo applies edit to the todo of the request path, bumps its version
+// and responds with it
+func (s *Server) changeTodo(w http.ResponseWriter, r *http.Request, edit func(*api.Todo) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, err := s.liveTodo(r)
+	if err == nil {
+		err = edit(todo)
+	}
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	todo.Version++
+	respond(w, http.StatusOK, *todo)
+}
+
+// project returns the project of the request path. The caller holds s.mu.
+func (s *Server) project(r *http.Request) (*api.Project, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.projects {
+		if s.projects[i].ID == id {
+			return &s.projects[i], nil
+		}
+	}
+	return nil, notFound("project", id)
+}
+
+// liveTodo returns the todo of the request path; soft-deleted todos can't be
+// changed. The caller holds s.mu.
+func (s *Server) liveTodo(r *http.Request) (*api.Todo, error) {
+	id, err := pathID(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.todos {
+		if s.todos[i].ID == id && !s.todos[i].Deleted {
+			return &s.todos[i], nil
+		}
+	}
+	return nil, notFound("todo", id)
+}
+
+// checkProject fails if a todo would be assigned to a project that doesn't
+// exist; nil is the inbox. The caller holds s.mu.
+func (s *Server) checkProject(projectID *int) error {
+	if projectID == nil {
+		return nil
+	}
+	for _, project := range s.projects {
+		if project.ID == *projectID {
+			return nil
+		}
+	}
+	return invalid("project_id", fmt.Sprintf("project %d does not exist", *projectID))
+}
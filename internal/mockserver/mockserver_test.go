@@ -0,0 +1,237 @@
+package mockserver
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// newClient starts a server holding fixture and returns a client for it
+func newClient(t *testing.T, fixture Fixture) (*api.Client, *Server) {
+	t.Helper()
+	server := New(fixture)
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+	policy := api.DefaultRetryPolicy
+	policy.MaxAttempts = 1
+	return api.NewClient(srv.URL+"/api", api.WithRetryPolicy(policy)), server
+}
+
+func TestProjectCRUD(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, Fixture{})
+
+	created, err := client.CreateProject(ctx, "pj", "tui", "~/src/pj", "main.go", "go", 2, "ready")
+	if err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	if created.ID != 1 || created.Version != 1 || created.Name != "pj" {
+		t.Errorf("CreateProject = %+v, want ID 1 at version 1", created)
+	}
+
+	got, err := client.GetProject(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	if got.Name != "pj" || got.Language != "go" || got.Priority != 2 {
+		t.Errorf("GetProject = %+v", got)
+	}
+
+	updated, err := client.UpdateProject(ctx, created.ID, created.Version, "pj-tui", "tui", "~/src/pj", "main.go", "go", 3, "in_progress")
+	if err != nil {
+		t.Fatalf("UpdateProject: %v", err)
+	}
+	if updated.Name != "pj-tui" || updated.Status != "in_progress" || updated.Version != 2 {
+		t.Errorf("UpdateProject = %+v, want renamed at version 2", updated)
+	}
+
+	status, err := client.UpdateProjectStatus(ctx, created.ID, "finished")
+	if err != nil {
+		t.Fatalf("UpdateProjectStatus: %v", err)
+	}
+	priority, err := client.UpdateProjectPriority(ctx, created.ID, 0)
+	if err != nil {
+		t.Fatalf("UpdateProjectPriority: %v", err)
+	}
+	if status.Status != "finished" || priority.Status != "finished" || priority.Priority != 0 || priority.Name != "pj-tui" {
+		t.Errorf("partial updates = %+v then %+v, want only their field changed", status, priority)
+	}
+
+	if err := client.DeleteProject(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	if _, err := client.GetProject(ctx, created.ID); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetProject after delete: err = %v, want ErrNotFound", err)
+	}
+	projects, err := client.GetProjects(ctx)
+	if err != nil || len(projects) != 0 {
+		t.Errorf("GetProjects after delete = %v, %v; want none", projects, err)
+	}
+}
+
+func TestTodoCRUD(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, Demo())
+
+	projectID := 2
+	created, err := client.CreateTodo(ctx, "write tests", 1, &projectID)
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	if created.ID != 11 || created.ProjectID == nil || *created.ProjectID != projectID {
+		t.Errorf("CreateTodo = %+v, want ID 11 in project %d", created, projectID)
+	}
+
+	updated, err := client.UpdateTodo(ctx, created.ID, created.Version, "write more tests", 3, nil)
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if updated.Description != "write more tests" || updated.Priority != 3 || updated.ProjectID != nil {
+		t.Errorf("UpdateTodo = %+v, want moved to the inbox", updated)
+	}
+
+	done, err := client.UpdateTodoCompleted(ctx, created.ID, true)
+	if err != nil {
+		t.Fatalf("UpdateTodoCompleted: %v", err)
+	}
+	if !done.Completed || done.CompletedAt.IsZero() {
+		t.Errorf("UpdateTodoCompleted = %+v, want completed with a time", done)
+	}
+
+	noted, err := client.UpdateTodoNotes(ctx, created.ID, "# Plan")
+	if err != nil {
+		t.Fatalf("UpdateTodoNotes: %v", err)
+	}
+	if noted.Notes != "# Plan" || !noted.Completed {
+		t.Errorf("UpdateTodoNotes = %+v, want notes set and still completed", noted)
+	}
+
+	todos, err := client.GetTodosByProject(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetTodosByProject: %v", err)
+	}
+	for _, todo := range todos {
+		if todo.ProjectID == nil || *todo.ProjectID != 1 {
+			t.Errorf("GetTodosByProject(1) returned %+v", todo)
+		}
+	}
+	if len(todos) != 4 {
+		t.Errorf("GetTodosByProject(1) returned %d todos, want 4", len(todos))
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	client, server := newClient(t, Demo())
+
+	if err := client.DeleteTodo(ctx, 3); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+	todo, err := client.GetTodo(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetTodo of a deleted todo: %v", err)
+	}
+	if !todo.Deleted {
+		t.Errorf("GetTodo = %+v, want it marked deleted", todo)
+	}
+	if err := client.DeleteTodo(ctx, 3); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("second DeleteTodo: err = %v, want ErrNotFound", err)
+	}
+	if _, err := client.UpdateTodoNotes(ctx, 3, "too late"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("UpdateTodoNotes of a deleted todo: err = %v, want ErrNotFound", err)
+	}
+
+	// Deleting a project soft-deletes its todos
+	if err := client.DeleteProject(ctx, 1); err != nil {
+		t.Fatalf("DeleteProject: %v", err)
+	}
+	for _, todo := range server.Snapshot().Todos {
+		if todo.ProjectID != nil && *todo.ProjectID == 1 && !todo.Deleted {
+			t.Errorf("todo %d of the deleted project is not deleted", todo.ID)
+		}
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, Demo())
+
+	project, err := client.GetProject(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetProject: %v", err)
+	}
+	// Someone else changes the project, bumping its version
+	if _, err := client.UpdateProjectPriority(ctx, 1, 0); err != nil {
+		t.Fatalf("UpdateProjectPriority: %v", err)
+	}
+
+	_, err = client.UpdateProject(ctx, 1, project.Version, "stale", "", "", "", "", 0, "ready")
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 412 || !errors.Is(err, api.ErrConflict) {
+		t.Fatalf("UpdateProject with a stale version: err = %v, want 412 and ErrConflict", err)
+	}
+
+	// Version 0 overwrites
+	if _, err := client.UpdateProject(ctx, 1, 0, "forced", "", "", "", "", 0, "ready"); err != nil {
+		t.Errorf("UpdateProject without a version: %v", err)
+	}
+
+	todo, err := client.GetTodo(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetTodo: %v", err)
+	}
+	if _, err := client.UpdateTodoCompleted(ctx, 2, true); err != nil {
+		t.Fatalf("UpdateTodoCompleted: %v", err)
+	}
+	if _, err := client.UpdateTodo(ctx, 2, todo.Version, "stale", 0, todo.ProjectID); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("UpdateTodo with a stale version: err = %v, want ErrConflict", err)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, Demo())
+
+	missing := 99
+	tests := []struct {
+		name  string
+		call  func() error
+		field string
+	}{
+		{"todo in missing project", func() error {
+			_, err := client.CreateTodo(ctx, "orphan", 0, &missing)
+			return err
+		}, "project_id"},
+		{"todo moved to missing project", func() error {
+			_, err := client.UpdateTodo(ctx, 2, 0, "moved", 0, &missing)
+			return err
+		}, "project_id"},
+		{"project without a name", func() error {
+			_, err := client.CreateProject(ctx, " ", "", "", "", "", 0, "ready")
+			return err
+		}, "name"},
+		{"todo without a description", func() error {
+			_, err := client.CreateTodo(ctx, "", 0, nil)
+			return err
+		}, "description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var apiErr *api.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != 400 || !errors.Is(err, api.ErrValidation) {
+				t.Fatalf("err = %v, want 400 and ErrValidation", err)
+			}
+			if api.FieldErrors(err)[tt.field] == "" {
+				t.Errorf("field errors = %v, want one for %s", api.FieldErrors(err), tt.field)
+			}
+		})
+	}
+
+	if _, err := client.GetTodo(ctx, 999); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("GetTodo of a missing todo: err = %v, want ErrNotFound", err)
+	}
+}
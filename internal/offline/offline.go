@@ -0,0 +1,359 @@
+// Package offline keeps pj-tui usable while the projectarium-v2 server is
+// unreachable. Its Backend wraps another backend, caching what it reads so
+// the board can open from the cache, and queueing the changes made meanwhile
+// in a durable outbox that is replayed in order once the server is back.
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/state"
+)
+
+// errNotCached is returned for reads while offline before anything was cached
+var errNotCached = fmt.Errorf("nothing cached for offline use yet: %w", api.ErrUnavailable)
+
+// store is the contents of the cache file: the last projects and todos read
+// from the server, with the queued changes applied, and the queue itself
+type store struct {
+	// URL is the API endpoint the cache belongs to
+	URL string `json:"url"`
+	// Fetched is when projects were last read from the server; zero if
+	// they never were
+	Fetched  time.Time     `json:"fetched,omitzero"`
+	Projects []api.Project `json:"projects"`
+	Todos    []api.Todo    `json:"todos"`
+	Outbox   []entry       `json:"outbox,omitempty"`
+	// LastID is the last ID given to a project or todo created offline
+	LastID int `json:"last_id,omitempty"`
+}
+
+// Backend serves reads from the server while it is reachable and from the
+// cache while it isn't, and queues changes made offline. It is safe for
+// concurrent use.
+type Backend struct {
+	remote api.Backend
+	path   string
+
+	syncing sync.Mutex // held for the whole of a Sync
+	mu      sync.Mutex // guards the fields below
+	store   store
+	offline bool
+}
+
+// Ensure Backend can stand in for the client
+var (
+	_ api.Backend         = (*Backend)(nil)
+	_ api.Syncer          = (*Backend)(nil)
+	_ api.Reauthenticator = (*Backend)(nil)
+)
+
+// DefaultPath returns the cache file for the API at url, next to the state
+// file: $XDG_STATE_HOME/pj-tui/offline/<hash of url>.json. Each endpoint has
+// its own file so an outbox is never replayed against the wrong server.
+func DefaultPath(url string) string {
+	statePath := state.DefaultPath()
+	if statePath == "" {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(url))
+	return filepath.Join(filepath.Dir(statePath), "offline", fmt.Sprintf("%08x.json", h.Sum32()))
+}
+
+// Open wraps remote with the cache at path, which belongs to the API at url;
+// an empty path keeps the cache in memory only. A corrupt cache file is
+// moved aside and reported along with a Backend that starts empty, so it can
+// be ignored.
+func Open(remote api.Backend, path, url string) (*Backend, error) {
+	b := &Backend{remote: remote, path: path, store: store{URL: url}}
+	if path == "" {
+		return b, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, err
+	}
+	var s store
+	if err := json.Unmarshal(data, &s); err != nil {
+		// Keep the file around; its outbox may hold changes worth saving
+		if renameErr := os.Rename(path, path+".corrupt"); renameErr != nil {
+			return b, fmt.Errorf("%s: %w", path, err)
+		}
+		return b, fmt.Errorf("%s: %w (moved to %s.corrupt)", path, err, path)
+	}
+	if s.URL == url {
+		b.store = s
+	}
+	return b, nil
+}
+
+// SyncStatus implements api.Syncer
+func (b *Backend) SyncStatus() api.SyncStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return api.SyncStatus{Offline: b.offline, Pending: len(b.store.Outbox)}
+}
+
+// SetSecret passes new credentials on to the wrapped backend, if it takes them
+func (b *Backend) SetSecret(secret string) {
+	if r, ok := b.remote.(api.Reauthenticator); ok {
+		r.SetSecret(secret)
+	}
+}
+
+// cached reports whether requests are answered locally: while the server is
+// unreachable, and until queued changes are sent so they aren't overtaken
+func (b *Backend) cached() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offline || len(b.store.Outbox) > 0
+}
+
+// goOffline switches to the cache if err means the server can't be reached,
+// reporting whether it did
+func (b *Backend) goOffline(err error) bool {
+	if !errors.Is(err, api.ErrUnavailable) {
+		return false
+	}
+	b.mu.Lock()
+	b.offline = true
+	b.mu.Unlock()
+	return true
+}
+
+// read returns what fetch gets from the server, storing it in the cache with
+// keep, or what local finds in the cache while the server is unreachable
+func read[T any](b *Backend, fetch func() (T, error), keep func(*store, T), local func(*store) (T, error)) (T, error) {
+	var fetchErr error
+	if !b.cached() {
+		v, err := fetch()
+		if err == nil {
+			b.update(func(s *store) { keep(s, v) })
+			return v, nil
+		}
+		if !b.goOffline(err) {
+			return v, err
+		}
+		fetchErr = err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, err := local(&b.store)
+	if errors.Is(err, errNotCached) && fetchErr != nil {
+		// Why the server couldn't be reached says more
+		return v, fetchErr
+	}
+	return v, err
+}
+
+// update changes the cache and saves it. Failing to save only costs what
+// would have been served offline, so it isn't reported.
+func (b *Backend) update(change func(*store)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	change(&b.store)
+	_ = b.save()
+}
+
+// save writes the cache file, replacing it atomically so a crash can't
+// leave it half-written. The caller holds b.mu.
+func (b *Backend) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(b.store)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".offline-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+// GetProjects implements api.Backend
+func (b *Backend) GetProjects(ctx context.Context) ([]api.Project, error) {
+	return read(b,
+		func() ([]api.Project, error) { return b.remote.GetProjects(ctx) },
+		func(s *store, projects []api.Project) {
+			// Copied, since the cache is changed in place
+			s.Projects = append([]api.Project(nil), projects...)
+			s.Fetched = time.Now()
+		},
+		func(s *store) ([]api.Project, error) {
+			if s.Fetched.IsZero() && len(s.Outbox) == 0 {
+				return nil, errNotCached
+			}
+			return append([]api.Project{}, s.Projects...), nil
+		})
+}
+
+// GetProject implements api.Backend
+func (b *Backend) GetProject(ctx context.Context, id int) (*api.Project, error) {
+	return read(b,
+		func() (*api.Project, error) { return b.remote.GetProject(ctx, id) },
+		func(s *store, project *api.Project) { s.putProject(*project) },
+		func(s *store) (*api.Project, error) {
+			i := s.findProject(id)
+			if i < 0 {
+				return nil, notFound("project", id)
+			}
+			project := s.Projects[i]
+			return &project, nil
+		})
+}
+
+// GetTodos implements api.Backend
+func (b *Backend) GetTodos(ctx context.Context) ([]api.Todo, error) {
+	return read(b,
+		func() ([]api.Todo, error) { return b.remote.GetTodos(ctx) },
+		func(s *store, todos []api.Todo) { s.Todos = append([]api.Todo(nil), todos...) },
+		func(s *store) ([]api.Todo, error) {
+			if s.Fetched.IsZero() && len(s.Outbox) == 0 {
+				return nil, errNotCached
+			}
+			return append([]api.Todo{}, s.Todos...), nil
+		})
+}
+
+// GetTodosByProject implements api.Backend
+func (b *Backend) GetTodosByProject(ctx context.Context, projectID int) ([]api.Todo, error) {
+	return read(b,
+		func() ([]api.Todo, error) { return b.remote.GetTodosByProject(ctx, projectID) },
+		func(s *store, todos []api.Todo) {
+			kept := s.Todos[:0]
+			for _, todo := range s.Todos {
+				if todo.ProjectID == nil || *todo.ProjectID != projectID {
+					kept = append(kept, todo)
+				}
+			}
+			s.Todos = append(kept, todos...)
+		},
+		func(s *store) ([]api.Todo, error) {
+			if s.Fetched.IsZero() && len(s.Outbox) == 0 {
+				return nil, errNotCached
+			}
+			todos := []api.Todo{}
+			for _, todo := range s.Todos {
+				if todo.ProjectID != nil && *todo.ProjectID == projectID {
+					todos = append(todos, todo)
+				}
+			}
+			return todos, nil
+		})
+}
+
+// GetTodo implements api.Backend
+func (b *Backend) GetTodo(ctx context.Context, id int) (*api.Todo, error) {
+	return read(b,
+		func() (*api.Todo, error) { return b.remote.GetTodo(ctx, id) },
+		func(s *store, todo *api.Todo) { s.putTodo(*todo) },
+		func(s *store) (*api.Todo, error) {
+			i := s.findTodo(id)
+			if i < 0 {
+				return nil, notFound("todo", id)
+			}
+			todo := s.Todos[i]
+			return &todo, nil
+		})
+}
+
+// CreateProject implements api.Backend
+func (b *Backend) CreateProject(ctx context.Context, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	r, err := b.write(ctx, entry{Op: api.OpCreateProject, Project: api.Project{
+		Name: name, Description: description, Path: path, File: file,
+		Language: language, Priority: priority, Status: status,
+	}})
+	return r.project, err
+}
+
+// UpdateProject implements api.Backend
+func (b *Backend) UpdateProject(ctx context.Context, id, version int, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateProject, Project: api.Project{
+		ID: id, Version: version, Name: name, Description: description, Path: path, File: file,
+		Language: language, Priority: priority, Status: status,
+	}})
+	return r.project, err
+}
+
+// UpdateProjectStatus implements api.Backend
+func (b *Backend) UpdateProjectStatus(ctx context.Context, id int, status string) (*api.Project, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateProjectStatus, Project: api.Project{ID: id, Status: status}})
+	return r.project, err
+}
+
+// UpdateProjectPriority implements api.Backend
+func (b *Backend) UpdateProjectPriority(ctx context.Context, id int, priority int) (*api.Project, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateProjectPriority, Project: api.Project{ID: id, Priority: priority}})
+	return r.project, err
+}
+
+// DeleteProject implements api.Backend
+func (b *Backend) DeleteProject(ctx context.Context, id int) error {
+	_, err := b.write(ctx, entry{Op: api.OpDeleteProject, Project: api.Project{ID: id}})
+	return err
+}
+
+// CreateTodo implements api.Backend
+func (b *Backend) CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*api.Todo, error) {
+	r, err := b.write(ctx, entry{Op: api.OpCreateTodo, Todo: api.Todo{
+		Description: description, Priority: priority, ProjectID: projectID,
+	}})
+	return r.todo, err
+}
+
+// UpdateTodo implements api.Backend
+func (b *Backend) UpdateTodo(ctx context.Context, id, version int, description string, priority int, projectID *int) (*api.Todo, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateTodo, Todo: api.Todo{
+		ID: id, Version: version, Description: description, Priority: priority, ProjectID: projectID,
+	}})
+	return r.todo, err
+}
+
+// UpdateTodoCompleted implements api.Backend
+func (b *Backend) UpdateTodoCompleted(ctx context.Context, id int, completed bool) (*api.Todo, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateTodoCompleted, Todo: api.Todo{ID: id, Completed: completed}})
+	return r.todo, err
+}
+
+// UpdateTodoNotes implements api.Backend
+func (b *Backend) UpdateTodoNotes(ctx context.Context, id int, notes string) (*api.Todo, error) {
+	r, err := b.write(ctx, entry{Op: api.OpUpdateTodoNotes, Todo: api.Todo{ID: id, Notes: notes}})
+	return r.todo, err
+}
+
+// DeleteTodo implements api.Backend
+func (b *Backend) DeleteTodo(ctx context.Context, id int) error {
+	_, err := b.write(ctx, entry{Op: api.OpDeleteTodo, Todo: api.Todo{ID: id}})
+	return err
+}
+
+// notFound is the error for a project or todo missing from the cache
+func notFound(kind string, id int) error {
+	return fmt.Errorf("%s %d: %w", kind, id, api.ErrNotFound)
+}
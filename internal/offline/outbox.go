@@ -0,0 +1,429 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// localIDBase is where the IDs of projects and todos created offline start,
+// far above the IDs the server hands out, until the server assigns real ones
+const localIDBase = 1 << 30
+
+// entry is a change queued in the outbox: the operation and its arguments,
+// held in a project or todo
+type entry struct {
+	Op       api.Operation `json:"op"`
+	Project  api.Project   `json:"project,omitzero"`
+	Todo     api.Todo      `json:"todo,omitzero"`
+	QueuedAt time.Time     `json:"queued_at"`
+}
+
+// describe names the change for error messages, e.g. "update todo 12"
+func (e entry) describe() string {
+	op := strings.ReplaceAll(string(e.Op), "_", " ")
+	if e.Op == api.OpCreateTodo {
+		return fmt.Sprintf("%s %q", op, e.Todo.Description)
+	}
+	if e.isTodo() {
+		return fmt.Sprintf("%s %d", op, e.Todo.ID)
+	}
+	if e.Op == api.OpCreateProject {
+		return fmt.Sprintf("%s %q", op, e.Project.Name)
+	}
+	return fmt.Sprintf("%s %d", op, e.Project.ID)
+}
+
+// isTodo reports whether the change affects a todo rather than a project
+func (e entry) isTodo() bool {
+	switch e.Op {
+	case api.OpCreateTodo, api.OpUpdateTodo, api.OpUpdateTodoCompleted, api.OpUpdateTodoNotes, api.OpDeleteTodo:
+		return true
+	}
+	return false
+}
+
+// isCreate reports whether the change creates a project or todo, which the
+// server can't tell apart from a second copy if it is sent twice
+func (e entry) isCreate() bool {
+	return e.Op == api.OpCreateProject || e.Op == api.OpCreateTodo
+}
+
+// uncertain reports whether a change failed in a way that leaves open
+// whether the server carried it out, and sending it again could repeat it
+func (e entry) uncertain(err error) bool {
+	return e.isCreate() && errors.Is(err, api.ErrUnavailable) && !api.NotSent(err)
+}
+
+// result is what a change returns: the project or todo after it, if any
+type result struct {
+	project *api.Project
+	todo    *api.Todo
+}
+
+// write sends a change to the server and caches its result, or applies it
+// to the cache and queues it while the server is unreachable. A create that
+// may have reached the server before failing isn't queued, since sending it
+// again could make it twice.
+func (b *Backend) write(ctx context.Context, e entry) (result, error) {
+	if !b.cached() {
+		r, err := send(ctx, b.remote, e)
+		if err == nil {
+			b.update(func(s *store) { s.keep(e, r) })
+			return r, nil
+		}
+		if !b.goOffline(err) || e.uncertain(err) {
+			return r, err
+		}
+	}
+	return b.queue(e)
+}
+
+// queue applies a change to the cache and adds it to the outbox. The change
+// is only accepted once it is safely on disk.
+func (b *Backend) queue(e entry) (result, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	before := b.store.clone()
+	switch e.Op {
+	case api.OpCreateProject:
+		e.Project.ID = b.store.nextID()
+	case api.OpCreateTodo:
+		e.Todo.ID = b.store.nextID()
+	}
+	r, err := b.store.apply(e)
+	if err != nil {
+		b.store = before
+		return result{}, err
+	}
+	e.QueuedAt = time.Now()
+	b.store.Outbox = append(b.store.Outbox, e)
+	if err := b.save(); err != nil {
+		b.store = before
+		return result{}, fmt.Errorf("failed to queue change while offline: %w", err)
+	}
+	return r, nil
+}
+
+// Sync implements api.Syncer. Changes the server rejects are dropped and
+// reported; on success the cache is refreshed and the backend is back online.
+func (b *Backend) Sync(ctx context.Context) (api.SyncResult, error) {
+	b.syncing.Lock()
+	defer b.syncing.Unlock()
+
+	var res api.SyncResult
+	for {
+		b.mu.Lock()
+		if len(b.store.Outbox) == 0 {
+			b.mu.Unlock()
+			break
+		}
+		e := b.store.Outbox[0]
+		b.mu.Unlock()
+
+		r, err := send(ctx, b.remote, e)
+		uncertain := e.uncertain(err)
+		if err != nil && !uncertain && (errors.Is(err, api.ErrUnavailable) || errors.Is(err, api.ErrUnauthorized) || ctx.Err() != nil) {
+			// Nothing was decided; try again later
+			b.goOffline(err)
+			return res, err
+		}
+
+		b.mu.Lock()
+		b.store.Outbox = b.store.Outbox[1:]
+		switch {
+		case uncertain:
+			// Sending it again could make it twice, so it's left to the user
+			res.Dropped = append(res.Dropped, fmt.Errorf("%s may not have been saved; check before making it again: %w", e.describe(), err))
+		case err != nil:
+			res.Dropped = append(res.Dropped, fmt.Errorf("%s: %w", e.describe(), err))
+		default:
+			res.Sent++
+			switch {
+			case e.Op == api.OpCreateProject && r.project != nil:
+				b.store.remap(false, e.Project.ID, r.project.ID)
+			case e.Op == api.OpCreateTodo && r.todo != nil:
+				b.store.remap(true, e.Todo.ID, r.todo.ID)
+			}
+			switch {
+			case r.project != nil:
+				b.store.rebase(false, r.project.ID, r.project.Version)
+			case r.todo != nil:
+				b.store.rebase(true, r.todo.ID, r.todo.Version)
+			}
+		}
+		err = b.save()
+		b.mu.Unlock()
+		if err != nil {
+			return res, err
+		}
+	}
+
+	// Refresh the cache, which also tells whether the server is back
+	projects, err := b.remote.GetProjects(ctx)
+	if err != nil {
+		b.goOffline(err)
+		return res, err
+	}
+	todos, err := b.remote.GetTodos(ctx)
+	if err != nil {
+		b.goOffline(err)
+		return res, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.store.Projects, b.store.Todos, b.store.Fetched = projects, todos, time.Now()
+	b.offline = false
+	_ = b.save()
+	return res, nil
+}
+
+// send makes the API call for a change
+func send(ctx context.Context, remote api.Backend, e entry) (result, error) {
+	var r result
+	var err error
+	p, t := e.Project, e.Todo
+	switch e.Op {
+	case api.OpCreateProject:
+		r.project, err = remote.CreateProject(ctx, p.Name, p.Description, p.Path, p.File, p.Language, p.Priority, p.Status)
+	case api.OpUpdateProject:
+		r.project, err = remote.UpdateProject(ctx, p.ID, p.Version, p.Name, p.Description, p.Path, p.File, p.Language, p.Priority, p.Status)
+	case api.OpUpdateProjectStatus:
+		r.project, err = remote.UpdateProjectStatus(ctx, p.ID, p.Status)
+	case api.OpUpdateProjectPriority:
+		r.project, err = remote.UpdateProjectPriority(ctx, p.ID, p.Priority)
+	case api.OpDeleteProject:
+		err = remote.DeleteProject(ctx, p.ID)
+	case api.OpCreateTodo:
+		r.todo, err = remote.CreateTodo(ctx, t.Description, t.Priority, t.ProjectID)
+	case api.OpUpdateTodo:
+		r.todo, err = remote.UpdateTodo(ctx, t.ID, t.Version, t.Description, t.Priority, t.ProjectID)
+	case api.OpUpdateTodoCompleted:
+		r.todo, err = remote.UpdateTodoCompleted(ctx, t.ID, t.Completed)
+	case api.OpUpdateTodoNotes:
+		r.todo, err = remote.UpdateTodoNotes(ctx, t.ID, t.Notes)
+	case api.OpDeleteTodo:
+		err = remote.DeleteTodo(ctx, t.ID)
+	default:
+		err = fmt.Errorf("unknown operation %q", e.Op)
+	}
+	return r, err
+}
+
+// keep updates the cache with the server's result of a change
+func (s *store) keep(e entry, r result) {
+	switch {
+	case r.project != nil:
+		s.putProject(*r.project)
+	case r.todo != nil:
+		s.putTodo(*r.todo)
+	case e.Op == api.OpDeleteProject:
+		s.removeProject(e.Project.ID)
+	case e.Op == api.OpDeleteTodo:
+		s.removeTodo(e.Todo.ID)
+	}
+}
+
+// apply makes a change to the cache the way the server would, returning the
+// changed project or todo
+func (s *store) apply(e entry) (result, error) {
+	now := time.Now()
+
+	if e.isTodo() {
+		if e.Op == api.OpCreateTodo {
+			todo := e.Todo
+			s.Todos = append(s.Todos, todo)
+			return result{todo: &todo}, nil
+		}
+		i := s.findTodo(e.Todo.ID)
+		if i < 0 {
+			return result{}, notFound("todo", e.Todo.ID)
+		}
+		todo := &s.Todos[i]
+		if e.Op == api.OpUpdateTodo && stale(e.Todo.Version, todo.Version) {
+			return result{}, fmt.Errorf("todo %d: %w", todo.ID, api.ErrConflict)
+		}
+		switch e.Op {
+		case api.OpUpdateTodo:
+			todo.Description, todo.Priority, todo.ProjectID = e.Todo.Description, e.Todo.Priority, e.Todo.ProjectID
+		case api.OpUpdateTodoCompleted:
+			todo.Completed, todo.CompletedAt = e.Todo.Completed, time.Time{}
+			if todo.Completed {
+				todo.CompletedAt = now
+			}
+		case api.OpUpdateTodoNotes:
+			todo.Notes = e.Todo.Notes
+		case api.OpDeleteTodo:
+			s.removeTodo(e.Todo.ID)
+			return result{}, nil
+		}
+		changed := *todo
+		return result{todo: &changed}, nil
+	}
+
+	if e.Op == api.OpCreateProject {
+		project := e.Project
+		project.UpdatedAt = now
+		s.Projects = append(s.Projects, project)
+		return result{project: &project}, nil
+	}
+	i := s.findProject(e.Project.ID)
+	if i < 0 {
+		return result{}, notFound("project", e.Project.ID)
+	}
+	project := &s.Projects[i]
+	if e.Op == api.OpUpdateProject && stale(e.Project.Version, project.Version) {
+		return result{}, fmt.Errorf("project %d: %w", project.ID, api.ErrConflict)
+	}
+	switch e.Op {
+	case api.OpUpdateProject:
+		*project = e.Project
+	case api.OpUpdateProjectStatus:
+		project.Status = e.Project.Status
+	case api.OpUpdateProjectPriority:
+		project.Priority = e.Project.Priority
+	case api.OpDeleteProject:
+		s.removeProject(e.Project.ID)
+		return result{}, nil
+	}
+	project.UpdatedAt = now
+	changed := *project
+	return result{project: &changed}, nil
+}
+
+// stale reports whether an edit based on version would overwrite a newer
+// cached one, the way the server would refuse it
+func stale(version, cached int) bool {
+	return version != 0 && cached != 0 && version != cached
+}
+
+// nextID hands out an ID for a project or todo created offline
+func (s *store) nextID() int {
+	s.LastID = max(s.LastID, localIDBase) + 1
+	return s.LastID
+}
+
+// remap points the cache and the queued changes at the ID the server gave a
+// project or todo created offline
+func (s *store) remap(todo bool, oldID, newID int) {
+	swap := func(id *int) {
+		if *id == oldID {
+			*id = newID
+		}
+	}
+	// Project references may be shared, so they are replaced, not changed
+	swapRef := func(ref **int) {
+		if *ref != nil && **ref == oldID {
+			id := newID
+			*ref = &id
+		}
+	}
+	if todo {
+		for i := range s.Todos {
+			swap(&s.Todos[i].ID)
+		}
+		for i := range s.Outbox {
+			if s.Outbox[i].isTodo() {
+				swap(&s.Outbox[i].Todo.ID)
+			}
+		}
+		return
+	}
+	for i := range s.Projects {
+		swap(&s.Projects[i].ID)
+	}
+	for i := range s.Todos {
+		swapRef(&s.Todos[i].ProjectID)
+	}
+	for i := range s.Outbox {
+		if s.Outbox[i].isTodo() {
+			swapRef(&s.Outbox[i].Todo.ProjectID)
+		} else {
+			swap(&s.Outbox[i].Project.ID)
+		}
+	}
+}
+
+// rebase moves the queued edits of a project or todo onto the version the
+// server gave it for the change before them, which was made offline too, so
+// they don't conflict with it
+func (s *store) rebase(todo bool, id, version int) {
+	if version == 0 {
+		return
+	}
+	for i := range s.Outbox {
+		e := &s.Outbox[i]
+		switch {
+		case todo && e.isTodo() && e.Todo.ID == id && e.Todo.Version != 0:
+			e.Todo.Version = version
+		case !todo && !e.isTodo() && e.Project.ID == id && e.Project.Version != 0:
+			e.Project.Version = version
+		}
+	}
+}
+
+// clone returns a copy of the store that shares nothing with it
+func (s *store) clone() store {
+	c := *s
+	c.Projects = append([]api.Project(nil), s.Projects...)
+	c.Todos = append([]api.Todo(nil), s.Todos...)
+	c.Outbox = append([]entry(nil), s.Outbox...)
+	return c
+}
+
+// findProject returns the index of the cached project with the given ID, or -1
+func (s *store) findProject(id int) int {
+	for i, project := range s.Projects {
+		if project.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findTodo returns the index of the cached todo with the given ID, or -1
+func (s *store) findTodo(id int) int {
+	for i, todo := range s.Todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// putProject adds or replaces a cached project
+func (s *store) putProject(project api.Project) {
+	if i := s.findProject(project.ID); i >= 0 {
+		s.Projects[i] = project
+		return
+	}
+	s.Projects = append(s.Projects, project)
+}
+
+// putTodo adds or replaces a cached todo
+func (s *store) putTodo(todo api.Todo) {
+	if i := s.findTodo(todo.ID); i >= 0 {
+		s.Todos[i] = todo
+		return
+	}
+	s.Todos = append(s.Todos, todo)
+}
+
+// removeProject drops a project from the cache
+func (s *store) removeProject(id int) {
+	if i := s.findProject(id); i >= 0 {
+		s.Projects = append(s.Projects[:i], s.Projects[i+1:]...)
+	}
+}
+
+// removeTodo drops a todo from the cache
+func (s *store) removeTodo(id int) {
+	if i := s.findTodo(id); i >= 0 {
+		s.Todos = append(s.Todos[:i], s.Todos[i+1:]...)
+	}
+}
@@ -0,0 +1,105 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/mockserver"
+)
+
+// Transport errors as the client reports them
+var (
+	errRefused = &api.APIError{Op: api.OpCreateTodo, Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+	errTimeout = &api.APIError{Op: api.OpCreateTodo, Err: &net.OpError{Op: "read", Net: "tcp", Err: context.DeadlineExceeded}}
+)
+
+// flakyRemote is a client of a mock server whose creates fail with err
+// instead of being sent, while err is set
+type flakyRemote struct {
+	*api.Client
+	err error
+}
+
+func (r *flakyRemote) CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*api.Todo, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.Client.CreateTodo(ctx, description, priority, projectID)
+}
+
+func newFlakyRemote(t *testing.T) (*flakyRemote, *mockserver.Server) {
+	t.Helper()
+	server := mockserver.New(mockserver.Fixture{})
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+	return &flakyRemote{Client: api.NewClient(srv.URL + "/api")}, server
+}
+
+func TestCreateQueuedOnlyWhenNotSent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("dial failure", func(t *testing.T) {
+		remote, server := newFlakyRemote(t)
+		b, _ := Open(remote, "", "test")
+		remote.err = errRefused
+		todo, err := b.CreateTodo(ctx, "write tests", 0, nil)
+		if err != nil {
+			t.Fatalf("CreateTodo: %v, want it queued", err)
+		}
+		if todo.ID < localIDBase || b.SyncStatus().Pending != 1 {
+			t.Errorf("todo %d with %d pending, want a local ID and one queued change", todo.ID, b.SyncStatus().Pending)
+		}
+
+		remote.err = nil
+		res, err := b.Sync(ctx)
+		if err != nil || res.Sent != 1 {
+			t.Fatalf("Sync = %+v, %v; want the create sent", res, err)
+		}
+		if n := len(server.Snapshot().Todos); n != 1 {
+			t.Errorf("server has %d todos, want 1", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		remote, _ := newFlakyRemote(t)
+		b, _ := Open(remote, "", "test")
+		remote.err = errTimeout
+		if _, err := b.CreateTodo(ctx, "write tests", 0, nil); !errors.Is(err, errTimeout) {
+			t.Errorf("CreateTodo: err = %v, want the timeout", err)
+		}
+		if status := b.SyncStatus(); status.Pending != 0 || !status.Offline {
+			t.Errorf("status = %+v, want offline with nothing queued", status)
+		}
+	})
+
+	t.Run("timeout while syncing", func(t *testing.T) {
+		remote, server := newFlakyRemote(t)
+		b, _ := Open(remote, "", "test")
+		remote.err = errRefused
+		if _, err := b.CreateTodo(ctx, "write tests", 0, nil); err != nil {
+			t.Fatalf("CreateTodo: %v", err)
+		}
+
+		remote.err = errTimeout
+		res, _ := b.Sync(ctx)
+		if len(res.Dropped) != 1 || !strings.Contains(res.Dropped[0].Error(), "may not have been saved") {
+			t.Errorf("dropped = %v, want the create reported instead of sent again", res.Dropped)
+		}
+		if b.SyncStatus().Pending != 0 {
+			t.Error("uncertain create still queued")
+		}
+		remote.err = nil
+		if _, err := b.Sync(ctx); err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		if n := len(server.Snapshot().Todos); n != 0 {
+			t.Errorf("server has %d todos, want the create not sent again", n)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+// Package state persists what pj-tui remembers between runs, such as the
+// board's sort mode and the manual order of its cards. Unlike the config
+// file it is written by the program, not edited by hand.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State is the contents of the state file
+type State struct {
+	// Sort is the board's sort mode; empty uses the configured default
+	Sort string `json:"sort,omitempty"`
+	// Order holds the manual order of project IDs in each column, keyed by
+	// the column's status
+	Order map[string][]int `json:"order,omitempty"`
+
+	path string
+}
+
+// DefaultPath returns the state file location, following the XDG base
+// directory spec: $XDG_STATE_HOME/pj-tui/state.json, falling back to
+// ~/.local/state/pj-tui/state.json
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "pj-tui", "state.json")
+}
+
+// Load reads the state file at path. A missing file is an empty state; a
+// corrupt one is reported along with an empty state, so it can be ignored.
+func Load(path string) (*State, error) {
+	s := &State{Order: make(map[string][]int), path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return &State{Order: make(map[string][]int), path: path}, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Order == nil {
+		s.Order = make(map[string][]int)
+	}
+	return s, nil
+}
+
+// Save writes the state back to the file it was loaded from, replacing it
+// atomically so a crash can't leave it half-written
+func (s *State) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Snapshot returns a copy of the state that can be saved in the background
+// while the original keeps changing
+func (s *State) Snapshot() *State {
+	c := *s
+	c.Order = make(map[string][]int, len(s.Order))
+	for status, ids := range s.Order {
+		c.Order[status] = append([]int(nil), ids...)
+	}
+	return &c
+}
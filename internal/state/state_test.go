@@ -0,0 +1,113 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pj-tui", "state.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s.Sort = "priority"
+	s.Order["ready"] = []int{3, 1, 2}
+	s.Order["finished"] = []int{4}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load after Save: %v", err)
+	}
+	if loaded.Sort != "priority" || !reflect.DeepEqual(loaded.Order, s.Order) {
+		t.Errorf("loaded %+v, want %+v", loaded, s)
+	}
+
+	// Saving again replaces the file, leaving no temporary files behind
+	loaded.Sort = ""
+	if err := loaded.Save(); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("state directory holds %d files, want only the state", len(entries))
+	}
+	if again, _ := Load(path); again.Sort != "" || len(again.Order) != 2 {
+		t.Errorf("after the second Save, loaded %+v", again)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("Load of a missing file: %v", err)
+	}
+	if s.Sort != "" || s.Order == nil || len(s.Order) != 0 {
+		t.Errorf("missing file loaded as %+v, want an empty state", s)
+	}
+
+	// Without a path nothing is read or written
+	s, err = Load("")
+	if err != nil {
+		t.Fatalf("Load without a path: %v", err)
+	}
+	s.Sort = "name"
+	if err := s.Save(); err != nil {
+		t.Errorf("Save without a path: %v", err)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"sort": "name", "order": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err == nil {
+		t.Fatal("corrupt file loaded without an error")
+	}
+	if s == nil || s.Sort != "" || s.Order == nil {
+		t.Fatalf("corrupt file loaded as %+v, want a usable empty state", s)
+	}
+
+	// Saving the empty state replaces the corrupt file
+	s.Sort = "name"
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if fixed, err := Load(path); err != nil || fixed.Sort != "name" {
+		t.Errorf("after saving over the corrupt file, Load = %+v, %v", fixed, err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := &State{Sort: "name", Order: map[string][]int{"ready": {1, 2}}}
+	snap := s.Snapshot()
+	s.Order["ready"][0] = 9
+	s.Order["finished"] = []int{3}
+	if !reflect.DeepEqual(snap.Order, map[string][]int{"ready": {1, 2}}) {
+		t.Errorf("snapshot order = %v, want it unaffected by later changes", snap.Order)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	if got, want := DefaultPath(), filepath.Join("/xdg/state", "pj-tui", "state.json"); got != want {
+		t.Errorf("DefaultPath with XDG_STATE_HOME = %q, want %q", got, want)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", home)
+	if got, want := DefaultPath(), filepath.Join(home, ".local", "state", "pj-tui", "state.json"); got != want {
+		t.Errorf("DefaultPath without XDG_STATE_HOME = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,485 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// todoGrouping is how the todos view groups its todos
+type todoGrouping string
+
+const (
+	groupByProject  todoGrouping = "project"
+	groupByPriority todoGrouping = "priority"
+	groupNone       todoGrouping = "none"
+)
+
+// todoGroupings are the groupings in the order the group key cycles through
+var todoGroupings = []todoGrouping{groupByProject, groupByPriority, groupNone}
+
+// todoSort is the order of the todos within each group
+type todoSort string
+
+const (
+	todoSortPriority    todoSort = "priority"    // highest priority first
+	todoSortDescription todoSort = "description" // alphabetical
+	todoSortCompleted   todoSort = "completion"  // open first, then most recently done
+)
+
+// todoSorts are the sorts in the order the sort key cycles through them
+var todoSorts = []todoSort{todoSortPriority, todoSortDescription, todoSortCompleted}
+
+// orphanGroup heads the todos that don't belong to a project
+const orphanGroup = "No project"
+
+// AllTodos lists the todos of every project, and those of none, grouped by
+// project or priority
+type AllTodos struct {
+	todos     []api.Todo
+	projects  map[int]api.Project
+	loaded    bool
+	group     todoGrouping
+	sort      todoSort
+	hideDone  bool
+	query     string
+	search    textinput.Model
+	Searching bool // Exported so model.go can route keys to the search input
+	rows      []todoRow
+	selected  int // index into rows; always a todo row when there is one
+	offset    int // first row shown
+	width     int
+	height    int
+	theme     Theme
+	keys      keyMap
+}
+
+// todoRow is a line of the todos view: a group heading or a todo
+type todoRow struct {
+	heading string
+	todo    api.Todo
+	match   []int // matched positions in the description
+}
+
+// NewAllTodos creates the todos view, empty until SetTodos; projects names
+// the projects todos belong to
+func NewAllTodos(projects []api.Project, hideDone bool, theme Theme, keys keyMap) *AllTodos {
+	byID := make(map[int]api.Project, len(projects))
+	for _, p := range projects {
+		byID[p.ID] = p
+	}
+	search := newSearchInput()
+	search.Placeholder = "search description or project"
+	return &AllTodos{
+		projects: byID,
+		group:    groupByProject,
+		sort:     todoSortPriority,
+		hideDone: hideDone,
+		search:   search,
+		theme:    theme,
+		keys:     keys,
+	}
+}
+
+// SetTodos replaces the listed todos, keeping the selected one selected
+func (a *AllTodos) SetTodos(todos []api.Todo) {
+	a.todos = todos
+	a.loaded = true
+	a.rebuild()
+}
+
+// SetSize sets the view dimensions
+func (a *AllTodos) SetSize(width, height int) {
+	a.width = width
+	a.height = height
+	a.scrollToSelection()
+}
+
+// selectedTodo returns the selected todo, if any is shown
+func (a *AllTodos) selectedTodo() (api.Todo, bool) {
+	if a.selected >= len(a.rows) || a.rows[a.selected].heading != "" {
+		return api.Todo{}, false
+	}
+	return a.rows[a.selected].todo, true
+}
+
+// projectName names the project a todo belongs to
+func (a *AllTodos) projectName(todo api.Todo) string {
+	if todo.ProjectID == nil {
+		return orphanGroup
+	}
+	if p, ok := a.projects[*todo.ProjectID]; ok {
+		return p.Name
+	}
+	return fmt.Sprintf("Project #%d", *todo.ProjectID)
+}
+
+// rebuild recomputes the rows from the todos, grouping, sort, search and
+// hidden completed todos, keeping the selected todo selected if it is shown
+func (a *AllTodos) rebuild() {
+	selected, hadSelection := a.selectedTodo()
+
+	var todos []todoRow
+	for _, todo := range a.todos {
+		if todo.Deleted || (a.hideDone && todo.Completed) {
+			continue
+		}
+		row := todoRow{todo: todo}
+		if a.query != "" {
+			_, positions, ok := fuzzyMatch(a.query, todo.Description)
+			if !ok {
+				if _, _, ok = fuzzyMatch(a.query, a.projectName(todo)); !ok {
+					continue
+				}
+			}
+			row.match = positions
+		}
+		todos = append(todos, row)
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		return a.less(todos[i].todo, todos[j].todo)
+	})
+
+	a.rows = a.rows[:0]
+	switch a.group {
+	case groupNone:
+		a.rows = append(a.rows, todos...)
+	default:
+		groups := make(map[string][]todoRow)
+		var headings []string
+		for _, row := range todos {
+			heading := a.heading(row.todo)
+			if _, ok := groups[heading]; !ok {
+				headings = append(headings, heading)
+			}
+			groups[heading] = append(groups[heading], row)
+		}
+		sort.SliceStable(headings, func(i, j int) bool {
+			return a.headingLess(groups[headings[i]][0].todo, groups[headings[j]][0].todo)
+		})
+		for _, heading := range headings {
+			a.rows = append(a.rows, todoRow{heading: heading})
+			a.rows = append(a.rows, groups[heading]...)
+		}
+	}
+
+	a.selected = 0
+	if hadSelection {
+		for i, row := range a.rows {
+			if row.heading == "" && row.todo.ID == selected.ID {
+				a.selected = i
+			}
+		}
+	}
+	a.landOnTodo()
+}
+
+// heading returns the group a todo falls in
+func (a *AllTodos) heading(todo api.Todo) string {
+	if a.group == groupByPriority {
+		return priorityName(todo.Priority)
+	}
+	return a.projectName(todo)
+}
+
+// headingLess orders groups by their first todos: projects by name with
+// orphans last, priorities highest first
+func (a *AllTodos) headingLess(x, y api.Todo) bool {
+	if a.group == groupByPriority {
+		return x.Priority > y.Priority
+	}
+	if (x.ProjectID == nil) != (y.ProjectID == nil) {
+		return y.ProjectID == nil
+	}
+	return strings.ToLower(a.projectName(x)) < strings.ToLower(a.projectName(y))
+}
+
+// less orders todos within a group by the sort
+func (a *AllTodos) less(x, y api.Todo) bool {
+	switch a.sort {
+	case todoSortDescription:
+		xd, yd := strings.ToLower(x.Description), strings.ToLower(y.Description)
+		if xd != yd {
+			return xd < yd
+		}
+	case todoSortCompleted:
+		if x.Completed != y.Completed {
+			return y.Completed
+		}
+		if !x.CompletedAt.Equal(y.CompletedAt) {
+			return x.CompletedAt.After(y.CompletedAt)
+		}
+	}
+	if x.Priority != y.Priority {
+		return x.Priority > y.Priority
+	}
+	return x.ID < y.ID
+}
+
+// priorityName labels a priority group
+func priorityName(priority int) string {
+	switch priority {
+	case 0:
+		return "Low priority"
+	case 1:
+		return "Medium-low priority"
+	case 2:
+		return "Medium-high priority"
+	}
+	return "High priority"
+}
+
+// moveSelection moves the selection to the next todo up (dir -1) or down
+// (dir 1), skipping group headings
+func (a *AllTodos) moveSelection(dir int) {
+	for i := a.selected + dir; i >= 0 && i < len(a.rows); i += dir {
+		if a.rows[i].heading == "" {
+			a.selected = i
+			break
+		}
+	}
+	a.scrollToSelection()
+}
+
+// landOnTodo moves the selection off a group heading, to the todo below it
+// or else the last todo
+func (a *AllTodos) landOnTodo() {
+	a.selected = max(0, min(a.selected, len(a.rows)-1))
+	if a.selected < len(a.rows) && a.rows[a.selected].heading != "" {
+		a.moveSelection(1)
+		if a.rows[a.selected].heading != "" {
+			a.moveSelection(-1)
+		}
+	}
+	a.scrollToSelection()
+}
+
+// listHeight is the number of rows that fit between the title and help
+func (a *AllTodos) listHeight() int {
+	return max(a.height-6, 3)
+}
+
+// scrollToSelection scrolls the selected todo, and its heading when it is
+// the first of its group, into view
+func (a *AllTodos) scrollToSelection() {
+	height := a.listHeight()
+	top := a.selected
+	if top > 0 && a.rows[top-1].heading != "" {
+		top--
+	}
+	if top < a.offset {
+		a.offset = top
+	} else if a.selected >= a.offset+height {
+		a.offset = a.selected - height + 1
+	}
+	a.offset = max(0, min(a.offset, len(a.rows)-height))
+}
+
+// Update handles messages for the todos view
+func (a AllTodos) Update(msg tea.Msg) (AllTodos, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return a, nil
+	}
+
+	if a.Searching {
+		switch keyMsg.String() {
+		case "enter":
+			a.Searching = false
+			a.search.Blur()
+			return a, nil
+		case "esc":
+			a.Searching = false
+			a.search.Blur()
+			a.search.SetValue("")
+			a.query = ""
+			a.rebuild()
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.search, cmd = a.search.Update(msg)
+		if a.search.Value() != a.query {
+			a.query = a.search.Value()
+			a.selected = 0
+			a.rebuild()
+		}
+		return a, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, a.keys.Up):
+		a.moveSelection(-1)
+	case key.Matches(keyMsg, a.keys.Down):
+		a.moveSelection(1)
+	case key.Matches(keyMsg, a.keys.Enter):
+		if todo, ok := a.selectedTodo(); ok {
+			return a, func() tea.Msg {
+				return jumpToProjectMsg{todo: todo}
+			}
+		}
+	case key.Matches(keyMsg, a.keys.Group):
+		a.group = cycle(todoGroupings, a.group)
+		a.rebuild()
+	case key.Matches(keyMsg, a.keys.Sort):
+		a.sort = cycle(todoSorts, a.sort)
+		a.rebuild()
+	case key.Matches(keyMsg, a.keys.ShowDone):
+		a.hideDone = !a.hideDone
+		a.rebuild()
+		hide := a.hideDone
+		return a, func() tea.Msg {
+			return hideDoneMsg{hide: hide}
+		}
+	case key.Matches(keyMsg, a.keys.Search):
+		a.Searching = true
+		a.search.SetValue(a.query)
+		a.search.CursorEnd()
+		return a, a.search.Focus()
+	case key.Matches(keyMsg, a.keys.Back):
+		if a.query != "" {
+			a.query = ""
+			a.search.SetValue("")
+			a.rebuild()
+			return a, nil
+		}
+		return a, func() tea.Msg {
+			return closeAllTodosMsg{}
+		}
+	}
+	return a, nil
+}
+
+// cycle returns the value after current in values, wrapping around
+func cycle[T comparable](values []T, current T) T {
+	for i, v := range values {
+		if v == current {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+// View renders the todos view
+func (a *AllTodos) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(a.theme.Accent).
+		MarginBottom(1).
+		MarginLeft(2)
+	subtitleStyle := lipgloss.NewStyle().Foreground(a.theme.Muted).MarginBottom(1)
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(a.theme.Warning).MarginLeft(2)
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(a.theme.Selection)
+	projectStyle := lipgloss.NewStyle().Foreground(a.theme.Muted)
+	doneStyle := lipgloss.NewStyle().Foreground(a.theme.Muted).Strikethrough(true)
+	emptyStyle := lipgloss.NewStyle().
+		Foreground(a.theme.Muted).
+		Italic(true).
+		MarginLeft(4)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(a.theme.Muted).
+		MarginTop(1).
+		MarginLeft(2)
+
+	shown := 0
+	done := 0
+	for _, row := range a.rows {
+		if row.heading == "" {
+			shown++
+			if row.todo.Completed {
+				done++
+			}
+		}
+	}
+	subtitle := fmt.Sprintf(" · %d todos, %d done · grouped by %s · sorted by %s", shown, done, a.group, a.sort)
+	if a.hideDone {
+		subtitle += " · done hidden"
+	}
+	title := lipgloss.JoinHorizontal(lipgloss.Top,
+		titleStyle.Render("✅ All Todos"), subtitleStyle.Render(subtitle))
+
+	var lines []string
+	switch {
+	case !a.loaded:
+		lines = append(lines, emptyStyle.Render("Loading todos..."))
+	case len(a.rows) == 0 && a.query != "":
+		lines = append(lines, emptyStyle.Render("No matching todos"))
+	case len(a.rows) == 0:
+		lines = append(lines, emptyStyle.Render("No todos"))
+	}
+
+	maxWidth := max(a.width-12, 20)
+	end := min(a.offset+a.listHeight(), len(a.rows))
+	for i := a.offset; i < end; i++ {
+		row := a.rows[i]
+		if row.heading != "" {
+			lines = append(lines, headingStyle.Render(row.heading))
+			continue
+		}
+		todo := row.todo
+
+		indicator := priorityIndicator(todo.Priority)
+		indicatorStyle := lipgloss.NewStyle().Foreground(a.theme.priorityColor(todo.Priority))
+		description := highlightMatches(a.theme, todo.Description, row.match, maxWidth)
+		if todo.Completed {
+			indicator = "✔"
+			indicatorStyle = lipgloss.NewStyle().Foreground(a.theme.Success)
+			description = doneStyle.Render(todo.Description)
+		}
+		text := indicatorStyle.Render(indicator) + " " + description
+		if a.group != groupByProject {
+			text += "  " + projectStyle.Render(a.projectName(todo))
+		}
+
+		if i == a.selected {
+			text = selectedStyle.Render("▸ ") + selectedStyle.Render(text)
+		} else {
+			text = "  " + text
+		}
+		lines = append(lines, "  "+text)
+	}
+
+	k := a.keys
+	help := helpStyle.Render(helpLine(
+		helpEntry("navigate", k.Up, k.Down),
+		helpEntry("go to project", k.Enter),
+		helpEntry("group", k.Group),
+		helpEntry("sort", k.Sort),
+		helpEntry("search", k.Search),
+		helpEntry("show/hide done", k.ShowDone),
+		helpEntry("refresh", k.Refresh),
+		helpEntry("board", k.AllTodos, k.Back),
+	))
+	if a.Searching {
+		help = helpStyle.Render(a.search.View() + "  " + projectStyle.Render("enter keep • esc clear"))
+	} else if a.query != "" {
+		help = helpStyle.Render(lipgloss.NewStyle().Foreground(a.theme.Accent).Render("/"+a.query) +
+			"  " + projectStyle.Render(helpEntry("clear", k.Back)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		"",
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+		help,
+	)
+}
+
+// Message types for the todos view
+type jumpToProjectMsg struct {
+	todo api.Todo
+}
+
+type closeAllTodosMsg struct{}
+
+type allTodosLoadedMsg struct {
+	view  *AllTodos // View that asked for the todos
+	todos []api.Todo
+	err   error
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AuthPrompt asks for new credentials after the API rejected the current ones
+type AuthPrompt struct {
+	input  textinput.Model
+	reason string
+	width  int
+	height int
+	theme  Theme
+}
+
+// NewAuthPrompt creates a credentials prompt explaining why it was shown
+func NewAuthPrompt(reason error, theme Theme) *AuthPrompt {
+	ti := textinput.New()
+	ti.Placeholder = "token or password (empty to re-read configured source)"
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '•'
+	ti.CharLimit = 4096
+	ti.Width = 50
+	ti.Focus()
+
+	return &AuthPrompt{
+		input:  ti,
+		reason: reason.Error(),
+		theme:  theme,
+	}
+}
+
+// SetSize sets the prompt dimensions
+func (p *AuthPrompt) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Update handles messages for the auth prompt
+func (p AuthPrompt) Update(msg tea.Msg) (AuthPrompt, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			secret := p.input.Value()
+			p.input.SetValue("")
+			return p, func() tea.Msg {
+				return submitAuthMsg{secret: secret}
+			}
+		case "esc":
+			return p, func() tea.Msg {
+				return cancelAuthMsg{}
+			}
+		}
+	}
+
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+// View renders the auth prompt
+func (p *AuthPrompt) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(p.theme.Warning).
+		MarginBottom(1)
+
+	reasonStyle := lipgloss.NewStyle().
+		Foreground(p.theme.Muted).
+		Width(60)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(p.theme.Muted).
+		MarginTop(1)
+
+	title := titleStyle.Render("🔒 Authentication Required")
+	reason := reasonStyle.Render(fmt.Sprintf("The server rejected the current credentials:\n%s", p.reason))
+	help := helpStyle.Render("enter submit • esc cancel")
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		reason,
+		"",
+		p.input.View(),
+		help,
+	)
+}
+
+// Message types for the auth prompt
+type submitAuthMsg struct {
+	secret string
+}
+
+type cancelAuthMsg struct{}
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// conflictValueWidth bounds each side of the conflict dialog
+const conflictValueWidth = 30
+
+// fieldAccessor reads and copies one field of a project or todo
+type fieldAccessor[T any] struct {
+	name string
+	get  func(T) string
+	copy func(dst *T, src T)
+}
+
+// projectAccessors are the fields of a project an edit can change
+var projectAccessors = []fieldAccessor[api.Project]{
+	{"Name", func(p api.Project) string { return p.Name }, func(d *api.Project, s api.Project) { d.Name = s.Name }},
+	{"Description", func(p api.Project) string { return p.Description }, func(d *api.Project, s api.Project) { d.Description = s.Description }},
+	{"Path", func(p api.Project) string { return p.Path }, func(d *api.Project, s api.Project) { d.Path = s.Path }},
+	{"File", func(p api.Project) string { return p.File }, func(d *api.Project, s api.Project) { d.File = s.File }},
+	{"Language", func(p api.Project) string { return p.Language }, func(d *api.Project, s api.Project) { d.Language = s.Language }},
+	{"Priority", func(p api.Project) string { return strconv.Itoa(p.Priority) }, func(d *api.Project, s api.Project) { d.Priority = s.Priority }},
+	{"Status", func(p api.Project) string { return p.Status }, func(d *api.Project, s api.Project) { d.Status = s.Status }},
+}
+
+// todoAccessors are the fields of a todo an edit can change; projects are
+// shown by name
+func todoAccessors(projectNames map[int]string) []fieldAccessor[api.Todo] {
+	project := func(t api.Todo) string {
+		if t.ProjectID == nil {
+			return "📥 Inbox"
+		}
+		if name, ok := projectNames[*t.ProjectID]; ok {
+			return fmt.Sprintf("%s (#%d)", name, *t.ProjectID)
+		}
+		return fmt.Sprintf("#%d", *t.ProjectID)
+	}
+	return []fieldAccessor[api.Todo]{
+		{"Description", func(t api.Todo) string { return t.Description }, func(d *api.Todo, s api.Todo) { d.Description = s.Description }},
+		{"Priority", func(t api.Todo) string { return strconv.Itoa(t.Priority) }, func(d *api.Todo, s api.Todo) { d.Priority = s.Priority }},
+		{"Project", project, func(d *api.Todo, s api.Todo) { d.ProjectID = s.ProjectID }},
+	}
+}
+
+// mergeField is a field the user's edit and the server's copy disagree on
+type mergeField struct {
+	index   int // into the accessors
+	name    string
+	mine    string
+	theirs  string
+	useMine bool
+}
+
+// diffFields lists the fields where mine and theirs differ. Each starts out
+// taken from the side that changed it: mine if the user edited it, theirs
+// otherwise.
+func diffFields[T any](accessors []fieldAccessor[T], base, mine, theirs T) []mergeField {
+	var fields []mergeField
+	for i, a := range accessors {
+		m, t := a.get(mine), a.get(theirs)
+		if m == t {
+			continue
+		}
+		fields = append(fields, mergeField{index: i, name: a.name, mine: m, theirs: t, useMine: m != a.get(base)})
+	}
+	return fields
+}
+
+// mergeFields returns theirs with the fields picked from mine
+func mergeFields[T any](accessors []fieldAccessor[T], fields []mergeField, mine, theirs T) T {
+	merged := theirs
+	for _, f := range fields {
+		if f.useMine {
+			accessors[f.index].copy(&merged, mine)
+		}
+	}
+	return merged
+}
+
+// ConflictDialog resolves an edit the server refused because the project or
+// todo changed since it was read. The fields the two versions disagree on
+// are shown side by side, and each can be taken from either.
+type ConflictDialog struct {
+	title    string
+	fields   []mergeField
+	selected int
+	resolve  func(fields []mergeField) tea.Msg
+	theme    Theme
+	keys     keyMap
+}
+
+// NewProjectConflict creates a dialog for an edit of base into mine that
+// conflicts with theirs, the server's copy. It returns nil if the two agree
+// after all.
+func NewProjectConflict(base, mine, theirs api.Project, theme Theme, keys keyMap) *ConflictDialog {
+	fields := diffFields(projectAccessors, base, mine, theirs)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ConflictDialog{
+		title:  fmt.Sprintf("Project %q was changed by someone else", theirs.Name),
+		fields: fields,
+		resolve: func(fields []mergeField) tea.Msg {
+			merged := mergeFields(projectAccessors, fields, mine, theirs)
+			return resolveProjectConflictMsg{theirs: theirs, merged: merged}
+		},
+		theme: theme,
+		keys:  keys,
+	}
+}
+
+// NewTodoConflict creates a dialog for an edit of base into mine, made in
+// list, that conflicts with theirs. It returns nil if the two agree after all.
+func NewTodoConflict(list *TodoList, base, mine, theirs api.Todo, projectNames map[int]string, theme Theme, keys keyMap) *ConflictDialog {
+	accessors := todoAccessors(projectNames)
+	fields := diffFields(accessors, base, mine, theirs)
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ConflictDialog{
+		title:  fmt.Sprintf("Todo %q was changed by someone else", theirs.Description),
+		fields: fields,
+		resolve: func(fields []mergeField) tea.Msg {
+			merged := mergeFields(accessors, fields, mine, theirs)
+			return resolveTodoConflictMsg{list: list, theirs: theirs, merged: merged}
+		},
+		theme: theme,
+		keys:  keys,
+	}
+}
+
+// Update handles messages for the conflict dialog
+func (c ConflictDialog) Update(msg tea.Msg) (ConflictDialog, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return c, nil
+	}
+
+	// Resolve with a copy, so the choices can't change underneath the message
+	done := func() tea.Cmd {
+		fields := append([]mergeField(nil), c.fields...)
+		return func() tea.Msg {
+			return c.resolve(fields)
+		}
+	}
+	pickAll := func(mine bool) {
+		for i := range c.fields {
+			c.fields[i].useMine = mine
+		}
+	}
+
+	switch {
+	case key.Matches(keyMsg, c.keys.KeepMine):
+		pickAll(true)
+		return c, done()
+	case key.Matches(keyMsg, c.keys.KeepTheirs, c.keys.Back):
+		pickAll(false)
+		return c, done()
+	case key.Matches(keyMsg, c.keys.Merge):
+		return c, done()
+	case key.Matches(keyMsg, c.keys.Up):
+		if c.selected > 0 {
+			c.selected--
+		}
+	case key.Matches(keyMsg, c.keys.Down):
+		if c.selected < len(c.fields)-1 {
+			c.selected++
+		}
+	case key.Matches(keyMsg, c.keys.Left):
+		c.fields[c.selected].useMine = true
+	case key.Matches(keyMsg, c.keys.Right):
+		c.fields[c.selected].useMine = false
+	case key.Matches(keyMsg, c.keys.ToggleSide):
+		c.fields[c.selected].useMine = !c.fields[c.selected].useMine
+	}
+	return c, nil
+}
+
+// View renders the conflict dialog
+func (c *ConflictDialog) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(c.theme.Warning).
+		MarginBottom(1)
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(c.theme.HeaderText)
+	nameStyle := lipgloss.NewStyle().Width(13)
+	valueStyle := lipgloss.NewStyle().Width(conflictValueWidth + 3)
+	chosenStyle := lipgloss.NewStyle().Foreground(c.theme.Success).Bold(true)
+	otherStyle := lipgloss.NewStyle().Foreground(c.theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Foreground(c.theme.Selection).Bold(true)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(c.theme.Muted).
+		MarginTop(1)
+
+	side := func(value string, chosen bool) string {
+		// Newlines would break the table
+		value = highlightMatches(c.theme, strings.ReplaceAll(value, "\n", " ⏎ "), nil, conflictValueWidth)
+		if value == "" {
+			value = "(empty)"
+		}
+		if chosen {
+			return valueStyle.Render(chosenStyle.Render("● " + value))
+		}
+		return valueStyle.Render(otherStyle.Render("○ " + value))
+	}
+
+	lines := []string{
+		titleStyle.Render("⚠ " + c.title),
+		"  " + nameStyle.Render("") + valueStyle.Render(headerStyle.Render("Mine")) + valueStyle.Render(headerStyle.Render("Theirs")),
+	}
+	for i, f := range c.fields {
+		marker, name := "  ", nameStyle.Render(f.name)
+		if i == c.selected {
+			marker, name = selectedStyle.Render("▸ "), nameStyle.Inherit(selectedStyle).Render(f.name)
+		}
+		lines = append(lines, marker+name+side(f.mine, f.useMine)+side(f.theirs, !f.useMine))
+	}
+
+	k := c.keys
+	lines = append(lines, helpStyle.Render(helpLine(
+		helpEntry("field", k.Up, k.Down),
+		helpEntry("mine/theirs", k.Left, k.Right),
+		k.ToggleSide.Help().Key+" toggle",
+		k.Merge.Help().Key+" merge",
+		k.KeepMine.Help().Key+" keep mine",
+		fmt.Sprintf("%s/%s keep theirs", k.KeepTheirs.Help().Key, k.Back.Help().Key),
+	)))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// Message types for conflict resolution
+type resolveProjectConflictMsg struct {
+	theirs api.Project
+	merged api.Project
+}
+
+type resolveTodoConflictMsg struct {
+	list   *TodoList
+	theirs api.Todo
+	merged api.Todo
+}
+
+// projectConflictMsg carries the server's copy of a project an edit
+// conflicted with
+type projectConflictMsg struct {
+	base   api.Project
+	mine   api.Project
+	theirs *api.Project
+	err    error
+}
+
+// todoConflictMsg carries the server's copy of a todo an edit conflicted with
+type todoConflictMsg struct {
+	list   *TodoList
+	base   api.Todo
+	mine   api.Todo
+	theirs *api.Todo
+	err    error
+}
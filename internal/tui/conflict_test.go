@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+func TestConflictDialogKeys(t *testing.T) {
+	base := api.Project{ID: 1, Name: "pj", Language: "go", Priority: 1}
+	mine := api.Project{ID: 1, Name: "pj-tui", Language: "go", Priority: 1}
+	theirs := api.Project{ID: 1, Name: "pj", Language: "rust", Priority: 3}
+
+	tests := []struct {
+		name     string
+		bindings map[string][]string
+		keys     []string
+		want     api.Project
+	}{
+		{"merge takes each field from the side that changed it", nil, []string{"enter"}, api.Project{ID: 1, Name: "pj-tui", Language: "rust", Priority: 3}},
+		{"keep mine", nil, []string{"m"}, mine},
+		{"keep theirs", nil, []string{"t"}, theirs},
+		{"back keeps theirs", nil, []string{"esc"}, theirs},
+		{"toggle the first field", nil, []string{" ", "enter"}, theirs},
+		{"pick mine for the second field", nil, []string{"k", "j", "enter"}, api.Project{ID: 1, Name: "pj-tui", Language: "go", Priority: 3}},
+		{"rebound keep mine", map[string][]string{"keep_mine": {"y"}}, []string{"m", "y"}, mine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := newKeyMap(config.KeysConfig{Bindings: tt.bindings})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dialog := NewProjectConflict(base, mine, theirs, darkTheme, keys)
+			var cmd tea.Cmd
+			for _, k := range tt.keys {
+				msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+				switch k {
+				case "enter":
+					msg = tea.KeyMsg{Type: tea.KeyEnter}
+				case "esc":
+					msg = tea.KeyMsg{Type: tea.KeyEsc}
+				case " ":
+					msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(k)}
+				}
+				*dialog, cmd = dialog.Update(msg)
+			}
+			if cmd == nil {
+				t.Fatal("dialog didn't resolve")
+			}
+			got := cmd().(resolveProjectConflictMsg).merged
+			if got != tt.want {
+				t.Errorf("merged = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorCommand returns the command opening path in the user's editor:
+// $VISUAL, then $EDITOR, then vi. The variables may carry arguments, as in
+// "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// editInEditor suspends the TUI to edit text in the user's editor, in a
+// temporary file named after pattern, and reports the saved text through done
+func editInEditor(text, pattern string, done func(text string, err error) tea.Msg) tea.Cmd {
+	fail := func(err error) tea.Cmd {
+		return func() tea.Msg {
+			return done("", err)
+		}
+	}
+
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return fail(err)
+	}
+	path := f.Name()
+	_, err = f.WriteString(text)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fail(err)
+	}
+
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return done("", err)
+		}
+		data, err := os.ReadFile(path)
+		return done(string(data), err)
+	})
+}
@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// fakeBackend is an in-memory api.Backend. Setting fail makes every change
+// fail with that error while reads keep working. Full updates are checked
+// against the version they were sent, like the API's If-Match.
+type fakeBackend struct {
+	mu       sync.Mutex
+	projects []api.Project
+	todos    []api.Todo
+	fail     error
+	getTodos int   // Calls of GetTodos
+	versions []int // Versions sent with full updates
+}
+
+var _ api.Backend = (*fakeBackend)(nil)
+
+func (f *fakeBackend) project(id int) (*api.Project, error) {
+	for i := range f.projects {
+		if f.projects[i].ID == id {
+			return &f.projects[i], nil
+		}
+	}
+	return nil, &api.APIError{StatusCode: 404, Message: "project not found"}
+}
+
+func (f *fakeBackend) todo(id int) (*api.Todo, error) {
+	for i := range f.todos {
+		if f.todos[i].ID == id && !f.todos[i].Deleted {
+			return &f.todos[i], nil
+		}
+	}
+	return nil, &api.APIError{StatusCode: 404, Message: "todo not found"}
+}
+
+// changeProject applies change to a project unless changes are failing or
+// version is stale; 0 skips the version check
+func (f *fakeBackend) changeProject(id, version int, change func(*api.Project)) (*api.Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	p, err := f.project(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != p.Version {
+		return nil, &api.APIError{StatusCode: 412, Message: "version mismatch"}
+	}
+	change(p)
+	p.Version++
+	saved := *p
+	return &saved, nil
+}
+
+// changeTodo applies change to a todo unless changes are failing or version
+// is stale; 0 skips the version check
+func (f *fakeBackend) changeTodo(id, version int, change func(*api.Todo)) (*api.Todo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	t, err := f.todo(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != t.Version {
+		return nil, &api.APIError{StatusCode: 412, Message: "version mismatch"}
+	}
+	change(t)
+	t.Version++
+	saved := *t
+	return &saved, nil
+}
+
+func (f *fakeBackend) GetProjects(ctx context.Context) ([]api.Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.projects), nil
+}
+
+func (f *fakeBackend) GetProject(ctx context.Context, id int) (*api.Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.project(id)
+	if err != nil {
+		return nil, err
+	}
+	saved := *p
+	return &saved, nil
+}
+
+func (f *fakeBackend) CreateProject(ctx context.Context, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	p := api.Project{ID: len(f.projects) + 1, Version: 1, Name: name, Description: description,
+		Path: path, File: file, Language: language, Priority: priority, Status: status}
+	f.projects = append(f.projects, p)
+	return &p, nil
+}
+
+func (f *fakeBackend) UpdateProject(ctx context.Context, id, version int, name, description, path, file, language string, priority int, status string) (*api.Project, error) {
+	f.mu.Lock()
+	f.versions = append(f.versions, version)
+	f.mu.Unlock()
+	return f.changeProject(id, version, func(p *api.Project) {
+		p.Name, p.Description, p.Path, p.File, p.Language = name, description, path, file, language
+		p.Priority, p.Status = priority, status
+	})
+}
+
+func (f *fakeBackend) UpdateProjectStatus(ctx context.Context, id int, status string) (*api.Project, error) {
+	return f.changeProject(id, 0, func(p *api.Project) { p.Status = status })
+}
+
+func (f *fakeBackend) UpdateProjectPriority(ctx context.Context, id int, priority int) (*api.Project, error) {
+	return f.changeProject(id, 0, func(p *api.Project) { p.Priority = priority })
+}
+
+func (f *fakeBackend) DeleteProject(ctx context.Context, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return f.fail
+	}
+	if _, err := f.project(id); err != nil {
+		return err
+	}
+	f.projects = slices.DeleteFunc(f.projects, func(p api.Project) bool { return p.ID == id })
+	return nil
+}
+
+func (f *fakeBackend) GetTodos(ctx context.Context) ([]api.Todo, error) {
+	f.mu.Lock()
+	f.getTodos++
+	f.mu.Unlock()
+	return f.GetTodosByProject(ctx, 0)
+}
+
+// GetTodosByProject returns the live todos of a project, or all of them for
+// project 0
+func (f *fakeBackend) GetTodosByProject(ctx context.Context, projectID int) ([]api.Todo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var todos []api.Todo
+	for _, t := range f.todos {
+		if t.Deleted || projectID != 0 && (t.ProjectID == nil || *t.ProjectID != projectID) {
+			continue
+		}
+		todos = append(todos, t)
+	}
+	return todos, nil
+}
+
+func (f *fakeBackend) GetTodo(ctx context.Context, id int) (*api.Todo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.todo(id)
+	if err != nil {
+		return nil, err
+	}
+	saved := *t
+	return &saved, nil
+}
+
+func (f *fakeBackend) CreateTodo(ctx context.Context, description string, priority int, projectID *int) (*api.Todo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	t := api.Todo{ID: len(f.todos) + 1, Version: 1, Description: description, Priority: priority, ProjectID: projectID}
+	f.todos = append(f.todos, t)
+	return &t, nil
+}
+
+func (f *fakeBackend) UpdateTodo(ctx context.Context, id, version int, description string, priority int, projectID *int) (*api.Todo, error) {
+	f.mu.Lock()
+	f.versions = append(f.versions, version)
+	f.mu.Unlock()
+	return f.changeTodo(id, version, func(t *api.Todo) {
+		t.Description, t.Priority, t.ProjectID = description, priority, projectID
+	})
+}
+
+func (f *fakeBackend) UpdateTodoCompleted(ctx context.Context, id int, completed bool) (*api.Todo, error) {
+	return f.changeTodo(id, 0, func(t *api.Todo) { t.Completed = completed })
+}
+
+func (f *fakeBackend) UpdateTodoNotes(ctx context.Context, id int, notes string) (*api.Todo, error) {
+	return f.changeTodo(id, 0, func(t *api.Todo) { t.Notes = notes })
+}
+
+func (f *fakeBackend) DeleteTodo(ctx context.Context, id int) error {
+	_, err := f.changeTodo(id, 0, func(t *api.Todo) { t.Deleted = true })
+	return err
+}
+
+// reauthFakeBackend is a fakeBackend that accepts new credentials
+type reauthFakeBackend struct {
+	*fakeBackend
+	secret string
+}
+
+func (f *reauthFakeBackend) SetSecret(secret string) {
+	f.secret = secret
+}
@@ -0,0 +1,208 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+	"github.com/sean-obeirne/projectarium-tui/internal/filter"
+)
+
+// FilterPicker switches the board between no filter, the named filters from
+// the config and a filter expression typed in
+type FilterPicker struct {
+	entries  []filterEntry
+	selected int
+	custom   bool // typing an expression
+	input    textinput.Model
+	err      string
+	current  *filter.Filter
+	config   *config.Config
+	theme    Theme
+	keys     keyMap
+}
+
+// filterEntry is a line of the picker: no filter, a named filter, or the
+// custom expression entry
+type filterEntry struct {
+	name string
+	expr string
+}
+
+// customEntry is the name of the entry that opens the expression input
+const customEntry = "Custom expression…"
+
+// NewFilterPicker creates a picker with the current filter selected
+func NewFilterPicker(cfg *config.Config, current *filter.Filter, theme Theme, keys keyMap) *FilterPicker {
+	entries := []filterEntry{{name: "All projects"}}
+	names := make([]string, 0, len(cfg.Filters))
+	for name := range cfg.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entries = append(entries, filterEntry{name: name, expr: cfg.Filters[name]})
+	}
+	entries = append(entries, filterEntry{name: customEntry})
+
+	ti := textinput.New()
+	ti.Placeholder = "lang:go prio:>=2 path:~/work -status:finished"
+	ti.CharLimit = 200
+	ti.Width = 50
+
+	p := &FilterPicker{
+		entries: entries,
+		input:   ti,
+		current: current,
+		config:  cfg,
+		theme:   theme,
+		keys:    keys,
+	}
+	switch {
+	case current == nil:
+	case current.Name != "":
+		for i, e := range entries {
+			if e.name == current.Name {
+				p.selected = i
+			}
+		}
+	default:
+		p.selected = len(entries) - 1
+	}
+	return p
+}
+
+// Update handles messages for the filter picker
+func (p FilterPicker) Update(msg tea.Msg) (FilterPicker, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	if p.custom {
+		switch keyMsg.String() {
+		case "enter":
+			f, err := filter.Parse(p.input.Value(), p.config.Workflow)
+			if err != nil {
+				p.err = err.Error()
+				return p, nil
+			}
+			return p, applyFilterCmd(f)
+		case "esc":
+			p.custom = false
+			p.err = ""
+			p.input.Blur()
+			return p, nil
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		p.err = ""
+		return p, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, p.keys.Up):
+		if p.selected > 0 {
+			p.selected--
+		}
+	case key.Matches(keyMsg, p.keys.Down):
+		if p.selected < len(p.entries)-1 {
+			p.selected++
+		}
+	case key.Matches(keyMsg, p.keys.Enter):
+		entry := p.entries[p.selected]
+		switch {
+		case p.selected == 0:
+			return p, applyFilterCmd(nil)
+		case p.selected == len(p.entries)-1:
+			p.custom = true
+			p.input.SetValue(p.current.String())
+			p.input.CursorEnd()
+			return p, p.input.Focus()
+		}
+		f, err := filter.Resolve(entry.name, p.config.Filters, p.config.Workflow)
+		if err != nil {
+			p.err = err.Error()
+			return p, nil
+		}
+		return p, applyFilterCmd(f)
+	case key.Matches(keyMsg, p.keys.Back, p.keys.Quit, p.keys.Filters):
+		return p, func() tea.Msg {
+			return cancelFilterPickerMsg{}
+		}
+	}
+	return p, nil
+}
+
+// View renders the filter picker
+func (p *FilterPicker) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(p.theme.Accent).
+		MarginBottom(1)
+
+	exprStyle := lipgloss.NewStyle().Foreground(p.theme.Muted)
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(p.theme.Selection)
+	errorStyle := lipgloss.NewStyle().Foreground(p.theme.Error).Width(60)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(p.theme.Muted).
+		MarginTop(1)
+
+	lines := []string{titleStyle.Render("🔎 Filter Projects")}
+	for i, e := range p.entries {
+		active := (i == 0 && p.current == nil) ||
+			(p.current != nil && p.current.Name != "" && e.name == p.current.Name) ||
+			(p.current != nil && p.current.Name == "" && i == len(p.entries)-1)
+		marker := "  "
+		if active {
+			marker = "● "
+		}
+		line := marker + e.name
+		if i == p.selected {
+			line = selectedStyle.Render("▸ " + line)
+		} else {
+			line = "  " + line
+		}
+		if e.expr != "" {
+			line += "  " + exprStyle.Render(e.expr)
+		}
+		lines = append(lines, line)
+	}
+
+	k := p.keys
+	help := helpLine(
+		helpEntry("move", k.Up, k.Down),
+		helpEntry("apply", k.Enter),
+		helpEntry("close", k.Back),
+	)
+	if p.custom {
+		lines = append(lines, "", p.input.View())
+		help = "enter apply • esc back"
+	}
+	if p.err != "" {
+		lines = append(lines, "", errorStyle.Render(p.err))
+	}
+	lines = append(lines, helpStyle.Render(help))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// applyFilterCmd asks the model to show only projects matching f; nil
+// shows every project
+func applyFilterCmd(f *filter.Filter) tea.Cmd {
+	return func() tea.Msg {
+		return applyFilterMsg{filter: f}
+	}
+}
+
+// Message types for the filter picker
+type applyFilterMsg struct {
+	filter *filter.Filter
+}
+
+type cancelFilterPickerMsg struct{}
+
+type openFilterPickerMsg struct{}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// fuzzyMatch reports whether the runes of pattern appear in order in text,
+// ignoring case. It returns a score, higher for matches that are contiguous
+// or start words, and the rune positions in text that matched.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(p) == 0 || len(lower) != len(t) {
+		return 0, nil, false
+	}
+
+	// Find where the leftmost match ends, then walk back from there to the
+	// latest start, so "ab" in "a_xab" highlights the tight "ab"
+	pi, end := 0, -1
+	for i := 0; i < len(lower) && pi < len(p); i++ {
+		if lower[i] == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = i
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	start := end
+	for pi = len(p) - 1; start >= 0; start-- {
+		if lower[start] == p[pi] {
+			pi--
+			if pi < 0 {
+				break
+			}
+		}
+	}
+
+	positions := make([]int, 0, len(p))
+	score := 0
+	pi = 0
+	for i := start; i <= end && pi < len(p); i++ {
+		if lower[i] != p[pi] {
+			continue
+		}
+		score++
+		if n := len(positions); n > 0 && positions[n-1] == i-1 {
+			score += 4 // contiguous with the previous match
+		}
+		if i == 0 || isWordStart(t[i-1], t[i]) {
+			score += 3
+		}
+		positions = append(positions, i)
+		pi++
+	}
+	score -= (end - start + 1 - len(p)) / 2 // gaps
+	return score, positions, true
+}
+
+// isWordStart reports whether cur begins a word following prev
+func isWordStart(prev, cur rune) bool {
+	if unicode.IsLower(prev) && unicode.IsUpper(cur) {
+		return true
+	}
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+// projectMatch is where a search query matched a project
+type projectMatch struct {
+	score       int
+	name        []int
+	description []int
+	language    []int
+}
+
+// matchProject matches query against a project's name, description and
+// language, preferring matches in the name
+func matchProject(query string, project api.Project) (projectMatch, bool) {
+	var m projectMatch
+	found := false
+	if score, positions, ok := fuzzyMatch(query, project.Name); ok {
+		m.score, m.name, found = score*2, positions, true
+	}
+	if score, positions, ok := fuzzyMatch(query, project.Description); ok {
+		m.score, m.description, found = max(m.score, score), positions, true
+	}
+	if score, positions, ok := fuzzyMatch(query, project.Language); ok {
+		m.score, m.language, found = max(m.score, score), positions, true
+	}
+	return m, found
+}
+
+// highlightMatches truncates text to maxLen runes with an ellipsis and
+// renders the runes at positions in theme's match style. Positions are
+// rune indices, so matches cut off by the ellipsis are dropped.
+func highlightMatches(theme Theme, text string, positions []int, maxLen int) string {
+	runes := []rune(text)
+	suffix := ""
+	if len(runes) > maxLen {
+		runes = runes[:max(maxLen-3, 0)]
+		suffix = "..."
+	}
+	if len(positions) == 0 {
+		return string(runes) + suffix
+	}
+
+	style := lipgloss.NewStyle().Foreground(theme.Accent).Bold(true).Underline(true)
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		if p < len(runes) {
+			matched[p] = true
+		}
+	}
+	var sb strings.Builder
+	for i, r := range runes {
+		if matched[i] {
+			sb.WriteString(style.Render(string(r)))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String() + suffix
+}
@@ -0,0 +1,32 @@
+package tui
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHighlightMatchesTruncatesRunes(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		positions []int
+		maxLen    int
+		want      string
+	}{
+		{"fits", "café", []int{3}, 10, "café"},
+		{"ascii", "projectarium", nil, 8, "proje..."},
+		{"multibyte", "naïve café au lait", []int{2, 7, 16}, 10, "naïve c..."},
+		{"match past the cut", "日本語のプロジェクト", []int{0, 8}, 6, "日本語..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightMatches(darkTheme, tt.text, tt.positions, tt.maxLen)
+			if !utf8.ValidString(got) {
+				t.Fatalf("highlightMatches = %q, cut inside a rune", got)
+			}
+			if got != tt.want {
+				t.Errorf("highlightMatches = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
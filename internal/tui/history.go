@@ -0,0 +1,345 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// maxUndo bounds the number of changes that can be undone
+const maxUndo = 100
+
+// changeKind identifies the type of a recorded mutation
+type changeKind int
+
+const (
+	changeProjectStatus changeKind = iota
+	changeProjectPriority
+	changeProjectEdit
+	changeProjectCreate
+	changeTodoCreate
+	changeTodoUpdate
+	changeTodoDelete
+	changeTodoComplete
+	changeTodoNotes
+)
+
+// change is a mutation recorded in the history, holding the affected project
+// or todo as it was before and after so it can be reversed and replayed
+type change struct {
+	kind          changeKind
+	beforeProject api.Project
+	afterProject  api.Project
+	beforeTodo    api.Todo
+	afterTodo     api.Todo
+}
+
+// isTodo reports whether the change affects a todo rather than a project
+func (c change) isTodo() bool {
+	return c.kind >= changeTodoCreate
+}
+
+// describe returns a short description of the change for notifications
+func (c change) describe() string {
+	switch c.kind {
+	case changeProjectStatus:
+		return fmt.Sprintf("status change of %q", c.afterProject.Name)
+	case changeProjectPriority:
+		return fmt.Sprintf("priority change of %q", c.afterProject.Name)
+	case changeProjectEdit:
+		return fmt.Sprintf("edit of %q", c.afterProject.Name)
+	case changeProjectCreate:
+		return fmt.Sprintf("creation of %q", c.afterProject.Name)
+	case changeTodoCreate:
+		return fmt.Sprintf("new todo %q", c.afterTodo.Description)
+	case changeTodoUpdate:
+		if !sameProject(c.beforeTodo.ProjectID, c.afterTodo.ProjectID) {
+			return fmt.Sprintf("move of todo %q", c.afterTodo.Description)
+		}
+		return fmt.Sprintf("edit of todo %q", c.afterTodo.Description)
+	case changeTodoDelete:
+		return fmt.Sprintf("deletion of todo %q", c.beforeTodo.Description)
+	case changeTodoComplete:
+		if c.afterTodo.Completed {
+			return fmt.Sprintf("completion of todo %q", c.afterTodo.Description)
+		}
+		return fmt.Sprintf("reopening of todo %q", c.afterTodo.Description)
+	case changeTodoNotes:
+		return fmt.Sprintf("notes of todo %q", c.afterTodo.Description)
+	}
+	return "change"
+}
+
+// remap replaces references to a re-created project or todo
+func (c *change) remap(todo bool, oldID, newID int) {
+	if todo != c.isTodo() {
+		return
+	}
+	if todo {
+		if c.beforeTodo.ID == oldID {
+			c.beforeTodo.ID = newID
+		}
+		if c.afterTodo.ID == oldID {
+			c.afterTodo.ID = newID
+		}
+		return
+	}
+	if c.beforeProject.ID == oldID {
+		c.beforeProject.ID = newID
+	}
+	if c.afterProject.ID == oldID {
+		c.afterProject.ID = newID
+	}
+}
+
+// setVersion records the version a project or todo is now at
+func (c *change) setVersion(todo bool, id, version int) {
+	if todo != c.isTodo() {
+		return
+	}
+	if todo && c.afterTodo.ID == id {
+		c.afterTodo.Version = version
+	} else if !todo && c.afterProject.ID == id {
+		c.afterProject.Version = version
+	}
+}
+
+// History is the undo/redo stack of mutations made from the TUI
+type History struct {
+	undo []change
+	redo []change
+}
+
+// NewHistory creates an empty history
+func NewHistory() *History {
+	return &History{}
+}
+
+// Record adds a new change, discarding anything that could be redone
+func (h *History) Record(c change) {
+	if c.isTodo() {
+		h.setVersion(true, c.afterTodo.ID, c.afterTodo.Version)
+	} else {
+		h.setVersion(false, c.afterProject.ID, c.afterProject.Version)
+	}
+	h.undo = append(h.undo, c)
+	if len(h.undo) > maxUndo {
+		h.undo = h.undo[len(h.undo)-maxUndo:]
+	}
+	h.redo = nil
+}
+
+// popUndo removes and returns the most recent change
+func (h *History) popUndo() (change, bool) {
+	if len(h.undo) == 0 {
+		return change{}, false
+	}
+	c := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	return c, true
+}
+
+// popRedo removes and returns the most recently undone change
+func (h *History) popRedo() (change, bool) {
+	if len(h.redo) == 0 {
+		return change{}, false
+	}
+	c := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	return c, true
+}
+
+// remap updates every recorded reference to a project or todo that was
+// re-created under a new ID
+func (h *History) remap(todo bool, oldID, newID int) {
+	for i := range h.undo {
+		h.undo[i].remap(todo, oldID, newID)
+	}
+	for i := range h.redo {
+		h.redo[i].remap(todo, oldID, newID)
+	}
+}
+
+// setVersion updates every recorded reference to a project or todo that a
+// change moved to a new version, so undoing or redoing earlier changes to it
+// isn't refused as a conflict
+func (h *History) setVersion(todo bool, id, version int) {
+	if id == 0 || version == 0 {
+		return
+	}
+	for i := range h.undo {
+		h.undo[i].setVersion(todo, id, version)
+	}
+	for i := range h.redo {
+		h.redo[i].setVersion(todo, id, version)
+	}
+}
+
+// undo reverts the most recent change by issuing the inverse API call
+func (m Model) undo() (tea.Model, tea.Cmd) {
+	c, ok := m.history.popUndo()
+	if !ok {
+		return m, m.notifier.Push(SeverityInfo, "Nothing to undo")
+	}
+	return m, m.applyChange(c, true)
+}
+
+// redo replays the most recently undone change
+func (m Model) redo() (tea.Model, tea.Cmd) {
+	c, ok := m.history.popRedo()
+	if !ok {
+		return m, m.notifier.Push(SeverityInfo, "Nothing to redo")
+	}
+	return m, m.applyChange(c, false)
+}
+
+// applyChange sends the API call that moves a change to its before state
+// (undo) or its after state (redo)
+func (m Model) applyChange(c change, undo bool) tea.Cmd {
+	// Edits are conditional on the version on screen, or the one the
+	// history last saw if it isn't showing, so undoing one can't overwrite
+	// what someone else changed since
+	projectVersion, todoVersion := c.afterProject.Version, c.afterTodo.Version
+	if m.kanbanBoard != nil {
+		if project, ok := m.kanbanBoard.FindProject(c.afterProject.ID); ok {
+			projectVersion = project.Version
+		}
+	}
+	if m.todoList != nil {
+		if todo, ok := m.todoList.FindTodo(c.afterTodo.ID); ok {
+			todoVersion = todo.Version
+		}
+	}
+
+	return func() tea.Msg {
+		msg := historyAppliedMsg{change: c, undo: undo}
+
+		project, todo := c.afterProject, c.afterTodo
+		if undo {
+			project, todo = c.beforeProject, c.beforeTodo
+		}
+
+		switch {
+		case c.kind == changeProjectStatus:
+			msg.project, msg.err = m.backend.UpdateProjectStatus(m.ctx, project.ID, project.Status)
+		case c.kind == changeProjectPriority:
+			msg.project, msg.err = m.backend.UpdateProjectPriority(m.ctx, project.ID, project.Priority)
+		case c.kind == changeProjectEdit:
+			msg.project, msg.err = m.backend.UpdateProject(m.ctx, project.ID, projectVersion, project.Name, project.Description,
+				project.Path, project.File, project.Language, project.Priority, project.Status)
+		case c.kind == changeProjectCreate && undo:
+			msg.err = m.backend.DeleteProject(m.ctx, c.afterProject.ID)
+		case c.kind == changeProjectCreate:
+			msg.project, msg.err = m.backend.CreateProject(m.ctx, project.Name, project.Description,
+				project.Path, project.File, project.Language, project.Priority, project.Status)
+		case c.kind == changeTodoUpdate:
+			msg.todo, msg.err = m.backend.UpdateTodo(m.ctx, todo.ID, todoVersion, todo.Description, todo.Priority, todo.ProjectID)
+		case c.kind == changeTodoComplete:
+			msg.todo, msg.err = m.backend.UpdateTodoCompleted(m.ctx, todo.ID, todo.Completed)
+		case c.kind == changeTodoNotes:
+			msg.todo, msg.err = m.backend.UpdateTodoNotes(m.ctx, todo.ID, todo.Notes)
+		case (c.kind == changeTodoCreate && undo) || (c.kind == changeTodoDelete && !undo):
+			id := c.afterTodo.ID
+			if c.kind == changeTodoDelete {
+				id = c.beforeTodo.ID
+			}
+			msg.err = m.backend.DeleteTodo(m.ctx, id)
+		default:
+			// Re-create a deleted todo, or a created one that was undone
+			msg.todo, msg.err = m.backend.CreateTodo(m.ctx, todo.Description, todo.Priority, todo.ProjectID)
+			// The todo exists again even if its notes or completion can't be
+			// restored
+			if msg.err == nil && todo.Notes != "" {
+				if noted, err := m.backend.UpdateTodoNotes(m.ctx, msg.todo.ID, todo.Notes); err == nil {
+					msg.todo = noted
+				}
+			}
+			if msg.err == nil && todo.Completed {
+				if done, err := m.backend.UpdateTodoCompleted(m.ctx, msg.todo.ID, true); err == nil {
+					msg.todo = done
+				}
+			}
+		}
+
+		return msg
+	}
+}
+
+// settleHistory moves an applied change to the opposite stack, or back where
+// it came from if the API call failed, and refreshes the affected view
+func (m Model) settleHistory(msg historyAppliedMsg) (tea.Model, tea.Cmd) {
+	c := msg.change
+	verb, action := "Redid", "redo"
+	if msg.undo {
+		verb, action = "Undid", "undo"
+	}
+
+	if msg.err != nil {
+		if msg.undo {
+			m.history.undo = append(m.history.undo, c)
+		} else {
+			m.history.redo = append(m.history.redo, c)
+		}
+		return m, m.notifier.Error(fmt.Sprintf("Failed to %s %s", action, c.describe()), msg.err)
+	}
+
+	// Creating something again gives it a new ID; point the history at it
+	if msg.todo != nil {
+		oldID := c.afterTodo.ID
+		if msg.undo {
+			oldID = c.beforeTodo.ID
+		}
+		if oldID != msg.todo.ID {
+			m.history.remap(true, oldID, msg.todo.ID)
+			c.remap(true, oldID, msg.todo.ID)
+		}
+	}
+	if msg.project != nil && c.kind == changeProjectCreate {
+		m.history.remap(false, c.afterProject.ID, msg.project.ID)
+		c.remap(false, c.afterProject.ID, msg.project.ID)
+	}
+	if msg.todo != nil {
+		m.history.setVersion(true, msg.todo.ID, msg.todo.Version)
+		c.setVersion(true, msg.todo.ID, msg.todo.Version)
+	}
+	if msg.project != nil {
+		m.history.setVersion(false, msg.project.ID, msg.project.Version)
+		c.setVersion(false, msg.project.ID, msg.project.Version)
+	}
+
+	if msg.undo {
+		m.history.redo = append(m.history.redo, c)
+	} else {
+		m.history.undo = append(m.history.undo, c)
+	}
+
+	cmds := []tea.Cmd{m.notifier.Push(SeveritySuccess, "%s %s", verb, c.describe())}
+	switch {
+	case c.isTodo():
+		if m.showTodoList {
+			cmds = append(cmds, m.loadTodos())
+		}
+		// The todo may belong to a project other than the open list's, so
+		// recount every card, quietly if the board is showing
+		m.progressStale = true
+		if m.viewMode == KanbanBoardView {
+			cmds = append(cmds, m.loadProjects())
+		}
+	case c.kind == changeProjectCreate:
+		// The project appeared or disappeared; rebuild the board quietly
+		cmds = append(cmds, m.loadProjects())
+	case msg.project != nil && m.kanbanBoard != nil:
+		m.kanbanBoard.SyncProject(*msg.project)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// historyAppliedMsg reports the result of an undo or redo
+type historyAppliedMsg struct {
+	change  change
+	undo    bool
+	project *api.Project
+	todo    *api.Todo
+	err     error
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+func TestUndoFromBoardIsConditional(t *testing.T) {
+	projectID := 1
+	before := api.Todo{ID: 1, Version: 1, Description: "first", ProjectID: &projectID}
+	after := api.Todo{ID: 1, Version: 2, Description: "renamed", ProjectID: &projectID}
+	backend := &fakeBackend{
+		projects: []api.Project{{ID: 1, Version: 1, Name: "pj", Status: config.DefaultWorkflow().Statuses()[0]}},
+		todos:    []api.Todo{after},
+	}
+	m := newTestModel(t, backend)
+	m.history.Record(change{kind: changeTodoUpdate, beforeTodo: before, afterTodo: after})
+
+	// The todo list is closed, so the version comes from the history
+	_, cmd := m.undo()
+	msg := cmd().(historyAppliedMsg)
+	if msg.err != nil {
+		t.Fatalf("undo from the board: %v", msg.err)
+	}
+	if !slices.Equal(backend.versions, []int{2}) {
+		t.Errorf("undo sent versions %v, want 2", backend.versions)
+	}
+	m, _ = update(m, msg)
+
+	// Redoing goes from the version the undo left
+	_, cmd = m.redo()
+	msg = cmd().(historyAppliedMsg)
+	if msg.err != nil {
+		t.Fatalf("redo from the board: %v", msg.err)
+	}
+	if !slices.Equal(backend.versions, []int{2, 3}) {
+		t.Errorf("redo sent versions %v, want 2 then 3", backend.versions)
+	}
+	m, _ = update(m, msg)
+
+	// Someone else renames the todo; undoing mustn't overwrite that
+	backend.todos[0].Description, backend.todos[0].Version = "theirs", 5
+	_, cmd = m.undo()
+	if msg := cmd().(historyAppliedMsg); !errors.Is(msg.err, api.ErrConflict) {
+		t.Errorf("undo over someone else's change: err = %v, want ErrConflict", msg.err)
+	}
+	if got := backend.todos[0].Description; got != "theirs" {
+		t.Errorf("description after the refused undo = %q, want theirs kept", got)
+	}
+}
@@ -1,63 +1,93 @@
 package tui
 
 import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
 )
 
 // KanbanBoard represents the kanban board view
 type KanbanBoard struct {
+	workflow            config.Workflow
 	columns             []ProjectColumn
 	selectedCol         int
 	selectedProject     int
 	desiredProject      int
-	scrollOffset        []int // scroll offset for each column
-	desiredScrollOffset []int // desired scroll offset for each column
+	scrollOffset        []int       // scroll offset for each column
+	desiredScrollOffset []int       // desired scroll offset for each column
+	pending             map[int]int // in-flight mutations per project ID
+	theme               Theme
+	keys                keyMap
+	visible             [][]int              // indices into each column's Projects shown by the filter
+	matches             map[int]projectMatch // search matches per project ID
+	query               string
+	filtering           bool // hide projects not matching the query
+	search              textinput.Model
+	Searching           bool   // Exported so model.go can route keys to the search input
+	filterLabel         string // names the filter applied to the projects, if any
+	sortMode            SortMode
+	order               map[string][]int     // manual order of project IDs per column status
+	progress            map[int]todoProgress // todo progress per project ID; nil until known
 	width               int
 	height              int
 }
 
+// pendingMarker prefixes cards and todos with changes not yet confirmed by the API
+const pendingMarker = "⟳ "
+
 // ProjectColumn represents a column containing projects
 type ProjectColumn struct {
 	Name     string
+	Status   string // API status of projects in this column
+	Color    lipgloss.TerminalColor
 	Projects []api.Project
 }
 
-// NewKanbanBoard creates a new kanban board with projects organized by status
-func NewKanbanBoard(projects []api.Project) *KanbanBoard {
-	// Organize projects into columns based on status
-	columns := []ProjectColumn{
-		{Name: "Ready", Projects: []api.Project{}},
-		{Name: "In Progress", Projects: []api.Project{}},
-		{Name: "Finished", Projects: []api.Project{}},
+// NewKanbanBoard creates a new kanban board with projects organized into the
+// workflow's columns by status
+func NewKanbanBoard(projects []api.Project, workflow config.Workflow, theme Theme, keys keyMap) *KanbanBoard {
+	columns := make([]ProjectColumn, len(workflow.Columns))
+	for i, col := range workflow.Columns {
+		columns[i] = ProjectColumn{
+			Name:     col.Name,
+			Status:   col.Status,
+			Color:    theme.columnColor(i, col.Color),
+			Projects: []api.Project{},
+		}
 	}
 
+	// Unknown statuses fall into the first column
 	for _, project := range projects {
-		switch project.Status {
-		case "ready", "Ready", "READY", "":
-			columns[0].Projects = append(columns[0].Projects, project)
-		case "in_progress", "In Progress", "IN_PROGRESS", "in progress":
-			columns[1].Projects = append(columns[1].Projects, project)
-		case "finished", "Finished", "FINISHED", "done", "Done", "DONE":
-			columns[2].Projects = append(columns[2].Projects, project)
-		default:
-			// Default to Ready if status is unknown
-			columns[0].Projects = append(columns[0].Projects, project)
-		}
+		idx := workflow.ColumnIndex(project.Status)
+		columns[idx].Projects = append(columns[idx].Projects, project)
 	}
 
 	kb := &KanbanBoard{
+		workflow:            workflow,
 		columns:             columns,
 		selectedCol:         0,
 		selectedProject:     0,
 		desiredProject:      0,
 		scrollOffset:        make([]int, len(columns)),
 		desiredScrollOffset: make([]int, len(columns)),
+		pending:             make(map[int]int),
+		theme:               theme,
+		keys:                keys,
+		search:              newSearchInput(),
+		sortMode:            SortPriority,
+		order:               make(map[string][]int),
+	}
+	for i := range columns {
+		sortProjects(columns[i].Projects, kb.sortMode, nil)
 	}
+	kb.refilter()
 
 	// Find first non-empty column to start with
-	for i, col := range columns {
-		if len(col.Projects) > 0 {
+	for i := range columns {
+		if len(kb.visible[i]) > 0 {
 			kb.selectedCol = i
 			break
 		}
@@ -66,6 +96,83 @@ func NewKanbanBoard(projects []api.Project) *KanbanBoard {
 	return kb
 }
 
+// SetFilterLabel shows the filter the board's projects were chosen by in
+// the title
+func (b *KanbanBoard) SetFilterLabel(label string) {
+	b.filterLabel = label
+}
+
+// todoProgress counts a project's completed todos
+type todoProgress struct {
+	done, total int
+}
+
+// countTodoProgress tallies the progress of every project owning one of todos
+func countTodoProgress(todos []api.Todo) map[int]todoProgress {
+	progress := make(map[int]todoProgress)
+	for _, todo := range todos {
+		if todo.Deleted || todo.ProjectID == nil {
+			continue
+		}
+		p := progress[*todo.ProjectID]
+		p.total++
+		if todo.Completed {
+			p.done++
+		}
+		progress[*todo.ProjectID] = p
+	}
+	return progress
+}
+
+// SetProgress replaces the todo progress shown on the cards
+func (b *KanbanBoard) SetProgress(progress map[int]todoProgress) {
+	if progress == nil {
+		b.progress = nil
+		return
+	}
+	b.progress = make(map[int]todoProgress, len(progress))
+	for id, p := range progress {
+		b.progress[id] = p
+	}
+}
+
+// SetProjectProgress updates the todo progress shown on one project's card
+func (b *KanbanBoard) SetProjectProgress(projectID, done, total int) {
+	if b.progress == nil {
+		b.progress = make(map[int]todoProgress)
+	}
+	b.progress[projectID] = todoProgress{done: done, total: total}
+}
+
+// shiftProgress adds (delta 1) or takes away (delta -1) a todo from its
+// project's progress, if the progress is known
+func (b *KanbanBoard) shiftProgress(todo api.Todo, delta int) {
+	if todo.ProjectID == nil || b.progress == nil {
+		return
+	}
+	p := b.progress[*todo.ProjectID]
+	p.total += delta
+	if todo.Completed {
+		p.done += delta
+	}
+	b.progress[*todo.ProjectID] = p
+}
+
+// progressBadge renders a project's todo progress, e.g. "✔ 2/5", and its
+// width; empty if the project has no todos or its progress isn't known
+func (b *KanbanBoard) progressBadge(projectID int) (string, int) {
+	p, ok := b.progress[projectID]
+	if !ok || p.total == 0 {
+		return "", 0
+	}
+	text := fmt.Sprintf("✔ %d/%d", p.done, p.total)
+	color := b.theme.Muted
+	if p.done == p.total {
+		color = b.theme.Success
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(text), lipgloss.Width(text)
+}
+
 // SetSize sets the board dimensions
 func (b *KanbanBoard) SetSize(width, height int) {
 	b.width = width
@@ -77,7 +184,7 @@ func (b *KanbanBoard) SetSize(width, height int) {
 	}
 
 	// Reset selection to top if we're scrolled past visible area
-	if len(b.columns) > 0 && len(b.columns[b.selectedCol].Projects) > 0 {
+	if len(b.columns) > 0 && len(b.visible[b.selectedCol]) > 0 {
 		b.selectedProject = 0
 	}
 }
@@ -87,43 +194,29 @@ func (b *KanbanBoard) GetSelectedProject() *api.Project {
 	if len(b.columns) == 0 || b.selectedCol >= len(b.columns) {
 		return nil
 	}
-	col := b.columns[b.selectedCol]
-	if b.selectedProject >= len(col.Projects) {
+	visible := b.visible[b.selectedCol]
+	if b.selectedProject >= len(visible) {
 		return nil
 	}
-	return &col.Projects[b.selectedProject]
+	return &b.columns[b.selectedCol].Projects[visible[b.selectedProject]]
 }
 
-// GetNextStatus returns the status name for progressing a project forward
+// GetNextStatus returns the API status for progressing a project forward
 func (b *KanbanBoard) GetNextStatus() string {
 	if b.selectedCol < len(b.columns)-1 {
-		return b.statusNameToAPIStatus(b.columns[b.selectedCol+1].Name)
+		return b.columns[b.selectedCol+1].Status
 	}
 	return "" // Already at the end
 }
 
-// GetPrevStatus returns the status name for regressing a project backward
+// GetPrevStatus returns the API status for regressing a project backward
 func (b *KanbanBoard) GetPrevStatus() string {
 	if b.selectedCol > 0 {
-		return b.statusNameToAPIStatus(b.columns[b.selectedCol-1].Name)
+		return b.columns[b.selectedCol-1].Status
 	}
 	return "" // Already at the start
 }
 
-// statusNameToAPIStatus converts display name to API status value
-func (b *KanbanBoard) statusNameToAPIStatus(displayName string) string {
-	switch displayName {
-	case "Ready":
-		return "ready"
-	case "In Progress":
-		return "in_progress"
-	case "Finished":
-		return "finished"
-	default:
-		return "ready"
-	}
-}
-
 // UpdateProjectInBoard updates a project in the board after an API change
 func (b *KanbanBoard) UpdateProjectInBoard(updatedProject api.Project) {
 	// Remove the project from its current column
@@ -139,58 +232,143 @@ func (b *KanbanBoard) UpdateProjectInBoard(updatedProject api.Project) {
 				// Find the new column for the project
 				newColIdx := b.getColumnIndexForStatus(updatedProject.Status)
 
-				// Insert into new column in sorted order (priority DESC, then name ASC)
-				inserted := false
-				for i, proj := range b.columns[newColIdx].Projects {
-					// Insert before this project if:
-					// - Our priority is higher, OR
-					// - Same priority but our name comes first alphabetically
-					if updatedProject.Priority > proj.Priority ||
-						(updatedProject.Priority == proj.Priority && updatedProject.Name < proj.Name) {
-						// Insert at position i
-						b.columns[newColIdx].Projects = append(
-							b.columns[newColIdx].Projects[:i],
-							append([]api.Project{updatedProject}, b.columns[newColIdx].Projects[i:]...)...,
-						)
-						b.selectedProject = i
-						b.desiredProject = i
-						inserted = true
-						break
-					}
-				}
+				// Insert into new column in sort order
+				col := &b.columns[newColIdx]
+				col.Projects = append(col.Projects, updatedProject)
+				sortProjects(col.Projects, b.sortMode, b.order[col.Status])
 
-				// If not inserted yet, append to end
-				if !inserted {
-					b.columns[newColIdx].Projects = append(b.columns[newColIdx].Projects, updatedProject)
-					b.selectedProject = len(b.columns[newColIdx].Projects) - 1
-					b.desiredProject = b.selectedProject
+				// Update selection to follow the project, unless the filter hides it
+				b.refilter()
+				if !b.SelectProject(updatedProject.ID) {
+					b.clampSelection()
 				}
+				return
+			}
+		}
+	}
+}
 
-				// Update selection to follow the project
-				b.selectedCol = newColIdx
+// FindProject returns a copy of the project with the given ID, if it is on the board
+func (b *KanbanBoard) FindProject(projectID int) (api.Project, bool) {
+	for _, col := range b.columns {
+		for _, proj := range col.Projects {
+			if proj.ID == projectID {
+				return proj, true
+			}
+		}
+	}
+	return api.Project{}, false
+}
 
-				// Ensure the selected project is visible
-				b.scrollOffset[b.selectedCol] = min(b.selectedProject, b.scrollOffset[b.selectedCol])
-				b.desiredScrollOffset[b.selectedCol] = b.scrollOffset[b.selectedCol]
+// SelectProject moves the selection to the project with the given ID,
+// scrolling it into view. It returns false if the project is not on the
+// board or is hidden by the filter.
+func (b *KanbanBoard) SelectProject(projectID int) bool {
+	for colIdx, visible := range b.visible {
+		for pos, projIdx := range visible {
+			if b.columns[colIdx].Projects[projIdx].ID == projectID {
+				b.selectPosition(colIdx, pos)
+				return true
+			}
+		}
+	}
+	return false
+}
 
-				return
+// selectPosition selects the pos'th shown project of a column, scrolling
+// it into view
+func (b *KanbanBoard) selectPosition(colIdx, pos int) {
+	b.selectedCol = colIdx
+	b.selectedProject = pos
+	b.desiredProject = pos
+
+	maxProjects := max((b.height-10)/7, 1)
+	if pos < b.scrollOffset[colIdx] {
+		b.scrollOffset[colIdx] = pos
+	} else if pos >= b.scrollOffset[colIdx]+maxProjects {
+		b.scrollOffset[colIdx] = pos - maxProjects + 1
+	}
+	b.desiredScrollOffset[colIdx] = b.scrollOffset[colIdx]
+}
+
+// clampSelection keeps the selection on a shown project after projects
+// were removed or hidden, moving to another column if this one emptied
+func (b *KanbanBoard) clampSelection() {
+	if len(b.columns) == 0 {
+		return
+	}
+	if len(b.visible[b.selectedCol]) == 0 {
+		for i := range b.visible {
+			if len(b.visible[i]) > 0 {
+				b.selectedCol = i
+				break
+			}
+		}
+	}
+	col := b.selectedCol
+	if b.selectedProject >= len(b.visible[col]) {
+		b.selectedProject = max(len(b.visible[col])-1, 0)
+		b.desiredProject = b.selectedProject
+	}
+	b.scrollOffset[col] = min(b.selectedProject, b.scrollOffset[col])
+	b.desiredScrollOffset[col] = b.scrollOffset[col]
+}
+
+// SyncProject replaces a project with the server's copy without moving the
+// selection away from whichever project is currently selected
+func (b *KanbanBoard) SyncProject(project api.Project) {
+	selected := b.GetSelectedProject()
+	if selected == nil {
+		b.UpdateProjectInBoard(project)
+		return
+	}
+	selectedID := selected.ID
+	b.UpdateProjectInBoard(project)
+	b.SelectProject(selectedID)
+}
+
+// BeginPending marks a project as having a mutation in flight
+func (b *KanbanBoard) BeginPending(projectID int) {
+	b.pending[projectID]++
+}
+
+// EndPending clears one in-flight mutation, reporting whether none remain
+func (b *KanbanBoard) EndPending(projectID int) bool {
+	if b.pending[projectID] > 1 {
+		b.pending[projectID]--
+		return false
+	}
+	delete(b.pending, projectID)
+	return true
+}
+
+// IsPending reports whether a project has mutations in flight
+func (b *KanbanBoard) IsPending(projectID int) bool {
+	return b.pending[projectID] > 0
+}
+
+// RemoveProject removes a project from the board, e.g. after it was deleted
+func (b *KanbanBoard) RemoveProject(projectID int) {
+	for colIdx := range b.columns {
+		for projIdx, proj := range b.columns[colIdx].Projects {
+			if proj.ID != projectID {
+				continue
 			}
+			b.columns[colIdx].Projects = append(
+				b.columns[colIdx].Projects[:projIdx],
+				b.columns[colIdx].Projects[projIdx+1:]...,
+			)
+
+			b.refilter()
+			b.clampSelection()
+			return
 		}
 	}
 }
 
 // getColumnIndexForStatus returns the column index for a given status
 func (b *KanbanBoard) getColumnIndexForStatus(status string) int {
-	switch status {
-	case "ready", "Ready", "READY", "":
-		return 0
-	case "in_progress", "In Progress", "IN_PROGRESS", "in progress":
-		return 1
-	case "finished", "Finished", "FINISHED", "done", "Done", "DONE":
-		return 2
-	default:
-		return 0
-	}
+	return b.workflow.ColumnIndex(status)
 }
 
 // RenderProjectCard renders a single project card
@@ -199,42 +377,26 @@ func (b *KanbanBoard) RenderProjectCard(project *api.Project, width int) string
 		return ""
 	}
 
-	// Priority-based border colors
-	lowPriorityBorder := lipgloss.Color("240")        // Gray
-	mediumLowPriorityBorder := lipgloss.Color("70")   // Green
-	mediumHighPriorityBorder := lipgloss.Color("214") // Yellow/Orange
-	highPriorityBorder := lipgloss.Color("196")       // Red
-
-	var borderColor lipgloss.Color
-	switch project.Priority {
-	case 0:
-		borderColor = lowPriorityBorder
-	case 1:
-		borderColor = mediumLowPriorityBorder
-	case 2:
-		borderColor = mediumHighPriorityBorder
-	default: // 3 or above
-		borderColor = highPriorityBorder
-	}
-
 	projectCardStyle := lipgloss.NewStyle().
 		Padding(1, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(borderColor)
+		BorderForeground(b.theme.priorityColor(project.Priority))
 
 	// Build project card content
 	name := project.Name
 	maxNameLen := width - 6
-	if len(name) > maxNameLen {
-		name = name[:maxNameLen-3] + "..."
+	pending := b.IsPending(project.ID)
+	if pending {
+		maxNameLen -= lipgloss.Width(pendingMarker)
 	}
-
-	description := project.Description
-	maxDescLen := width - 6
-	if len(description) > maxDescLen {
-		description = description[:maxDescLen-3] + "..."
+	match := b.matches[project.ID]
+	name = highlightMatches(b.theme, name, match.name, maxNameLen)
+	if pending {
+		name = pendingMarker + name
 	}
 
+	description := highlightMatches(b.theme, project.Description, match.description, width-6)
+
 	// Language badge
 	languageBadge := ""
 	if project.Language != "" {
@@ -242,13 +404,17 @@ func (b *KanbanBoard) RenderProjectCard(project *api.Project, width int) string
 	}
 
 	// Status badge
-	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	statusText := statusStyle.Render("Status: " + project.Status)
+	statusStyle := lipgloss.NewStyle().Foreground(b.theme.Muted)
+	status := "Status: " + b.workflow.DisplayName(project.Status)
+	if p, ok := b.progress[project.ID]; ok && p.total > 0 {
+		status += fmt.Sprintf(" · Todos: %d/%d done", p.done, p.total)
+	}
+	statusText := statusStyle.Render(status)
 
 	// Create header with name (left) and language (right)
 	headerWidth := width - 6
 	nameStyle := lipgloss.NewStyle().Align(lipgloss.Left)
-	langStyle := lipgloss.NewStyle().Align(lipgloss.Right).Foreground(lipgloss.Color("241"))
+	langStyle := lipgloss.NewStyle().Align(lipgloss.Right).Foreground(b.theme.Muted)
 
 	header := lipgloss.JoinHorizontal(
 		lipgloss.Top,
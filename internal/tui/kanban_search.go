@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newSearchInput creates the input line of the board's search mode
+func newSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "search name, description or language"
+	ti.CharLimit = 100
+	return ti
+}
+
+// refilter recomputes the search matches and which projects each column
+// shows. It leaves the selection alone; callers clamp or reselect.
+func (b *KanbanBoard) refilter() {
+	b.matches = make(map[int]projectMatch)
+	b.visible = make([][]int, len(b.columns))
+	for colIdx, col := range b.columns {
+		b.visible[colIdx] = make([]int, 0, len(col.Projects))
+		for projIdx, project := range col.Projects {
+			if b.query != "" {
+				m, ok := matchProject(b.query, project)
+				if ok {
+					b.matches[project.ID] = m
+				} else if b.filtering {
+					continue
+				}
+			}
+			b.visible[colIdx] = append(b.visible[colIdx], projIdx)
+		}
+	}
+}
+
+// Filtered reports whether the filter is hiding projects that don't match
+// the search
+func (b *KanbanBoard) Filtered() bool {
+	return b.filtering && b.query != ""
+}
+
+// SetSearch restores a search, e.g. on the board rebuilt by a refresh
+func (b *KanbanBoard) SetSearch(query string, filtering bool) {
+	b.query = query
+	b.filtering = filtering
+	b.search.SetValue(query)
+	b.refilter()
+	b.clampSelection()
+}
+
+// Search returns the current query and whether it filters the board
+func (b *KanbanBoard) Search() (string, bool) {
+	return b.query, b.filtering
+}
+
+// setQuery updates the search as it is typed, jumping to the best hit
+func (b *KanbanBoard) setQuery(query string) {
+	selected := b.GetSelectedProject()
+	b.query = query
+	b.refilter()
+
+	bestCol, bestPos, bestScore := -1, 0, 0
+	for colIdx, visible := range b.visible {
+		for pos, projIdx := range visible {
+			m, ok := b.matches[b.columns[colIdx].Projects[projIdx].ID]
+			if ok && (bestCol < 0 || m.score > bestScore) {
+				bestCol, bestPos, bestScore = colIdx, pos, m.score
+			}
+		}
+	}
+	if bestCol >= 0 {
+		b.selectPosition(bestCol, bestPos)
+		return
+	}
+	if selected == nil || !b.SelectProject(selected.ID) {
+		b.clampSelection()
+	}
+}
+
+// clearSearch drops the query and filter, keeping the selected project
+func (b *KanbanBoard) clearSearch() {
+	selected := b.GetSelectedProject()
+	b.query = ""
+	b.filtering = false
+	b.search.SetValue("")
+	b.refilter()
+	if selected == nil || !b.SelectProject(selected.ID) {
+		b.clampSelection()
+	}
+}
+
+// toggleFilter hides or shows the projects not matching the search
+func (b *KanbanBoard) toggleFilter() {
+	selected := b.GetSelectedProject()
+	b.filtering = !b.filtering
+	b.refilter()
+	if selected == nil || !b.SelectProject(selected.ID) {
+		b.clampSelection()
+	}
+}
+
+// jumpMatch selects the next (dir 1) or previous (dir -1) search hit in
+// reading order, column by column, wrapping around the board
+func (b *KanbanBoard) jumpMatch(dir int) {
+	type hit struct{ col, pos int }
+	var before, after []hit
+	for colIdx, visible := range b.visible {
+		for pos, projIdx := range visible {
+			if _, ok := b.matches[b.columns[colIdx].Projects[projIdx].ID]; !ok {
+				continue
+			}
+			h := hit{colIdx, pos}
+			if colIdx < b.selectedCol || (colIdx == b.selectedCol && pos < b.selectedProject) {
+				before = append(before, h)
+			} else if colIdx != b.selectedCol || pos != b.selectedProject {
+				after = append(after, h)
+			}
+		}
+	}
+
+	var target *hit
+	switch {
+	case dir > 0 && len(after) > 0:
+		target = &after[0]
+	case dir > 0 && len(before) > 0:
+		target = &before[0]
+	case dir < 0 && len(before) > 0:
+		target = &before[len(before)-1]
+	case dir < 0 && len(after) > 0:
+		target = &after[len(after)-1]
+	}
+	if target != nil {
+		b.selectPosition(target.col, target.pos)
+	}
+}
+
+// MatchCount returns how many projects match the search
+func (b *KanbanBoard) MatchCount() int {
+	return len(b.matches)
+}
+
+// updateSearch handles keys while the search input is open
+func (b KanbanBoard) updateSearch(msg tea.KeyMsg) (KanbanBoard, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		// Keep the search (and filter) and go back to the board
+		b.Searching = false
+		b.search.Blur()
+		if b.query == "" {
+			b.clearSearch()
+		}
+		return b, nil
+	case "esc":
+		b.Searching = false
+		b.search.Blur()
+		b.clearSearch()
+		return b, nil
+	}
+
+	var cmd tea.Cmd
+	b.search, cmd = b.search.Update(msg)
+	if b.search.Value() != b.query {
+		b.setQuery(b.search.Value())
+	}
+	return b, cmd
+}
+
+// startSearch opens the search input, editing the current query
+func (b *KanbanBoard) startSearch() tea.Cmd {
+	b.Searching = true
+	b.search.SetValue(b.query)
+	b.search.CursorEnd()
+	return b.search.Focus()
+}
+
+// searchKey handles the search keys on the board, reporting whether msg
+// was one of them
+func (b *KanbanBoard) searchKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, b.keys.Search):
+		return b.startSearch(), true
+	case key.Matches(msg, b.keys.Filter):
+		if b.query == "" {
+			// Nothing to filter by yet: type the query first
+			b.filtering = true
+			return b.startSearch(), true
+		}
+		b.toggleFilter()
+		return nil, true
+	case b.query == "":
+		return nil, false
+	case key.Matches(msg, b.keys.NextMatch):
+		b.jumpMatch(1)
+		return nil, true
+	case key.Matches(msg, b.keys.PrevMatch):
+		b.jumpMatch(-1)
+		return nil, true
+	case key.Matches(msg, b.keys.Back):
+		b.clearSearch()
+		return nil, true
+	}
+	return nil, false
+}
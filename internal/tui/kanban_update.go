@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -8,27 +9,67 @@ import (
 func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "a":
+		if b.Searching {
+			return b.updateSearch(msg)
+		}
+		if cmd, ok := b.searchKey(msg); ok {
+			return b, cmd
+		}
+
+		switch {
+		case key.Matches(msg, b.keys.Sort):
+			b.resort(b.sortMode.next())
+			return b, func() tea.Msg {
+				return boardOrderChangedMsg{}
+			}
+		case key.Matches(msg, b.keys.MoveUp):
+			if b.moveCard(-1) {
+				return b, func() tea.Msg {
+					return boardOrderChangedMsg{}
+				}
+			}
+		case key.Matches(msg, b.keys.MoveDown):
+			if b.moveCard(1) {
+				return b, func() tea.Msg {
+					return boardOrderChangedMsg{}
+				}
+			}
+		case key.Matches(msg, b.keys.Filters):
+			return b, func() tea.Msg {
+				return openFilterPickerMsg{}
+			}
+		case key.Matches(msg, b.keys.Add):
 			// Add new project - this will be handled by the parent Model
 			return b, func() tea.Msg {
 				return openProjectModalMsg{}
 			}
-		case "e":
+		case key.Matches(msg, b.keys.Edit):
 			// Edit selected project - this will be handled by the parent Model
 			if project := b.GetSelectedProject(); project != nil {
 				return b, func() tea.Msg {
 					return openEditProjectModalMsg{project: project}
 				}
 			}
-		case "d", "x":
+		case key.Matches(msg, b.keys.Delete):
 			// Delete selected project - this will be handled by the parent Model
 			if project := b.GetSelectedProject(); project != nil {
 				return b, func() tea.Msg {
 					return deleteProjectMsg{projectID: project.ID}
 				}
 			}
-		case "p":
+		case key.Matches(msg, b.keys.Open):
+			if project := b.GetSelectedProject(); project != nil {
+				return b, launchProjectCmd(*project, launchEditor)
+			}
+		case key.Matches(msg, b.keys.Shell):
+			if project := b.GetSelectedProject(); project != nil {
+				return b, launchProjectCmd(*project, launchShell)
+			}
+		case key.Matches(msg, b.keys.Launch):
+			if project := b.GetSelectedProject(); project != nil {
+				return b, launchProjectCmd(*project, launchCommand)
+			}
+		case key.Matches(msg, b.keys.Progress):
 			// Progress: move project to next status
 			if project := b.GetSelectedProject(); project != nil {
 				nextStatus := b.GetNextStatus()
@@ -39,7 +80,7 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					}
 				}
 			}
-		case "r":
+		case key.Matches(msg, b.keys.Regress):
 			// Regress: move project to previous status
 			if project := b.GetSelectedProject(); project != nil {
 				prevStatus := b.GetPrevStatus()
@@ -50,7 +91,7 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					}
 				}
 			}
-		case "+", "=":
+		case key.Matches(msg, b.keys.PriorityUp):
 			// Increase priority (maximum 3)
 			if project := b.GetSelectedProject(); project != nil {
 				newPriority := project.Priority + 1
@@ -62,7 +103,7 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					return updatePriorityMsg{projectID: project.ID, priority: newPriority}
 				}
 			}
-		case "-", "_":
+		case key.Matches(msg, b.keys.PriorityDown):
 			// Decrease priority (minimum 0)
 			if project := b.GetSelectedProject(); project != nil {
 				newPriority := project.Priority - 1
@@ -74,13 +115,13 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					return updatePriorityMsg{projectID: project.ID, priority: newPriority}
 				}
 			}
-		case "left", "j":
+		case key.Matches(msg, b.keys.Left):
 			// Move left, skipping empty columns
 			for i := b.selectedCol - 1; i >= 0; i-- {
-				if len(b.columns[i].Projects) > 0 {
+				if len(b.visible[i]) > 0 {
 					b.selectedCol = i
 					// Try to maintain position using desiredProject, but clamp to available range
-					b.selectedProject = min(b.desiredProject, len(b.columns[i].Projects)-1)
+					b.selectedProject = min(b.desiredProject, len(b.visible[i])-1)
 					// Restore the desired scroll offset for this column
 					b.scrollOffset[i] = b.desiredScrollOffset[i]
 
@@ -106,13 +147,13 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					break
 				}
 			}
-		case "right", ";":
+		case key.Matches(msg, b.keys.Right):
 			// Move right, skipping empty columns
 			for i := b.selectedCol + 1; i < len(b.columns); i++ {
-				if len(b.columns[i].Projects) > 0 {
+				if len(b.visible[i]) > 0 {
 					b.selectedCol = i
 					// Try to maintain position using desiredProject, but clamp to available range
-					b.selectedProject = min(b.desiredProject, len(b.columns[i].Projects)-1)
+					b.selectedProject = min(b.desiredProject, len(b.visible[i])-1)
 					// Restore the desired scroll offset for this column
 					b.scrollOffset[i] = b.desiredScrollOffset[i]
 
@@ -136,7 +177,7 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 					break
 				}
 			}
-		case "up", "l":
+		case key.Matches(msg, b.keys.Up):
 			if b.selectedProject > 0 {
 				b.selectedProject--
 				// Update desiredProject to track the maximum index reached
@@ -146,9 +187,8 @@ func (b KanbanBoard) Update(msg tea.Msg) (KanbanBoard, tea.Cmd) {
 				// Save the current scroll position as desired
 				b.desiredScrollOffset[b.selectedCol] = b.scrollOffset[b.selectedCol]
 			}
-		case "down", "k":
-			currentCol := b.columns[b.selectedCol]
-			if b.selectedProject < len(currentCol.Projects)-1 {
+		case key.Matches(msg, b.keys.Down):
+			if b.selectedProject < len(b.visible[b.selectedCol])-1 {
 				b.selectedProject++
 				// Update desiredProject to track the maximum index reached
 				b.desiredProject = b.selectedProject
@@ -15,56 +15,40 @@ func (b *KanbanBoard) View() string {
 	// Styles
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("63")).
+		Foreground(b.theme.Accent).
 		MarginBottom(1).
 		MarginLeft(2)
 
-	// Column colors: blue, yellow, green
-	columnColors := []lipgloss.Color{
-		lipgloss.Color("63"),  // Blue for "Ready"
-		lipgloss.Color("214"), // Yellow for "In Progress"
-		lipgloss.Color("70"),  // Green for "Finished"
-	}
-
 	columnHeaderStyle := lipgloss.NewStyle().
 		Bold(true).
 		Padding(0, 1).
-		Foreground(lipgloss.Color("15")).
+		Foreground(b.theme.HeaderText).
 		Align(lipgloss.Center)
 
-	// Priority-based border colors (4 levels: 0-3)
-	// Priority 0: Low (Gray)
-	// Priority 1: Medium-Low (Green)
-	// Priority 2: Medium-High (Yellow/Orange)
-	// Priority 3: High (Red)
-	lowPriorityBorder := lipgloss.Color("240")        // Gray
-	mediumLowPriorityBorder := lipgloss.Color("70")   // Green
-	mediumHighPriorityBorder := lipgloss.Color("214") // Yellow/Orange
-	highPriorityBorder := lipgloss.Color("196")       // Red
-
-	// Priority-based selection colors (lighter versions)
-	lowPrioritySelected := lipgloss.Color("245")        // Light Gray
-	mediumLowPrioritySelected := lipgloss.Color("120")  // Light Green
-	mediumHighPrioritySelected := lipgloss.Color("227") // Light Yellow
-	highPrioritySelected := lipgloss.Color("210")       // Light Red
-
 	projectCardStyle := lipgloss.NewStyle().
 		Padding(1, 2).
 		MarginBottom(1).
 		Border(lipgloss.RoundedBorder())
 
 	emptyColumnStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(b.theme.Muted).
 		Padding(2).
 		Italic(true)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(b.theme.Muted).
 		MarginTop(1).
 		MarginLeft(2)
 
 	// Title
 	title := titleStyle.Render("📋 Project Board")
+	subtitle := ""
+	if b.filterLabel != "" {
+		subtitle += " · " + b.filterLabel
+	}
+	subtitle += " · sorted by " + string(b.sortMode)
+	subtitleStyle := lipgloss.NewStyle().Foreground(b.theme.Muted).MarginBottom(1)
+	title = lipgloss.JoinHorizontal(lipgloss.Top, title, subtitleStyle.Render(subtitle))
 
 	// Calculate column width
 	colWidth := (b.width - 8) / len(b.columns)
@@ -85,12 +69,17 @@ func (b *KanbanBoard) View() string {
 		// Column border style
 		columnBorderStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(columnColors[i]).
+			BorderForeground(col.Color).
 			Padding(0, 1)
 
 		// Column header
-		headerStyle := columnHeaderStyle.Background(columnColors[i])
-		header := headerStyle.Width(colWidth - 4).Render(fmt.Sprintf("%s (%d)", col.Name, len(col.Projects)))
+		headerStyle := columnHeaderStyle.Background(col.Color).Reverse(b.theme.Monochrome)
+		visible := b.visible[i]
+		count := fmt.Sprintf("%d", len(col.Projects))
+		if b.Filtered() {
+			count = fmt.Sprintf("%d/%d", len(visible), len(col.Projects))
+		}
+		header := headerStyle.Width(colWidth - 4).Render(fmt.Sprintf("%s (%s)", col.Name, count))
 
 		// Projects
 		projectViews := []string{}
@@ -104,65 +93,61 @@ func (b *KanbanBoard) View() string {
 		// Calculate scroll range for this column
 		scrollStart := b.scrollOffset[i]
 		scrollEnd := scrollStart + maxProjects
-		if scrollEnd > len(col.Projects) {
-			scrollEnd = len(col.Projects)
+		if scrollEnd > len(visible) {
+			scrollEnd = len(visible)
 		}
 
 		for j := scrollStart; j < scrollEnd; j++ {
-			project := col.Projects[j]
-
-			// Determine border color based on priority (4 levels)
-			var borderColor lipgloss.Color
-			var selectedBorderColor lipgloss.Color
-
-			switch project.Priority {
-			case 0:
-				borderColor = lowPriorityBorder
-				selectedBorderColor = lowPrioritySelected
-			case 1:
-				borderColor = mediumLowPriorityBorder
-				selectedBorderColor = mediumLowPrioritySelected
-			case 2:
-				borderColor = mediumHighPriorityBorder
-				selectedBorderColor = mediumHighPrioritySelected
-			default: // 3 or above
-				borderColor = highPriorityBorder
-				selectedBorderColor = highPrioritySelected
-			}
+			project := col.Projects[visible[j]]
+			match := b.matches[project.ID]
 
-			style := projectCardStyle.BorderForeground(borderColor)
+			// Border color follows priority, lighter when selected
+			style := projectCardStyle.BorderForeground(b.theme.priorityColor(project.Priority))
 			if i == b.selectedCol && j == b.selectedProject {
-				style = projectCardStyle.BorderForeground(selectedBorderColor).Bold(true)
+				style = projectCardStyle.
+					Border(b.theme.selectedBorder()).
+					BorderForeground(b.theme.prioritySelectedColor(project.Priority)).
+					Bold(true)
 			}
 
 			// Build project card content
 			name := project.Name
 			maxNameLen := colWidth - 6
-			if len(name) > maxNameLen {
-				name = name[:maxNameLen-3] + "..."
+			pending := b.IsPending(project.ID)
+			if pending {
+				maxNameLen -= lipgloss.Width(pendingMarker)
 			}
-
-			description := project.Description
-			maxDescLen := colWidth - 6
-			if len(description) > maxDescLen {
-				description = description[:maxDescLen-3] + "..."
+			name = highlightMatches(b.theme, name, match.name, maxNameLen)
+			if pending {
+				name = pendingMarker + name
 			}
 
+			description := highlightMatches(b.theme, project.Description, match.description, colWidth-6)
+
 			// Language badge (right-aligned)
-			languageBadge := ""
-			if project.Language != "" {
-				languageBadge = project.Language
+			languageBadge := project.Language
+			languageWidth := len(languageBadge)
+			languageBadge = highlightMatches(b.theme, languageBadge, match.language, languageWidth)
+
+			// Todo progress goes before the language
+			if progress, width := b.progressBadge(project.ID); width > 0 {
+				if languageWidth > 0 {
+					progress += " "
+					width++
+				}
+				languageBadge = progress + languageBadge
+				languageWidth += width
 			}
 
-			// Create header with name (left) and language (right)
+			// Create header with name (left) and badges (right)
 			headerWidth := colWidth - 6
 			nameStyle := lipgloss.NewStyle().Align(lipgloss.Left)
-			langStyle := lipgloss.NewStyle().Align(lipgloss.Right).Foreground(lipgloss.Color("241"))
+			langStyle := lipgloss.NewStyle().Align(lipgloss.Right).Foreground(b.theme.Muted)
 
 			header := lipgloss.JoinHorizontal(
 				lipgloss.Top,
-				nameStyle.Width(headerWidth-len(languageBadge)).Render(name),
-				langStyle.Width(len(languageBadge)).Render(languageBadge),
+				nameStyle.Width(headerWidth-languageWidth).Render(name),
+				langStyle.Width(languageWidth).Render(languageBadge),
 			)
 
 			// Description (centered)
@@ -178,22 +163,26 @@ func (b *KanbanBoard) View() string {
 		// Add scrolling indicators
 		if scrollStart > 0 {
 			indicator := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241")).
+				Foreground(b.theme.Muted).
 				Align(lipgloss.Center).
 				Render(fmt.Sprintf("↑ %d more above", scrollStart))
 			projectViews = append([]string{indicator}, projectViews...)
 		}
 
-		if scrollEnd < len(col.Projects) {
+		if scrollEnd < len(visible) {
 			indicator := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241")).
+				Foreground(b.theme.Muted).
 				Align(lipgloss.Center).
-				Render(fmt.Sprintf("↓ %d more below", len(col.Projects)-scrollEnd))
+				Render(fmt.Sprintf("↓ %d more below", len(visible)-scrollEnd))
 			projectViews = append(projectViews, indicator)
 		}
 
 		if len(projectViews) == 0 {
-			projectViews = append(projectViews, emptyColumnStyle.Width(colWidth-6).Render("No projects"))
+			empty := "No projects"
+			if len(col.Projects) > 0 {
+				empty = "No matches"
+			}
+			projectViews = append(projectViews, emptyColumnStyle.Width(colWidth-6).Render(empty))
 		}
 
 		columnContent := lipgloss.JoinVertical(lipgloss.Left, projectViews...)
@@ -211,8 +200,35 @@ func (b *KanbanBoard) View() string {
 	// Join columns horizontally with spacing
 	board := lipgloss.JoinHorizontal(lipgloss.Top, columnViews...)
 
-	// Help text
-	help := helpStyle.Render("  ←/j →/; columns • ↑/l ↓/k projects • enter todos • a add • e edit • d delete • p progress • r regress • +/- priority • R refresh • q quit")
+	// Help text, replaced by the search line while searching
+	k := b.keys
+	help := helpStyle.Render("  " + helpLine(
+		helpEntry("columns", k.Left, k.Right),
+		helpEntry("projects", k.Up, k.Down),
+		helpEntry("todos", k.Enter),
+		helpEntry("all todos", k.AllTodos),
+		helpEntry("inbox", k.Inbox),
+		helpEntry("add", k.Add),
+		helpEntry("edit", k.Edit),
+		helpEntry("delete", k.Delete),
+		helpEntry("open", k.Open),
+		helpEntry("shell", k.Shell),
+		helpEntry("launch", k.Launch),
+		helpEntry("progress", k.Progress),
+		helpEntry("regress", k.Regress),
+		helpEntry("priority", k.PriorityUp, k.PriorityDown),
+		helpEntry("undo/redo", k.Undo, k.Redo),
+		helpEntry("search", k.Search),
+		helpEntry("filters", k.Filters),
+		helpEntry("sort", k.Sort),
+		helpEntry("reorder", k.MoveUp, k.MoveDown),
+		helpEntry("refresh", k.Refresh),
+		helpEntry("messages", k.Messages),
+		helpEntry("quit", k.Quit),
+	))
+	if b.Searching || b.query != "" {
+		help = helpStyle.Render(b.searchView())
+	}
 
 	// Combine everything
 	return lipgloss.JoinVertical(
@@ -224,3 +240,30 @@ func (b *KanbanBoard) View() string {
 		help,
 	)
 }
+
+// searchView renders the search line: the input while typing, then the
+// query with its hit count and the keys acting on it
+func (b *KanbanBoard) searchView() string {
+	k := b.keys
+	matches := fmt.Sprintf("%d matches", b.MatchCount())
+	if b.MatchCount() == 1 {
+		matches = "1 match"
+	}
+	matchStyle := lipgloss.NewStyle().Foreground(b.theme.Muted)
+
+	if b.Searching {
+		return b.search.View() + "  " + matchStyle.Render(matches+" • enter keep • esc clear")
+	}
+
+	query := lipgloss.NewStyle().Foreground(b.theme.Accent).Render("/" + b.query)
+	state := matches
+	if b.Filtered() {
+		state += ", filtered"
+	}
+	return query + "  " + matchStyle.Render(state+" • "+helpLine(
+		helpEntry("next/prev", k.NextMatch, k.PrevMatch),
+		helpEntry("filter", k.Filter),
+		helpEntry("edit search", k.Search),
+		helpEntry("clear", k.Back),
+	))
+}
@@ -0,0 +1,374 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+// Action names a command that can be bound to keys, as used in the
+// [keys.bindings] table of the config file
+type Action string
+
+const (
+	ActionUp           Action = "up"
+	ActionDown         Action = "down"
+	ActionLeft         Action = "left"
+	ActionRight        Action = "right"
+	ActionTodos        Action = "todos"
+	ActionBack         Action = "back"
+	ActionQuit         Action = "quit"
+	ActionRefresh      Action = "refresh"
+	ActionMessages     Action = "messages"
+	ActionUndo         Action = "undo"
+	ActionRedo         Action = "redo"
+	ActionAdd          Action = "add"
+	ActionEdit         Action = "edit"
+	ActionDelete       Action = "delete"
+	ActionProgress     Action = "progress"
+	ActionRegress      Action = "regress"
+	ActionPriorityUp   Action = "priority_up"
+	ActionPriorityDown Action = "priority_down"
+	ActionSearch       Action = "search"
+	ActionNextMatch    Action = "next_match"
+	ActionPrevMatch    Action = "prev_match"
+	ActionFilter       Action = "filter"
+	ActionFilters      Action = "filters"
+	ActionSort         Action = "sort"
+	ActionMoveUp       Action = "move_up"
+	ActionMoveDown     Action = "move_down"
+	ActionComplete     Action = "complete"
+	ActionShowDone     Action = "show_completed"
+	ActionAllTodos     Action = "all_todos"
+	ActionGroup        Action = "group"
+	ActionMove         Action = "move"
+	ActionInbox        Action = "inbox"
+	ActionNotes        Action = "notes"
+	ActionNotesEditor  Action = "notes_editor"
+	ActionOpen         Action = "open"
+	ActionShell        Action = "shell"
+	ActionLaunch       Action = "launch"
+	ActionKeepMine     Action = "keep_mine"
+	ActionKeepTheirs   Action = "keep_theirs"
+	ActionMerge        Action = "merge"
+	ActionToggleSide   Action = "toggle_side"
+)
+
+type keyMap struct {
+	Up           key.Binding
+	Down         key.Binding
+	Left         key.Binding
+	Right        key.Binding
+	Enter        key.Binding
+	Back         key.Binding
+	Quit         key.Binding
+	Refresh      key.Binding
+	Messages     key.Binding
+	Undo         key.Binding
+	Redo         key.Binding
+	Add          key.Binding
+	Edit         key.Binding
+	Delete       key.Binding
+	Progress     key.Binding
+	Regress      key.Binding
+	PriorityUp   key.Binding
+	PriorityDown key.Binding
+	Search       key.Binding
+	NextMatch    key.Binding
+	PrevMatch    key.Binding
+	Filter       key.Binding
+	Filters      key.Binding
+	Sort         key.Binding
+	MoveUp       key.Binding
+	MoveDown     key.Binding
+	Complete     key.Binding
+	ShowDone     key.Binding
+	AllTodos     key.Binding
+	Group        key.Binding
+	Move         key.Binding
+	Inbox        key.Binding
+	Notes        key.Binding
+	NotesEditor  key.Binding
+	Open         key.Binding
+	Shell        key.Binding
+	Launch       key.Binding
+	KeepMine     key.Binding
+	KeepTheirs   key.Binding
+	Merge        key.Binding
+	ToggleSide   key.Binding
+}
+
+// actionDef registers an action with its description and the keyMap field
+// holding its binding
+type actionDef struct {
+	action  Action
+	desc    string
+	binding func(*keyMap) *key.Binding
+}
+
+// actions is the registry of every bindable action
+var actions = []actionDef{
+	{ActionUp, "move up", func(k *keyMap) *key.Binding { return &k.Up }},
+	{ActionDown, "move down", func(k *keyMap) *key.Binding { return &k.Down }},
+	{ActionLeft, "move left", func(k *keyMap) *key.Binding { return &k.Left }},
+	{ActionRight, "move right", func(k *keyMap) *key.Binding { return &k.Right }},
+	{ActionTodos, "todos", func(k *keyMap) *key.Binding { return &k.Enter }},
+	{ActionBack, "back", func(k *keyMap) *key.Binding { return &k.Back }},
+	{ActionQuit, "quit", func(k *keyMap) *key.Binding { return &k.Quit }},
+	{ActionRefresh, "refresh", func(k *keyMap) *key.Binding { return &k.Refresh }},
+	{ActionMessages, "messages", func(k *keyMap) *key.Binding { return &k.Messages }},
+	{ActionUndo, "undo", func(k *keyMap) *key.Binding { return &k.Undo }},
+	{ActionRedo, "redo", func(k *keyMap) *key.Binding { return &k.Redo }},
+	{ActionAdd, "add", func(k *keyMap) *key.Binding { return &k.Add }},
+	{ActionEdit, "edit", func(k *keyMap) *key.Binding { return &k.Edit }},
+	{ActionDelete, "delete", func(k *keyMap) *key.Binding { return &k.Delete }},
+	{ActionProgress, "progress", func(k *keyMap) *key.Binding { return &k.Progress }},
+	{ActionRegress, "regress", func(k *keyMap) *key.Binding { return &k.Regress }},
+	{ActionPriorityUp, "raise priority", func(k *keyMap) *key.Binding { return &k.PriorityUp }},
+	{ActionPriorityDown, "lower priority", func(k *keyMap) *key.Binding { return &k.PriorityDown }},
+	{ActionSearch, "search", func(k *keyMap) *key.Binding { return &k.Search }},
+	{ActionNextMatch, "next match", func(k *keyMap) *key.Binding { return &k.NextMatch }},
+	{ActionPrevMatch, "previous match", func(k *keyMap) *key.Binding { return &k.PrevMatch }},
+	{ActionFilter, "filter by search", func(k *keyMap) *key.Binding { return &k.Filter }},
+	{ActionFilters, "pick filter", func(k *keyMap) *key.Binding { return &k.Filters }},
+	{ActionSort, "change sort", func(k *keyMap) *key.Binding { return &k.Sort }},
+	{ActionMoveUp, "move card up", func(k *keyMap) *key.Binding { return &k.MoveUp }},
+	{ActionMoveDown, "move card down", func(k *keyMap) *key.Binding { return &k.MoveDown }},
+	{ActionComplete, "toggle done", func(k *keyMap) *key.Binding { return &k.Complete }},
+	{ActionShowDone, "show/hide done", func(k *keyMap) *key.Binding { return &k.ShowDone }},
+	{ActionAllTodos, "all todos", func(k *keyMap) *key.Binding { return &k.AllTodos }},
+	{ActionGroup, "change grouping", func(k *keyMap) *key.Binding { return &k.Group }},
+	{ActionMove, "move to project", func(k *keyMap) *key.Binding { return &k.Move }},
+	{ActionInbox, "inbox", func(k *keyMap) *key.Binding { return &k.Inbox }},
+	{ActionNotes, "edit notes", func(k *keyMap) *key.Binding { return &k.Notes }},
+	{ActionNotesEditor, "edit notes in $EDITOR", func(k *keyMap) *key.Binding { return &k.NotesEditor }},
+	{ActionOpen, "open in $EDITOR", func(k *keyMap) *key.Binding { return &k.Open }},
+	{ActionShell, "shell in project", func(k *keyMap) *key.Binding { return &k.Shell }},
+	{ActionLaunch, "launch project", func(k *keyMap) *key.Binding { return &k.Launch }},
+	{ActionKeepMine, "keep mine", func(k *keyMap) *key.Binding { return &k.KeepMine }},
+	{ActionKeepTheirs, "keep theirs", func(k *keyMap) *key.Binding { return &k.KeepTheirs }},
+	{ActionMerge, "merge", func(k *keyMap) *key.Binding { return &k.Merge }},
+	{ActionToggleSide, "toggle field", func(k *keyMap) *key.Binding { return &k.ToggleSide }},
+}
+
+// conflictActions are only read by the conflict dialog, which takes all
+// keys, so they may reuse keys of the board and todo lists
+var conflictActions = map[Action]bool{
+	ActionKeepMine:   true,
+	ActionKeepTheirs: true,
+	ActionMerge:      true,
+	ActionToggleSide: true,
+}
+
+// conflictShared are the other actions the conflict dialog reads, whose keys
+// its own actions mustn't reuse
+var conflictShared = []Action{ActionUp, ActionDown, ActionLeft, ActionRight, ActionBack}
+
+// defaultKeys is the original layout, with movement on j/k/l/; one key to
+// the right of vim's h/j/k/l
+var defaultKeys = map[Action][]string{
+	ActionUp:           {"up", "l"},
+	ActionDown:         {"down", "k"},
+	ActionLeft:         {"left", "j"},
+	ActionRight:        {"right", ";"},
+	ActionTodos:        {"enter"},
+	ActionBack:         {"esc", "backspace"},
+	ActionQuit:         {"q", "ctrl+c"},
+	ActionRefresh:      {"R"},
+	ActionMessages:     {"M"},
+	ActionUndo:         {"u"},
+	ActionRedo:         {"ctrl+r"},
+	ActionAdd:          {"a"},
+	ActionEdit:         {"e"},
+	ActionDelete:       {"d", "x"},
+	ActionProgress:     {"p"},
+	ActionRegress:      {"r"},
+	ActionPriorityUp:   {"+", "="},
+	ActionPriorityDown: {"-", "_"},
+	ActionSearch:       {"/"},
+	ActionNextMatch:    {"n"},
+	ActionPrevMatch:    {"N"},
+	ActionFilter:       {"f"},
+	ActionFilters:      {"F"},
+	ActionSort:         {"s"},
+	ActionMoveUp:       {"L", "shift+up"},
+	ActionMoveDown:     {"K", "shift+down"},
+	ActionComplete:     {"c", " "},
+	ActionShowDone:     {"H"},
+	ActionAllTodos:     {"T"},
+	ActionGroup:        {"g"},
+	ActionMove:         {"m"},
+	ActionInbox:        {"i"},
+	ActionNotes:        {"o"},
+	ActionNotesEditor:  {"O"},
+	ActionOpen:         {"v"},
+	ActionShell:        {"!"},
+	ActionLaunch:       {"X"},
+	ActionKeepMine:     {"m"},
+	ActionKeepTheirs:   {"t"},
+	ActionMerge:        {"enter"},
+	ActionToggleSide:   {" "},
+}
+
+// keyPresets are the built-in keymaps selectable with keys.preset
+var keyPresets = map[string]map[Action][]string{
+	"default": defaultKeys,
+	"vim": withKeys(defaultKeys, map[Action][]string{
+		ActionUp:       {"up", "k"},
+		ActionDown:     {"down", "j"},
+		ActionLeft:     {"left", "h"},
+		ActionRight:    {"right", "l"},
+		ActionMoveUp:   {"K", "shift+up"},
+		ActionMoveDown: {"J", "shift+down"},
+	}),
+	"emacs": withKeys(defaultKeys, map[Action][]string{
+		ActionUp:       {"up", "ctrl+p"},
+		ActionDown:     {"down", "ctrl+n"},
+		ActionLeft:     {"left", "ctrl+b"},
+		ActionRight:    {"right", "ctrl+f"},
+		ActionBack:     {"esc", "ctrl+g"},
+		ActionUndo:     {"u", "ctrl+_"},
+		ActionMoveUp:   {"alt+p", "shift+up"},
+		ActionMoveDown: {"alt+n", "shift+down"},
+	}),
+	"arrows": withKeys(defaultKeys, map[Action][]string{
+		ActionUp:       {"up"},
+		ActionDown:     {"down"},
+		ActionLeft:     {"left"},
+		ActionRight:    {"right"},
+		ActionBack:     {"esc"},
+		ActionMoveUp:   {"shift+up"},
+		ActionMoveDown: {"shift+down"},
+	}),
+}
+
+// withKeys returns a copy of base with some actions rebound
+func withKeys(base, overrides map[Action][]string) map[Action][]string {
+	keys := make(map[Action][]string, len(base))
+	for action, k := range base {
+		keys[action] = k
+	}
+	for action, k := range overrides {
+		keys[action] = k
+	}
+	return keys
+}
+
+// newKeyMap builds the keymap from a preset and per-action overrides,
+// rejecting unknown presets and actions and keys bound to two actions
+func newKeyMap(cfg config.KeysConfig) (keyMap, error) {
+	preset := cfg.Preset
+	if preset == "" {
+		preset = "default"
+	}
+	base, ok := keyPresets[preset]
+	if !ok {
+		return keyMap{}, fmt.Errorf("unknown key preset %q (want %s)", preset, strings.Join(presetNames(), ", "))
+	}
+
+	bindings := withKeys(base, nil)
+	var problems []string
+	for name, k := range cfg.Bindings {
+		action := Action(name)
+		if _, ok := base[action]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown action %q", name))
+			continue
+		}
+		bindings[action] = normalizeKeys(k)
+	}
+
+	var km keyMap
+	owner := make(map[string]Action)
+	conflictOwner := make(map[string]Action)
+	for _, action := range conflictShared {
+		for _, s := range bindings[action] {
+			conflictOwner[s] = action
+		}
+	}
+	for _, def := range actions {
+		k := bindings[def.action]
+		owners := owner
+		if conflictActions[def.action] {
+			owners = conflictOwner
+		}
+		for _, s := range k {
+			if other, ok := owners[s]; ok {
+				problems = append(problems, fmt.Sprintf("key %q is bound to both %s and %s", s, other, def.action))
+				continue
+			}
+			owners[s] = def.action
+		}
+		*def.binding(&km) = key.NewBinding(
+			key.WithKeys(k...),
+			key.WithHelp(helpKeys(k), def.desc),
+		)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return keyMap{}, fmt.Errorf("invalid keybindings: %s", strings.Join(problems, "; "))
+	}
+	return km, nil
+}
+
+// presetNames returns the names of the built-in keymaps
+func presetNames() []string {
+	names := make([]string, 0, len(keyPresets))
+	for name := range keyPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keySymbols are the glyphs shown in help for the arrow keys, and the name
+// shown for the space bar
+var keySymbols = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+	" ":     "space",
+}
+
+// normalizeKeys accepts "space" in the config for the space bar, which
+// Bubble Tea reports as " "
+func normalizeKeys(keys []string) []string {
+	normalized := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "space" {
+			k = " "
+		}
+		normalized[i] = k
+	}
+	return normalized
+}
+
+// helpKeys formats keys for help text, e.g. "←/j"
+func helpKeys(keys []string) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		if symbol, ok := keySymbols[k]; ok {
+			k = symbol
+		}
+		parts[i] = k
+	}
+	return strings.Join(parts, "/")
+}
+
+// helpEntry formats one help item, e.g. "←/j →/; columns"
+func helpEntry(desc string, bindings ...key.Binding) string {
+	keys := make([]string, len(bindings))
+	for i, b := range bindings {
+		keys[i] = b.Help().Key
+	}
+	return strings.Join(keys, " ") + " " + desc
+}
+
+// helpLine joins help items into a single line
+func helpLine(entries ...string) string {
+	return strings.Join(entries, " • ")
+}
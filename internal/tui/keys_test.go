@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	for _, preset := range presetNames() {
+		if _, err := newKeyMap(config.KeysConfig{Preset: preset}); err != nil {
+			t.Errorf("preset %s: %v", preset, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		bindings map[string][]string
+		wantErr  string
+	}{
+		{"conflict dialog reuses board keys", map[string][]string{"keep_mine": {"a"}, "merge": {"e"}}, ""},
+		{"conflict dialog reuses its own keys", map[string][]string{"keep_mine": {"t"}}, `key "t" is bound to both keep_mine and keep_theirs`},
+		{"conflict dialog reuses navigation", map[string][]string{"toggle_side": {"down"}}, `key "down" is bound to both down and toggle_side`},
+		{"board reuses board keys", map[string][]string{"refresh": {"a"}}, `key "a" is bound to both refresh and add`},
+		{"unknown action", map[string][]string{"fly": {"z"}}, `unknown action "fly"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newKeyMap(config.KeysConfig{Bindings: tt.bindings})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("err = %v, want none", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
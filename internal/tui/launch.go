@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// launchKind is what the board opens for a project
+type launchKind int
+
+const (
+	launchEditor  launchKind = iota // The project's file, or its directory, in $EDITOR
+	launchShell                     // A subshell in the project's directory
+	launchCommand                   // The project's configured launch command
+)
+
+// String describes the launch for messages
+func (k launchKind) String() string {
+	switch k {
+	case launchShell:
+		return "shell"
+	case launchCommand:
+		return "launch command"
+	}
+	return "editor"
+}
+
+// launchProject suspends the TUI to open a project, returning to the board
+// when the editor, shell or command exits. Nothing is run without a
+// directory for the project, so it never runs in pj-tui's own.
+func (m Model) launchProject(project api.Project, kind launchKind) (tea.Model, tea.Cmd) {
+	dir, err := projectDir(project)
+	if err != nil {
+		return m, m.notifier.Error(fmt.Sprintf("Can't open %q", project.Name), err)
+	}
+	if dir == "" {
+		return m, m.notifier.Push(SeverityWarning, "%q has no path to open; set one, or an absolute file, with %s", project.Name, m.keys.Edit.Help().Key)
+	}
+
+	var cmd *exec.Cmd
+	switch kind {
+	case launchEditor:
+		target := expandHome(project.File)
+		switch {
+		case target == "":
+			target = dir
+		case !filepath.IsAbs(target):
+			target = filepath.Join(dir, target)
+		}
+		cmd = editorCommand(target)
+	case launchShell:
+		shell := os.Getenv("SHELL")
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		cmd = exec.Command(shell)
+	case launchCommand:
+		command := m.config.Launch.CommandFor(project.Name)
+		if command == "" {
+			return m, m.notifier.Push(SeverityWarning, "No launch command for %q; set one under [launch] in the config", project.Name)
+		}
+		cmd = exec.Command("/bin/sh", "-c", command)
+	}
+
+	// Commands run in the project, and can refer to it through the environment
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"PJ_PROJECT_ID="+strconv.Itoa(project.ID),
+		"PJ_PROJECT_NAME="+project.Name,
+		"PJ_PROJECT_PATH="+dir,
+		"PJ_PROJECT_FILE="+project.File,
+	)
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return projectLaunchedMsg{project: project, kind: kind, err: err}
+	})
+}
+
+// settleLaunch reports a failed launch once the TUI is back
+func (m Model) settleLaunch(msg projectLaunchedMsg) (tea.Model, tea.Cmd) {
+	var exitErr *exec.ExitError
+	switch {
+	case msg.err == nil:
+		return m, nil
+	case msg.kind == launchShell && errors.As(msg.err, &exitErr):
+		// The shell's exit status is that of the last command typed
+		return m, nil
+	}
+	return m, m.notifier.Error(fmt.Sprintf("The %s for %q failed", msg.kind, msg.project.Name), msg.err)
+}
+
+// projectDir returns the project's directory: its path, or the directory of
+// its file if that is absolute and there is no path. It is empty if there is
+// neither, and fails if the path doesn't lead to a directory.
+func projectDir(project api.Project) (string, error) {
+	dir := expandHome(strings.TrimSpace(project.Path))
+	if dir == "" {
+		file := expandHome(strings.TrimSpace(project.File))
+		if !filepath.IsAbs(file) {
+			return "", nil
+		}
+		dir = filepath.Dir(file)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+func launchProjectCmd(project api.Project, kind launchKind) tea.Cmd {
+	return func() tea.Msg {
+		return launchProjectMsg{project: project, kind: kind}
+	}
+}
+
+// launchProjectMsg asks to open a project from the board
+type launchProjectMsg struct {
+	project api.Project
+	kind    launchKind
+}
+
+// projectLaunchedMsg reports that the editor, shell or command for a
+// project exited
+type projectLaunchedMsg struct {
+	project api.Project
+	kind    launchKind
+	err     error
+}
@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+func TestProjectDir(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		project api.Project
+		want    string
+		wantErr bool
+	}{
+		{"path", api.Project{Path: root, File: "main.go"}, root, false},
+		{"path wins over an absolute file", api.Project{Path: root, File: "/elsewhere/main.go"}, root, false},
+		{"absolute file without a path", api.Project{File: file}, root, false},
+		{"relative file without a path", api.Project{File: "main.go"}, "", false},
+		{"neither", api.Project{}, "", false},
+		{"path is a file", api.Project{Path: file}, "", true},
+		{"missing path", api.Project{Path: filepath.Join(root, "gone")}, "", true},
+		{"file in a missing directory", api.Project{File: filepath.Join(root, "gone", "main.go")}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := projectDir(tt.project)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("dir = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sean-obeirne/projectarium-tui/internal/api"
 	"github.com/sean-obeirne/projectarium-tui/internal/config"
+	"github.com/sean-obeirne/projectarium-tui/internal/filter"
+	"github.com/sean-obeirne/projectarium-tui/internal/offline"
+	"github.com/sean-obeirne/projectarium-tui/internal/state"
 )
 
 // ViewMode represents the current view
@@ -17,99 +25,225 @@ const (
 	KanbanBoardView ViewMode = iota
 	LoadingView
 	ErrorView
+	AllTodosView
 )
 
 // Model is the main Bubble Tea model
 type Model struct {
-	apiClient         *api.Client
+	backend           api.Backend
 	config            *config.Config
 	viewMode          ViewMode
 	projects          []api.Project
 	kanbanBoard       *KanbanBoard
+	progressStale     bool // Todos changed in ways the progress on the cards doesn't follow
 	todoList          *TodoList
 	showTodoList      bool // Whether to show todo list overlay
+	inboxOpen         bool // Whether the todo list is the inbox rather than currentProject's
+	projectPicker     *ProjectPicker
+	showProjectPicker bool // Whether to show the picker moving a todo to another project
+	allTodos          *AllTodos
 	projectModal      *ProjectModal
 	showProjectModal  bool // Whether to show project creation modal
 	showDeleteConfirm bool // Whether to show delete confirmation
 	projectToDelete   *api.Project
+	authPrompt        *AuthPrompt
+	showAuthPrompt    bool // Whether to show the re-authentication prompt
+	filterPicker      *FilterPicker
+	showFilterPicker  bool           // Whether to show the filter picker
+	filter            *filter.Filter // Narrows the projects put on the board; nil shows all
+	sortMode          SortMode
+	hideDone          bool         // Whether todo lists hide completed todos
+	state             *state.State // Remembered between runs
+	stateErr          error        // Problem reading the state file, reported on startup
+	offlineErr        error        // Problem reading the offline cache, reported on startup
+	conflictDialog    *ConflictDialog
+	showConflict      bool // Whether an edit conflict is being resolved
 	currentProject    *api.Project
 	width             int
 	height            int
 	err               error
 	loading           bool
 	keys              keyMap
+	theme             Theme
+	ctx               context.Context // Cancelled when the program quits
+	cancel            context.CancelFunc
+	loads             *loadTracker
+	notifier          *Notifier
+	history           *History
 }
 
-type keyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Left    key.Binding
-	Right   key.Binding
-	Enter   key.Binding
-	Back    key.Binding
-	Quit    key.Binding
-	Refresh key.Binding
-}
-
-var keys = keyMap{
-	Up: key.NewBinding(
-		key.WithKeys("up", "l"),
-		key.WithHelp("↑/l", "move up"),
-	),
-	Down: key.NewBinding(
-		key.WithKeys("down", "k"),
-		key.WithHelp("↓/k", "move down"),
-	),
-	Left: key.NewBinding(
-		key.WithKeys("left", "j"),
-		key.WithHelp("←/j", "move left"),
-	),
-	Right: key.NewBinding(
-		key.WithKeys("right", ";"),
-		key.WithHelp("→/;", "move right"),
-	),
-	Enter: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "select"),
-	),
-	Back: key.NewBinding(
-		key.WithKeys("esc", "backspace"),
-		key.WithHelp("esc", "back"),
-	),
-	Quit: key.NewBinding(
-		key.WithKeys("q", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
-	Refresh: key.NewBinding(
-		key.WithKeys("R"),
-		key.WithHelp("R", "refresh"),
-	),
-}
-
-// NewModel creates a new TUI model
-func NewModel() Model {
-	cfg := config.Load()
-	client := api.NewClient(cfg.APIBaseURL)
+// errLoadCancelled is shown when the initial load is cancelled with esc
+var errLoadCancelled = errors.New("loading cancelled")
+
+// loadTracker tracks in-flight loads so they can be cancelled with esc, and
+// so that results of loads superseded by a newer one are discarded. It is
+// shared by pointer because Model is passed around by value.
+type loadTracker struct {
+	projectsGen    int
+	cancelProjects context.CancelFunc
+	todosGen       int
+	cancelTodos    context.CancelFunc
+}
 
-	return Model{
-		apiClient: client,
-		config:    cfg,
-		viewMode:  LoadingView,
-		keys:      keys,
-		loading:   true,
+// startProjects cancels any in-flight project load and starts a new one
+func (l *loadTracker) startProjects(parent context.Context) (context.Context, int) {
+	l.stopProjects()
+	ctx, cancel := context.WithCancel(parent)
+	l.cancelProjects = cancel
+	return ctx, l.projectsGen
+}
+
+// finishProjects releases the context of a completed project load
+func (l *loadTracker) finishProjects() {
+	if l.cancelProjects != nil {
+		l.cancelProjects()
+		l.cancelProjects = nil
+	}
+}
+
+// stopProjects cancels the in-flight project load, if any, and invalidates its result
+func (l *loadTracker) stopProjects() {
+	l.finishProjects()
+	l.projectsGen++
+}
+
+// startTodos cancels any in-flight todo load and starts a new one
+func (l *loadTracker) startTodos(parent context.Context) (context.Context, int) {
+	l.stopTodos()
+	ctx, cancel := context.WithCancel(parent)
+	l.cancelTodos = cancel
+	return ctx, l.todosGen
+}
+
+// finishTodos releases the context of a completed todo load
+func (l *loadTracker) finishTodos() {
+	if l.cancelTodos != nil {
+		l.cancelTodos()
+		l.cancelTodos = nil
 	}
 }
 
+// stopTodos cancels the in-flight todo load, if any, and invalidates its result
+func (l *loadTracker) stopTodos() {
+	l.finishTodos()
+	l.todosGen++
+}
+
+// NewModel creates a new TUI model backed by the configured backend: the
+// local data file, or the projectarium-v2 API, behind the offline cache if
+// it is enabled
+func NewModel(cfg *config.Config) (Model, error) {
+	backend, err := cfg.Backend()
+	if err != nil {
+		return Model{}, err
+	}
+	var offlineErr error
+	if cfg.DataFile == "" && cfg.Offline {
+		backend, offlineErr = offline.Open(backend, offline.DefaultPath(cfg.APIBaseURL), cfg.APIBaseURL)
+	}
+	m, err := NewModelWithBackend(backend, cfg)
+	m.offlineErr = offlineErr
+	return m, err
+}
+
+// NewModelWithBackend creates a new TUI model that uses the given backend
+// for all project and todo operations. It fails if the configured
+// keybindings, theme or startup filter are invalid.
+func NewModelWithBackend(backend api.Backend, cfg *config.Config) (Model, error) {
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		return Model{}, err
+	}
+	theme, err := newTheme(cfg.Theme)
+	if err != nil {
+		return Model{}, err
+	}
+	var startFilter *filter.Filter
+	if cfg.Defaults.Filter != "" {
+		if startFilter, err = filter.Resolve(cfg.Defaults.Filter, cfg.Filters, cfg.Workflow); err != nil {
+			return Model{}, err
+		}
+	}
+	sortMode, err := parseSortMode(cfg.Defaults.Sort)
+	if err != nil {
+		return Model{}, fmt.Errorf("defaults.sort: %w", err)
+	}
+
+	// The sort picked on the board last time beats the configured default
+	st, stateErr := state.Load(state.DefaultPath())
+	if mode, err := parseSortMode(st.Sort); err == nil && st.Sort != "" {
+		sortMode = mode
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return Model{
+		backend:  backend,
+		config:   cfg,
+		viewMode: LoadingView,
+		keys:     keys,
+		theme:    theme,
+		filter:   startFilter,
+		sortMode: sortMode,
+		hideDone: cfg.Defaults.HideCompleted,
+		state:    st,
+		stateErr: stateErr,
+		loading:  true,
+		ctx:      ctx,
+		cancel:   cancel,
+		loads:    &loadTracker{},
+		notifier: NewNotifier(theme, keys),
+		history:  NewHistory(),
+	}, nil
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return m.loadProjects
+	cmds := []tea.Cmd{m.loadProjects(), m.syncTick()}
+	if m.stateErr != nil {
+		cmds = append(cmds, m.notifier.Error("Ignored unreadable state file", m.stateErr))
+	}
+	if m.offlineErr != nil {
+		cmds = append(cmds, m.notifier.Error("Ignored unreadable offline cache", m.offlineErr))
+	}
+	// Ignored settings were printed before the board took over the screen
+	for _, w := range m.config.Warnings {
+		cmds = append(cmds, m.notifier.Push(SeverityWarning, "Ignored setting: %s", w))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles messages
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Rejected credentials settle like any failed request, rolling back
+	// optimistic changes, and then prompt for new ones
+	if err := resultErr(msg); errors.Is(err, api.ErrUnauthorized) {
+		if _, ok := m.backend.(api.Reauthenticator); ok {
+			next, cmd := m.update(msg)
+			next, promptCmd := next.(Model).promptReauth(err)
+			return next, tea.Batch(cmd, promptCmd)
+		}
+	}
+	return m.update(msg)
+}
+
+// update handles a message once Update has dealt with rejected credentials
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle key messages for modal/input routing
 	switch keyMsg := msg.(type) {
 	case tea.KeyMsg:
+		// If the auth prompt is showing, it takes all keys
+		if m.showAuthPrompt && m.authPrompt != nil {
+			var cmd tea.Cmd
+			*m.authPrompt, cmd = m.authPrompt.Update(msg)
+			return m, cmd
+		}
+
+		// If the message history is showing, it takes all keys
+		if m.notifier.ShowHistory {
+			m.notifier.Update(keyMsg)
+			return m, nil
+		}
+
 		// If delete confirmation is showing, handle y/n keys
 		if m.showDeleteConfirm {
 			switch keyMsg.String() {
@@ -124,7 +258,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showDeleteConfirm = false
 				m.projectToDelete = nil
 				return m, nil
-			case "n", "N", "esc", "q":
+			case "n", "N":
+				// Cancel deletion
+				m.showDeleteConfirm = false
+				m.projectToDelete = nil
+				return m, nil
+			}
+			if key.Matches(keyMsg, m.keys.Back, m.keys.Quit) {
 				// Cancel deletion
 				m.showDeleteConfirm = false
 				m.projectToDelete = nil
@@ -134,6 +274,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// If an edit conflict is being resolved, the dialog takes all keys
+		if m.showConflict && m.conflictDialog != nil {
+			var cmd tea.Cmd
+			*m.conflictDialog, cmd = m.conflictDialog.Update(msg)
+			return m, cmd
+		}
+
+		// If the filter picker is showing, it takes all keys
+		if m.showFilterPicker && m.filterPicker != nil {
+			var cmd tea.Cmd
+			*m.filterPicker, cmd = m.filterPicker.Update(msg)
+			return m, cmd
+		}
+
+		// If the move picker is showing, it takes all keys
+		if m.showProjectPicker && m.projectPicker != nil {
+			var cmd tea.Cmd
+			*m.projectPicker, cmd = m.projectPicker.Update(msg)
+			return m, cmd
+		}
+
 		// If project modal is showing, let it handle keys first (except for messages it generates)
 		if m.showProjectModal && m.projectModal != nil {
 			var cmd tea.Cmd
@@ -146,8 +307,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// If the board's search input is open, it takes all keys
+		if m.viewMode == KanbanBoardView && m.kanbanBoard != nil && m.kanbanBoard.Searching && !m.showTodoList {
+			var cmd tea.Cmd
+			*m.kanbanBoard, cmd = m.kanbanBoard.Update(msg)
+			return m, cmd
+		}
+
+		// If the todos view's search input is open, it takes all keys
+		if m.viewMode == AllTodosView && m.allTodos != nil && m.allTodos.Searching {
+			var cmd tea.Cmd
+			*m.allTodos, cmd = m.allTodos.Update(msg)
+			return m, cmd
+		}
+
 		// If todo list is showing and in input mode, let it handle keys first
-		if m.showTodoList && m.todoList != nil && (m.todoList.InputMode == AddingMode || m.todoList.InputMode == EditingMode) {
+		if m.showTodoList && m.todoList != nil && m.todoList.InputMode != NormalMode {
 			var cmd tea.Cmd
 			*m.todoList, cmd = m.todoList.Update(msg)
 			// The todo list returns commands that generate messages, let them flow through
@@ -171,32 +346,90 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// If todo list is showing, close it instead of quitting
 			if m.showTodoList {
-				m.showTodoList = false
-				m.currentProject = nil
-				m.todoList = nil
+				m.closeTodoList()
 				return m, nil
 			}
+			// Go back to the board from the todos view
+			if m.viewMode == AllTodosView {
+				return m.closeAllTodos()
+			}
+			// Abandon any in-flight requests before quitting
+			m.cancel()
 			return m, tea.Quit
-		case key.Matches(msg, m.keys.Refresh):
+		case key.Matches(msg, m.keys.Back):
+			// Cancel an in-flight project load, returning to the board if there is one
+			if m.loading {
+				m.loads.stopProjects()
+				m.loading = false
+				if m.kanbanBoard != nil {
+					m.viewMode = KanbanBoardView
+				} else {
+					m.err = errLoadCancelled
+					m.viewMode = ErrorView
+				}
+				return m, nil
+			}
+			// Cancel an in-flight todo load before the overlay opens
+			if m.loads.cancelTodos != nil {
+				m.loads.stopTodos()
+				m.currentProject = nil
+				m.inboxOpen = false
+				return m, nil
+			}
+			// Close the todo list overlay
+			if m.showTodoList {
+				m.closeTodoList()
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.Undo):
+			if m.viewMode == KanbanBoardView {
+				return m.undo()
+			}
+		case key.Matches(msg, m.keys.Redo):
 			if m.viewMode == KanbanBoardView {
+				return m.redo()
+			}
+		case key.Matches(msg, m.keys.Messages):
+			m.notifier.ToggleHistory()
+			return m, nil
+		case key.Matches(msg, m.keys.AllTodos):
+			switch {
+			case m.viewMode == AllTodosView:
+				return m.closeAllTodos()
+			case m.viewMode == KanbanBoardView && !m.showTodoList:
+				m.allTodos = NewAllTodos(m.projects, m.hideDone, m.theme, m.keys)
+				m.allTodos.SetSize(m.width, m.boardHeight())
+				m.viewMode = AllTodosView
+				return m, m.loadAllTodos(m.allTodos)
+			}
+		case key.Matches(msg, m.keys.Inbox):
+			if m.viewMode == KanbanBoardView && !m.showTodoList {
+				return m.openInbox()
+			}
+		case key.Matches(msg, m.keys.Refresh):
+			if m.viewMode == AllTodosView && m.allTodos != nil {
+				return m, m.loadAllTodos(m.allTodos)
+			}
+			if m.viewMode == KanbanBoardView || m.viewMode == ErrorView {
 				m.loading = true
 				m.viewMode = LoadingView
-				return m, m.loadProjects
+				// Todos may have changed elsewhere, too
+				m.progressStale = true
+				// Try to send changes made offline right away, too
+				return m, tea.Batch(m.loadProjects(), m.sync(false))
 			}
 		case key.Matches(msg, m.keys.Enter):
 			// Toggle todo list for selected project
 			if m.viewMode == KanbanBoardView && m.kanbanBoard != nil {
 				if m.showTodoList {
 					// Close todo list if already showing
-					m.showTodoList = false
-					m.currentProject = nil
-					m.todoList = nil
+					m.closeTodoList()
 					return m, nil
 				} else {
 					// Open todo list for selected project
 					if project := m.kanbanBoard.GetSelectedProject(); project != nil {
 						m.currentProject = project
-						return m, m.loadTodos
+						return m, m.loadTodos()
 					}
 				}
 			}
@@ -205,129 +438,340 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.notifier.SetSize(msg.Width, msg.Height)
 		if m.kanbanBoard != nil {
-			m.kanbanBoard.SetSize(msg.Width, msg.Height)
+			m.kanbanBoard.SetSize(msg.Width, m.boardHeight())
 		}
 		if m.todoList != nil {
 			m.todoList.SetSize(msg.Width, msg.Height)
 		}
+		if m.allTodos != nil {
+			m.allTodos.SetSize(msg.Width, m.boardHeight())
+		}
 		if m.projectModal != nil {
 			m.projectModal.SetSize(msg.Width, msg.Height)
 		}
+		if m.authPrompt != nil {
+			m.authPrompt.SetSize(msg.Width, msg.Height)
+		}
 
 	case projectsLoadedMsg:
+		if msg.gen != m.loads.projectsGen {
+			// Superseded by a newer refresh or cancelled
+			return m, nil
+		}
+		m.loads.finishProjects()
 		m.loading = false
-		m.projects = msg.projects
-		m.err = msg.err
-		if m.err != nil {
+		if msg.err != nil {
+			// Keep showing the board if a refresh fails; only a failed
+			// initial load has nothing to fall back to
+			if m.kanbanBoard != nil {
+				m.viewMode = KanbanBoardView
+				return m, m.notifier.Error("Failed to refresh projects", msg.err)
+			}
+			m.err = msg.err
 			m.viewMode = ErrorView
 			return m, nil
 		}
-
-		// Create kanban board with all projects
-		m.kanbanBoard = NewKanbanBoard(m.projects)
-		m.kanbanBoard.SetSize(m.width, m.height)
+		m.projects = msg.projects
+		m.err = nil
+
+		// Create kanban board with the projects passing the filter, keeping
+		// any search
+		previous := m.kanbanBoard
+		m.kanbanBoard = NewKanbanBoard(m.filter.Apply(m.projects), m.config.Workflow, m.theme, m.keys)
+		m.kanbanBoard.SetSize(m.width, m.boardHeight())
+		m.kanbanBoard.SetFilterLabel(m.filter.Label())
+		m.kanbanBoard.SetSort(m.sortMode, m.state.Order)
+		if msg.progress != nil {
+			m.kanbanBoard.SetProgress(msg.progress)
+			m.progressStale = false
+		} else if previous != nil {
+			m.kanbanBoard.SetProgress(previous.progress)
+		}
+		if previous != nil {
+			m.kanbanBoard.SetSearch(previous.Search())
+		}
 		m.viewMode = KanbanBoardView
 		return m, nil
 
 	case todosLoadedMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+		if msg.gen != m.loads.todosGen {
+			// Superseded by a newer load or cancelled
 			return m, nil
 		}
+		m.loads.finishTodos()
+		if msg.err != nil {
+			if !m.showTodoList {
+				m.currentProject = nil
+				m.inboxOpen = false
+			}
+			return m, m.notifier.Error("Failed to load todos", msg.err)
+		}
 
-		projectName := "Unknown Project"
-		projectID := 0
+		projectName := "Inbox"
+		var projectID *int
 		if m.currentProject != nil {
 			projectName = m.currentProject.Name
-			projectID = m.currentProject.ID
+			id := m.currentProject.ID
+			projectID = &id
 		}
 
-		m.todoList = NewTodoList(msg.todos, projectName, projectID)
+		m.todoList = NewTodoList(msg.todos, projectName, projectID, m.config.Defaults.TodoPriority, m.hideDone, m.theme, m.keys)
 		m.todoList.SetSize(m.width, m.height)
 		m.showTodoList = true
+		m.syncProgress()
+		return m, nil
+
+	case allTodosLoadedMsg:
+		if msg.view == nil || msg.view != m.allTodos {
+			// The todos view was closed
+			return m, nil
+		}
+		if msg.err != nil {
+			if !m.allTodos.loaded {
+				m.viewMode = KanbanBoardView
+				m.allTodos = nil
+			}
+			return m, m.notifier.Error("Failed to load todos", msg.err)
+		}
+		m.allTodos.SetTodos(msg.todos)
+		if m.kanbanBoard != nil {
+			m.kanbanBoard.SetProgress(countTodoProgress(msg.todos))
+			m.progressStale = false
+		}
 		return m, nil
 
+	case closeAllTodosMsg:
+		return m.closeAllTodos()
+
+	case jumpToProjectMsg:
+		return m.jumpToProject(msg.todo)
+
 	case progressProjectMsg:
-		return m, m.updateProjectStatus(msg.projectID, msg.status)
+		return m.applyProjectStatus(msg.projectID, msg.status)
 
 	case regressProjectMsg:
-		return m, m.updateProjectStatus(msg.projectID, msg.status)
+		return m.applyProjectStatus(msg.projectID, msg.status)
 
 	case updatePriorityMsg:
-		return m, m.updateProjectPriority(msg.projectID, msg.priority)
+		return m.applyProjectPriority(msg.projectID, msg.priority)
 
 	case projectStatusUpdatedMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
-			return m, nil
+		return m.settleProject(changeProjectStatus, msg.projectID, msg.project, msg.previous, msg.err, "Failed to update project status")
+
+	case projectPriorityUpdatedMsg:
+		return m.settleProject(changeProjectPriority, msg.projectID, msg.project, msg.previous, msg.err, "Failed to update project priority")
+
+	case createTodoMsg:
+		// Show the new todo right away; it is replaced by the saved one later
+		todo := api.Todo{Description: msg.description, Priority: msg.priority, ProjectID: msg.projectID}
+		tempID := 0
+		if m.todoList != nil && m.todoList.belongs(todo) {
+			tempID = m.todoList.AddPendingTodo(todo)
+			m.syncProgress()
 		}
-		// Update the project in the kanban board
-		if msg.project != nil && m.kanbanBoard != nil {
-			m.kanbanBoard.UpdateProjectInBoard(*msg.project)
+		return m, m.createTodo(m.todoList, tempID, msg.description, msg.priority, msg.projectID)
+
+	case updateTodoMsg:
+		if msg.id < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
 		}
-		return m, nil
+		// Apply the change right away, remembering the old todo for rollback
+		previous := api.Todo{ID: msg.id}
+		if m.todoList != nil {
+			if todo, ok := m.todoList.FindTodo(msg.id); ok {
+				previous = todo
+				todo.Description = msg.description
+				todo.Priority = msg.priority
+				todo.ProjectID = msg.projectID
+				m.todoList.ReplaceTodo(msg.id, todo)
+				m.todoList.SetPending(msg.id, true)
+			}
+		}
+		return m, m.updateTodo(m.todoList, previous, msg.description, msg.priority, msg.projectID)
 
-	case projectPriorityUpdatedMsg:
+	case completeTodoMsg:
+		if msg.id < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
+		}
+		// Tick the todo off right away, remembering the old todo for rollback
+		previous := api.Todo{ID: msg.id}
+		if m.todoList != nil {
+			if todo, ok := m.todoList.FindTodo(msg.id); ok {
+				previous = todo
+				todo.Completed = msg.completed
+				todo.CompletedAt = time.Time{}
+				if msg.completed {
+					todo.CompletedAt = time.Now()
+				}
+				m.todoList.ReplaceTodo(msg.id, todo)
+				m.todoList.SetPending(msg.id, true)
+				m.syncProgress()
+			}
+		}
+		return m, m.updateTodoCompleted(m.todoList, previous, msg.completed)
+
+	case updateTodoNotesMsg:
+		if msg.id < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
+		}
+		// Show the new notes right away, remembering the old todo for rollback
+		previous := api.Todo{ID: msg.id}
+		if m.todoList != nil {
+			if todo, ok := m.todoList.FindTodo(msg.id); ok {
+				previous = todo
+				todo.Notes = msg.notes
+				m.todoList.ReplaceTodo(msg.id, todo)
+				m.todoList.SetPending(msg.id, true)
+			}
+		}
+		return m, m.updateTodoNotes(m.todoList, previous, msg.notes)
+
+	case launchProjectMsg:
+		return m.launchProject(msg.project, msg.kind)
+
+	case projectLaunchedMsg:
+		return m.settleLaunch(msg)
+
+	case editNotesMsg:
+		if msg.todo.ID < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
+		}
+		todo := msg.todo
+		return m, editInEditor(todo.Notes, "pj-tui-notes-*.md", func(text string, err error) tea.Msg {
+			return notesEditedMsg{todo: todo, notes: text, err: err}
+		})
+
+	case notesEditedMsg:
 		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+			return m, m.notifier.Error("Failed to edit notes", msg.err)
+		}
+		// Editors end files with a newline the notes don't need
+		notes := strings.TrimRight(msg.notes, " \t\r\n")
+		if notes == msg.todo.Notes {
 			return m, nil
 		}
-		// Update the project in the kanban board by reloading
-		// (Priority changes may need reordering)
-		if msg.project != nil && m.kanbanBoard != nil {
-			m.kanbanBoard.UpdateProjectInBoard(*msg.project)
+		return m, updateTodoNotesCmd(msg.todo.ID, notes)
+
+	case openProjectPickerMsg:
+		if msg.todo.ID < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
 		}
+		m.projectPicker = NewProjectPicker(msg.todo, m.projects, m.theme)
+		m.showProjectPicker = true
+		return m, textinput.Blink
+
+	case cancelProjectPickerMsg:
+		m.showProjectPicker = false
+		m.projectPicker = nil
 		return m, nil
 
-	case createTodoMsg:
-		// User wants to create a new todo
-		return m, m.createTodo(msg.description, msg.priority, msg.projectID)
+	case moveTodoMsg:
+		m.showProjectPicker = false
+		m.projectPicker = nil
+		return m, updateTodoCmd(msg.todo.ID, msg.todo.Description, msg.todo.Priority, msg.projectID)
 
-	case updateTodoMsg:
-		// User wants to update a todo
-		return m, m.updateTodo(msg.id, msg.description, msg.priority, msg.projectID)
+	case syncTickMsg:
+		if cmd := m.sync(true); cmd != nil {
+			return m, cmd
+		}
+		return m, m.syncTick()
+
+	case syncedMsg:
+		return m.settleSync(msg)
+
+	case hideDoneMsg:
+		m.hideDone = msg.hide
+		return m, nil
 
 	case deleteTodoMsg:
-		// User wants to delete a todo
-		return m, m.deleteTodo(msg.id)
+		if msg.id < 0 {
+			return m, m.notifier.Push(SeverityWarning, "That todo is still being saved")
+		}
+		// Remove the todo right away, remembering where it was for rollback
+		removed := api.Todo{ID: msg.id}
+		index := 0
+		if m.todoList != nil {
+			if todo, i, ok := m.todoList.RemoveTodo(msg.id); ok {
+				removed, index = todo, i
+				m.syncProgress()
+			}
+		}
+		return m, m.deleteTodo(m.todoList, removed, index)
 
 	case todoCreatedMsg:
+		list := m.liveTodoList(msg.list)
+		if list != nil && msg.tempID != 0 {
+			list.SetPending(msg.tempID, false)
+			if msg.err != nil {
+				list.RemoveTodo(msg.tempID)
+			} else if msg.todo != nil {
+				list.ReplaceTodo(msg.tempID, *msg.todo)
+			}
+			m.syncProgress()
+		}
 		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
-			return m, nil
+			return m, m.notifier.Error("Failed to create todo", msg.err)
 		}
-		// Reload todos for the current project
-		if m.currentProject != nil {
-			return m, m.loadTodos
+		if msg.todo != nil {
+			m.history.Record(change{kind: changeTodoCreate, afterTodo: *msg.todo})
 		}
 		return m, nil
 
 	case todoUpdatedMsg:
+		list := m.liveTodoList(msg.list)
+		if list != nil {
+			list.SetPending(msg.previous.ID, false)
+		}
+		if errors.Is(msg.err, api.ErrNotFound) {
+			// The todo was deleted elsewhere
+			if list != nil {
+				list.RemoveTodo(msg.previous.ID)
+				m.syncProgress()
+			}
+			return m, m.notifier.Push(SeverityWarning, "Todo no longer exists and was removed")
+		}
 		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+			if list != nil {
+				list.ReplaceTodo(msg.previous.ID, msg.previous)
+				m.syncProgress()
+			}
+			if errors.Is(msg.err, api.ErrConflict) && msg.kind == changeTodoUpdate {
+				// Someone else changed the todo; merge with their copy
+				return m, m.loadTodoConflict(msg.list, msg.previous, msg.attempted)
+			}
+			return m, m.notifier.Error("Failed to update todo; change rolled back", msg.err)
+		}
+		if msg.todo == nil {
 			return m, nil
 		}
-		// Reload todos for the current project
-		if m.currentProject != nil {
-			return m, m.loadTodos
+		if list != nil {
+			list.ReplaceTodo(msg.previous.ID, *msg.todo)
+			m.syncProgress()
+		}
+		if msg.list != nil {
+			m.history.Record(change{kind: msg.kind, beforeTodo: msg.previous, afterTodo: *msg.todo})
+		}
+		if !sameProject(msg.previous.ProjectID, msg.todo.ProjectID) {
+			return m.settleMove(list, msg.previous, *msg.todo)
 		}
 		return m, nil
 
 	case todoDeletedMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+		if errors.Is(msg.err, api.ErrNotFound) {
+			// Already gone
 			return m, nil
 		}
-		// Reload todos for the current project
-		if m.currentProject != nil {
-			return m, m.loadTodos
+		if msg.err != nil {
+			if list := m.liveTodoList(msg.list); list != nil {
+				list.InsertTodo(msg.index, msg.todo)
+				m.syncProgress()
+			}
+			return m, m.notifier.Error("Failed to delete todo; todo restored", msg.err)
+		}
+		if msg.list != nil {
+			m.history.Record(change{kind: changeTodoDelete, beforeTodo: msg.todo})
 		}
 		return m, nil
 
@@ -336,8 +780,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.createProject(msg.name, msg.description, msg.path, msg.file, msg.language, msg.priority, msg.status)
 
 	case updateProjectMsg:
-		// User wants to update an existing project
-		return m, m.updateProject(msg.id, msg.name, msg.description, msg.path, msg.file, msg.language, msg.priority, msg.status)
+		// User wants to update an existing project; the edit is based on the
+		// project as the modal showed it, so a concurrent change conflicts
+		updated := msg.original
+		updated.Name, updated.Description, updated.Path, updated.File = msg.name, msg.description, msg.path, msg.file
+		updated.Language, updated.Priority, updated.Status = msg.language, msg.priority, msg.status
+		return m, m.updateProject(msg.original, updated)
 
 	case cancelProjectCreationMsg:
 		// User cancelled project creation
@@ -346,30 +794,114 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case projectCreatedMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+		if m.showFieldErrors(msg.err) {
 			return m, nil
 		}
+		if msg.err != nil {
+			return m, m.notifier.Error("Failed to create project", msg.err)
+		}
+		if msg.project != nil {
+			m.history.Record(change{kind: changeProjectCreate, afterProject: *msg.project})
+		}
 		// Close modal and reload projects
 		m.showProjectModal = false
 		m.projectModal = nil
 		m.loading = true
 		m.viewMode = LoadingView
-		return m, m.loadProjects
+		return m, m.loadProjects()
 
 	case projectUpdatedMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
+		if m.showFieldErrors(msg.err) {
 			return m, nil
 		}
+		if cmd, ok := m.dropIfDeleted(msg.projectID, msg.err); ok {
+			m.showProjectModal = false
+			m.projectModal = nil
+			return m, cmd
+		}
+		if errors.Is(msg.err, api.ErrConflict) {
+			// Someone else changed the project; merge with their copy
+			m.showProjectModal = false
+			m.projectModal = nil
+			return m, m.loadProjectConflict(msg.previous, msg.attempted)
+		}
+		if msg.err != nil {
+			return m, m.notifier.Error("Failed to update project", msg.err)
+		}
+		if msg.project != nil && msg.previous.ID != 0 {
+			m.history.Record(change{kind: changeProjectEdit, beforeProject: msg.previous, afterProject: *msg.project})
+		}
 		// Close modal and reload projects
 		m.showProjectModal = false
 		m.projectModal = nil
 		m.loading = true
 		m.viewMode = LoadingView
-		return m, m.loadProjects
+		return m, m.loadProjects()
+
+	case projectConflictMsg:
+		if cmd, ok := m.dropIfDeleted(msg.base.ID, msg.err); ok {
+			return m, cmd
+		}
+		if msg.err != nil {
+			return m, m.notifier.Error(fmt.Sprintf("Edit of %q conflicted and the server's copy failed to load", msg.mine.Name), msg.err)
+		}
+		m.conflictDialog = NewProjectConflict(msg.base, msg.mine, *msg.theirs, m.theme, m.keys)
+		if m.conflictDialog == nil {
+			// The server already has what the edit asked for
+			return m.Update(resolveProjectConflictMsg{theirs: *msg.theirs, merged: *msg.theirs})
+		}
+		m.showConflict = true
+		return m, nil
+
+	case resolveProjectConflictMsg:
+		m.showConflict = false
+		m.conflictDialog = nil
+		if msg.merged == msg.theirs {
+			if m.kanbanBoard != nil {
+				m.kanbanBoard.SyncProject(msg.theirs)
+			}
+			return m, nil
+		}
+		return m, m.updateProject(msg.theirs, msg.merged)
+
+	case todoConflictMsg:
+		if errors.Is(msg.err, api.ErrNotFound) {
+			if list := m.liveTodoList(msg.list); list != nil {
+				list.RemoveTodo(msg.base.ID)
+				m.syncProgress()
+			}
+			return m, m.notifier.Push(SeverityWarning, "Todo no longer exists and was removed")
+		}
+		if msg.err != nil {
+			return m, m.notifier.Error(fmt.Sprintf("Edit of todo %q conflicted and the server's copy failed to load", msg.mine.Description), msg.err)
+		}
+		names := make(map[int]string, len(m.projects))
+		for _, p := range m.projects {
+			names[p.ID] = p.Name
+		}
+		m.conflictDialog = NewTodoConflict(msg.list, msg.base, msg.mine, *msg.theirs, names, m.theme, m.keys)
+		if m.conflictDialog == nil {
+			return m.Update(resolveTodoConflictMsg{list: msg.list, theirs: *msg.theirs, merged: *msg.theirs})
+		}
+		m.showConflict = true
+		return m, nil
+
+	case resolveTodoConflictMsg:
+		m.showConflict = false
+		m.conflictDialog = nil
+		list := m.liveTodoList(msg.list)
+		if msg.merged == msg.theirs {
+			if list != nil && list.ReplaceTodo(msg.theirs.ID, msg.theirs) {
+				m.syncProgress()
+			}
+			return m, nil
+		}
+		// Show the merge right away, like any other edit
+		if list != nil {
+			list.ReplaceTodo(msg.theirs.ID, msg.merged)
+			list.SetPending(msg.theirs.ID, true)
+		}
+		return m, m.updateTodo(list, msg.theirs, msg.merged.Description, msg.merged.Priority, msg.merged.ProjectID)
 
 	case deleteProjectMsg:
 		// User wants to delete a project - show confirmation first
@@ -383,26 +915,89 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case projectDeletedMsg:
+		if cmd, ok := m.dropIfDeleted(msg.projectID, msg.err); ok {
+			return m, cmd
+		}
 		if msg.err != nil {
-			m.err = msg.err
-			m.viewMode = ErrorView
-			return m, nil
+			return m, m.notifier.Error("Failed to delete project", msg.err)
 		}
 		// Reload projects after deletion
 		m.loading = true
 		m.viewMode = LoadingView
-		return m, m.loadProjects
+		return m, m.loadProjects()
+
+	case historyAppliedMsg:
+		return m.settleHistory(msg)
+
+	case dismissNotificationMsg:
+		m.notifier.Dismiss(msg.id)
+		return m, nil
+
+	case submitAuthMsg:
+		// Retry with the new credentials
+		if reauth, ok := m.backend.(api.Reauthenticator); ok {
+			reauth.SetSecret(msg.secret)
+		}
+		m.showAuthPrompt = false
+		m.authPrompt = nil
+		m.closeTodoList()
+		m.loading = true
+		m.viewMode = LoadingView
+		return m, m.loadProjects()
+
+	case cancelAuthMsg:
+		m.showAuthPrompt = false
+		m.authPrompt = nil
+		if m.kanbanBoard == nil {
+			m.viewMode = ErrorView
+		}
+		return m, nil
 
 	case openProjectModalMsg:
 		// Open the project creation modal
-		m.projectModal = NewProjectModal()
+		m.projectModal = NewProjectModal(m.config.Workflow, m.config.Defaults, m.theme, m.keys)
 		m.projectModal.SetSize(m.width, m.height)
 		m.showProjectModal = true
 		return m, nil
 
+	case boardOrderChangedMsg:
+		if m.kanbanBoard == nil {
+			return m, nil
+		}
+		m.sortMode = m.kanbanBoard.SortMode()
+		m.state.Sort = string(m.sortMode)
+		m.state.Order = m.kanbanBoard.Order()
+		return m, m.saveState()
+
+	case stateSavedMsg:
+		if msg.err != nil {
+			return m, m.notifier.Error("Failed to save the board order", msg.err)
+		}
+		return m, nil
+
+	case openFilterPickerMsg:
+		m.filterPicker = NewFilterPicker(m.config, m.filter, m.theme, m.keys)
+		m.showFilterPicker = true
+		return m, nil
+
+	case cancelFilterPickerMsg:
+		m.showFilterPicker = false
+		m.filterPicker = nil
+		return m, nil
+
+	case applyFilterMsg:
+		// Rebuild the board from fresh projects so changes made while other
+		// projects were filtered out are picked up
+		m.filter = msg.filter
+		m.showFilterPicker = false
+		m.filterPicker = nil
+		m.loading = true
+		m.viewMode = LoadingView
+		return m, m.loadProjects()
+
 	case openEditProjectModalMsg:
 		// Open the project edit modal
-		m.projectModal = NewProjectModalForEdit(msg.project)
+		m.projectModal = NewProjectModalForEdit(msg.project, m.config.Workflow, m.theme, m.keys)
 		m.projectModal.SetSize(m.width, m.height)
 		m.showProjectModal = true
 		return m, nil
@@ -418,6 +1013,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.viewMode == AllTodosView && m.allTodos != nil {
+		*m.allTodos, cmd = m.allTodos.Update(msg)
+		return m, cmd
+	}
+
 	// If todo list is showing, let it handle updates
 	if m.showTodoList && m.todoList != nil {
 		*m.todoList, cmd = m.todoList.Update(msg)
@@ -427,8 +1027,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-// View renders the UI
+// View renders the UI with the notification status line below it
 func (m Model) View() string {
+	status := m.notifier.StatusLine()
+	if indicator := m.syncIndicator(); indicator != "" {
+		status = lipgloss.JoinHorizontal(lipgloss.Top, indicator, status)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.mainView(), status)
+}
+
+// boardHeight is the height available above the notification status line
+func (m Model) boardHeight() int {
+	return max(m.height-1, 0)
+}
+
+// mainView renders the current view or overlay
+func (m Model) mainView() string {
+	if m.showAuthPrompt && m.authPrompt != nil {
+		promptStyle := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(m.theme.Warning).
+			Padding(1, 2)
+
+		return lipgloss.Place(
+			m.width,
+			m.boardHeight(),
+			lipgloss.Center,
+			lipgloss.Center,
+			promptStyle.Render(m.authPrompt.View()),
+		)
+	}
+
+	if m.notifier.ShowHistory {
+		historyStyle := lipgloss.NewStyle().
+			Width(min(100, max(m.width-4, 20))).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(m.theme.Accent).
+			Padding(1, 2)
+
+		return lipgloss.Place(
+			m.width,
+			m.boardHeight(),
+			lipgloss.Center,
+			lipgloss.Center,
+			historyStyle.Render(m.notifier.HistoryView()),
+		)
+	}
+
+	if m.showConflict && m.conflictDialog != nil {
+		conflictStyle := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(m.theme.Warning).
+			Padding(1, 2)
+
+		return lipgloss.Place(
+			m.width,
+			m.boardHeight(),
+			lipgloss.Center,
+			lipgloss.Center,
+			conflictStyle.Render(m.conflictDialog.View()),
+		)
+	}
+
 	if m.loading {
 		return m.loadingView()
 	}
@@ -442,17 +1102,17 @@ func (m Model) View() string {
 			confirmStyle := lipgloss.NewStyle().
 				Width(50).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("196")).
+				BorderForeground(m.theme.Error).
 				Padding(1, 2).
 				Align(lipgloss.Center)
 
 			titleStyle := lipgloss.NewStyle().
 				Bold(true).
-				Foreground(lipgloss.Color("196")).
+				Foreground(m.theme.Error).
 				MarginBottom(1)
 
 			helpStyle := lipgloss.NewStyle().
-				Foreground(lipgloss.Color("241")).
+				Foreground(m.theme.Muted).
 				MarginTop(1)
 
 			title := titleStyle.Render("⚠️  Delete Project?")
@@ -472,13 +1132,29 @@ func (m Model) View() string {
 
 			return lipgloss.Place(
 				m.width,
-				m.height,
+				m.boardHeight(),
 				lipgloss.Center,
 				lipgloss.Center,
 				confirmView,
 			)
 		}
 
+		// Overlay filter picker if showing
+		if m.showFilterPicker && m.filterPicker != nil {
+			pickerStyle := lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(m.theme.Accent).
+				Padding(1, 2)
+
+			return lipgloss.Place(
+				m.width,
+				m.boardHeight(),
+				lipgloss.Center,
+				lipgloss.Center,
+				pickerStyle.Render(m.filterPicker.View()),
+			)
+		}
+
 		// Overlay project modal if showing
 		if m.showProjectModal && m.projectModal != nil {
 			modalWidth := 80
@@ -495,7 +1171,7 @@ func (m Model) View() string {
 				Width(modalWidth).
 				Height(modalHeight).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("63")).
+				BorderForeground(m.theme.Accent).
 				Padding(1, 2)
 
 			modalView := modalStyle.Render(m.projectModal.View())
@@ -503,52 +1179,80 @@ func (m Model) View() string {
 			// Center the modal
 			return lipgloss.Place(
 				m.width,
-				m.height,
+				m.boardHeight(),
 				lipgloss.Center,
 				lipgloss.Center,
 				modalView,
 			)
 		}
 
+		// Overlay the picker moving a todo to another project
+		if m.showProjectPicker && m.projectPicker != nil {
+			pickerStyle := lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(m.theme.Accent).
+				Padding(1, 2)
+
+			return lipgloss.Place(
+				m.width,
+				m.boardHeight(),
+				lipgloss.Center,
+				lipgloss.Center,
+				pickerStyle.Render(m.projectPicker.View()),
+			)
+		}
+
 		// Overlay todo list if showing
-		if m.showTodoList && m.todoList != nil && m.currentProject != nil {
-			// Calculate dimensions
-			projectCardWidth := 40
+		if m.showTodoList && m.todoList != nil && (m.currentProject != nil || m.inboxOpen) {
+			// Calculate dimensions; the side column takes what the list
+			// leaves, within limits
 			todoListWidth := int(float64(m.width) * 0.5)
 			if todoListWidth < 50 {
 				todoListWidth = 50
 			}
+			sideWidth := max(40, min(m.width-todoListWidth-6, 64))
 
 			modalHeight := int(float64(m.height) * 0.7)
 			if modalHeight < 20 {
 				modalHeight = 20
 			}
 
-			// Render the project card
-			projectCard := m.kanbanBoard.RenderProjectCard(m.currentProject, projectCardWidth)
-
 			// Style the todo list
 			todoStyle := lipgloss.NewStyle().
 				Width(todoListWidth).
 				Height(modalHeight).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("63")).
+				BorderForeground(m.theme.Accent).
 				Padding(1, 2)
 
 			todoView := todoStyle.Render(m.todoList.View())
 
-			// Join project card and todo list horizontally
-			combined := lipgloss.JoinHorizontal(
-				lipgloss.Top,
-				projectCard,
-				"  ", // spacing
-				todoView,
-			)
+			// Stack the project card above the selected todo's notes, and
+			// put them beside the todo list; the inbox has no project to show
+			sideHeight := modalHeight + 2 // the list's border
+			var side []string
+			if m.currentProject != nil {
+				projectCard := m.kanbanBoard.RenderProjectCard(m.currentProject, sideWidth)
+				side = append(side, projectCard)
+				sideHeight -= lipgloss.Height(projectCard)
+			}
+			if notes := m.todoList.NotesView(sideWidth, sideHeight); notes != "" {
+				side = append(side, notes)
+			}
+			combined := todoView
+			if len(side) > 0 {
+				combined = lipgloss.JoinHorizontal(
+					lipgloss.Top,
+					lipgloss.JoinVertical(lipgloss.Left, side...),
+					"  ", // spacing
+					todoView,
+				)
+			}
 
 			// Center the combined view
 			return lipgloss.Place(
 				m.width,
-				m.height,
+				m.boardHeight(),
 				lipgloss.Center,
 				lipgloss.Center,
 				combined,
@@ -557,6 +1261,11 @@ func (m Model) View() string {
 		return board
 	case ErrorView:
 		return m.errorView()
+	case AllTodosView:
+		if m.allTodos != nil {
+			return m.allTodos.View()
+		}
+		return m.kanbanBoardView()
 	default:
 		return m.loadingView()
 	}
@@ -571,7 +1280,7 @@ func (m Model) kanbanBoardView() string {
 
 func (m Model) loadingView() string {
 	style := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("63")).
+		Foreground(m.theme.Accent).
 		MarginTop(2).
 		MarginLeft(2)
 	return style.Render("Loading projects...")
@@ -579,29 +1288,252 @@ func (m Model) loadingView() string {
 
 func (m Model) errorView() string {
 	errorStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("196")).
+		Foreground(m.theme.Error).
 		MarginTop(2).
 		MarginLeft(2)
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(m.theme.Muted).
 		MarginTop(1).
 		MarginLeft(2)
 
 	errMsg := errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
-	help := helpStyle.Render("\nPress 'R' to retry, 'q' to quit")
+	help := helpStyle.Render(fmt.Sprintf("\nPress '%s' to retry, '%s' to quit",
+		m.keys.Refresh.Help().Key, m.keys.Quit.Help().Key))
 	return errMsg + help
 }
 
+// promptReauth opens the credentials prompt after the API rejected a request
+func (m Model) promptReauth(err error) (tea.Model, tea.Cmd) {
+	m.loads.stopProjects()
+	m.loads.stopTodos()
+	m.loading = false
+	m.err = err
+	m.authPrompt = NewAuthPrompt(err, m.theme)
+	m.authPrompt.SetSize(m.width, m.height)
+	m.showAuthPrompt = true
+	return m, textinput.Blink
+}
+
+// resultErr returns the error carried by an API result message, if any
+func resultErr(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case projectsLoadedMsg:
+		return msg.err
+	case todosLoadedMsg:
+		return msg.err
+	case allTodosLoadedMsg:
+		return msg.err
+	case projectStatusUpdatedMsg:
+		return msg.err
+	case projectPriorityUpdatedMsg:
+		return msg.err
+	case todoCreatedMsg:
+		return msg.err
+	case todoUpdatedMsg:
+		return msg.err
+	case todoDeletedMsg:
+		return msg.err
+	case projectCreatedMsg:
+		return msg.err
+	case projectUpdatedMsg:
+		return msg.err
+	case projectDeletedMsg:
+		return msg.err
+	case historyAppliedMsg:
+		return msg.err
+	case projectConflictMsg:
+		return msg.err
+	case todoConflictMsg:
+		return msg.err
+	}
+	return nil
+}
+
+// applyProjectStatus moves a project to a new column right away and sends the
+// change to the API; settleProject rolls it back if the request fails
+func (m Model) applyProjectStatus(projectID int, status string) (tea.Model, tea.Cmd) {
+	var previous api.Project
+	if m.kanbanBoard != nil {
+		if project, ok := m.kanbanBoard.FindProject(projectID); ok {
+			previous = project
+			project.Status = status
+			m.kanbanBoard.UpdateProjectInBoard(project)
+			m.kanbanBoard.BeginPending(projectID)
+		}
+	}
+	return m, m.updateProjectStatus(projectID, status, previous)
+}
+
+// applyProjectPriority changes a project's priority right away and sends the
+// change to the API; settleProject rolls it back if the request fails
+func (m Model) applyProjectPriority(projectID int, priority int) (tea.Model, tea.Cmd) {
+	var previous api.Project
+	if m.kanbanBoard != nil {
+		if project, ok := m.kanbanBoard.FindProject(projectID); ok {
+			previous = project
+			project.Priority = priority
+			m.kanbanBoard.UpdateProjectInBoard(project)
+			m.kanbanBoard.BeginPending(projectID)
+		}
+	}
+	return m, m.updateProjectPriority(projectID, priority, previous)
+}
+
+// settleProject applies the result of an optimistic project update: the
+// server's copy on success, or the previous state on failure
+func (m Model) settleProject(kind changeKind, projectID int, project *api.Project, previous api.Project, err error, action string) (tea.Model, tea.Cmd) {
+	if m.kanbanBoard == nil {
+		return m, nil
+	}
+	settled := m.kanbanBoard.EndPending(projectID)
+	if cmd, ok := m.dropIfDeleted(projectID, err); ok {
+		return m, cmd
+	}
+	if err != nil {
+		if previous.ID != 0 {
+			m.kanbanBoard.SyncProject(previous)
+		}
+		return m, m.notifier.Error(action+"; change rolled back", err)
+	}
+	if project != nil && previous.ID != 0 {
+		m.history.Record(change{kind: kind, beforeProject: previous, afterProject: *project})
+	}
+	// Only the last of several overlapping updates reflects the final state
+	if settled && project != nil {
+		m.kanbanBoard.SyncProject(*project)
+	}
+	return m, nil
+}
+
+// closeAllTodos goes back from the todos view to the board
+func (m Model) closeAllTodos() (tea.Model, tea.Cmd) {
+	m.viewMode = KanbanBoardView
+	m.allTodos = nil
+	return m, nil
+}
+
+// openInbox opens the todo list of the todos without a project
+func (m Model) openInbox() (tea.Model, tea.Cmd) {
+	m.currentProject = nil
+	m.inboxOpen = true
+	return m, m.loadTodos()
+}
+
+// closeTodoList closes the todo list overlay, of a project or the inbox
+func (m *Model) closeTodoList() {
+	m.showTodoList = false
+	m.currentProject = nil
+	m.inboxOpen = false
+	m.todoList = nil
+}
+
+// settleMove takes a todo moved to another project out of the open list and
+// carries its progress over to the card of the project it moved to
+func (m Model) settleMove(list *TodoList, previous, todo api.Todo) (tea.Model, tea.Cmd) {
+	removed := false
+	if list != nil && list.belongs(previous) && !list.belongs(todo) {
+		list.RemoveTodo(todo.ID)
+		m.syncProgress()
+		removed = true
+	}
+	destination := "the inbox"
+	if todo.ProjectID != nil {
+		destination = fmt.Sprintf("project #%d", *todo.ProjectID)
+		for _, p := range m.projects {
+			if p.ID == *todo.ProjectID {
+				destination = fmt.Sprintf("%q", p.Name)
+			}
+		}
+	}
+	if m.kanbanBoard != nil {
+		if !removed {
+			m.kanbanBoard.shiftProgress(previous, -1)
+		}
+		m.kanbanBoard.shiftProgress(todo, 1)
+	}
+	return m, m.notifier.Push(SeveritySuccess, "Moved %q to %s", todo.Description, destination)
+}
+
+// jumpToProject leaves the todos view for the card of the project a todo
+// belongs to, clearing a search on the board that hides it
+func (m Model) jumpToProject(todo api.Todo) (tea.Model, tea.Cmd) {
+	if todo.ProjectID == nil {
+		// Todos without a project live in the inbox
+		m.viewMode = KanbanBoardView
+		m.allTodos = nil
+		return m.openInbox()
+	}
+	if m.kanbanBoard == nil {
+		return m, nil
+	}
+	id := *todo.ProjectID
+	if !m.kanbanBoard.SelectProject(id) {
+		if query, _ := m.kanbanBoard.Search(); query != "" {
+			m.kanbanBoard.clearSearch()
+		}
+		if !m.kanbanBoard.SelectProject(id) {
+			if m.filter != nil {
+				return m, m.notifier.Push(SeverityWarning, "The project is hidden by the filter %q", m.filter.Label())
+			}
+			return m, m.notifier.Push(SeverityWarning, "The project is no longer on the board")
+		}
+	}
+	return m.closeAllTodos()
+}
+
+// syncProgress shows the open todo list's progress on its project's card
+func (m Model) syncProgress() {
+	if m.kanbanBoard == nil || m.todoList == nil || m.todoList.projectID == nil {
+		return
+	}
+	done, total := m.todoList.Progress()
+	m.kanbanBoard.SetProjectProgress(*m.todoList.projectID, done, total)
+}
+
+// liveTodoList returns list if it is still the open todo list, so results
+// for a list that has since been closed are ignored
+func (m Model) liveTodoList(list *TodoList) *TodoList {
+	if list != nil && list == m.todoList {
+		return list
+	}
+	return nil
+}
+
+// dropIfDeleted removes a project from the board when the backend reports it
+// no longer exists, returning true if err was handled that way
+func (m *Model) dropIfDeleted(projectID int, err error) (tea.Cmd, bool) {
+	if !errors.Is(err, api.ErrNotFound) {
+		return nil, false
+	}
+	if m.kanbanBoard != nil {
+		m.kanbanBoard.RemoveProject(projectID)
+	}
+	return m.notifier.Push(SeverityWarning, "Project no longer exists and was removed from the board"), true
+}
+
+// showFieldErrors shows validation errors in the open project modal,
+// returning true if err was handled that way
+func (m *Model) showFieldErrors(err error) bool {
+	if !errors.Is(err, api.ErrValidation) || m.projectModal == nil {
+		return false
+	}
+	m.projectModal.SetValidationError(err)
+	return true
+}
+
 // Messages
 
 type projectsLoadedMsg struct {
 	projects []api.Project
+	progress map[int]todoProgress // nil if not counted or the todos couldn't be loaded
 	err      error
+	gen      int
 }
 
 type todosLoadedMsg struct {
 	todos []api.Todo
 	err   error
+	gen   int
 }
 
 type progressProjectMsg struct {
@@ -620,27 +1552,47 @@ type updatePriorityMsg struct {
 }
 
 type projectStatusUpdatedMsg struct {
-	project *api.Project
-	err     error
+	projectID int
+	project   *api.Project
+	previous  api.Project // Board state before the optimistic update
+	err       error
 }
 
 type projectPriorityUpdatedMsg struct {
-	project *api.Project
-	err     error
+	projectID int
+	project   *api.Project
+	previous  api.Project // Board state before the optimistic update
+	err       error
 }
 
 type todoCreatedMsg struct {
-	todo *api.Todo
-	err  error
+	list   *TodoList // List showing the placeholder todo
+	tempID int       // Placeholder ID to replace with the saved todo
+	todo   *api.Todo
+	err    error
 }
 
 type todoUpdatedMsg struct {
-	todo *api.Todo
-	err  error
+	kind      changeKind // changeTodoUpdate, changeTodoComplete or changeTodoNotes, for the history
+	list      *TodoList
+	previous  api.Todo // Todo before the optimistic update
+	attempted api.Todo // Todo as an edit would have left it, for conflicts
+	todo      *api.Todo
+	err       error
+}
+
+// notesEditedMsg carries the notes saved in $EDITOR for a todo
+type notesEditedMsg struct {
+	todo  api.Todo // Todo as it was when the editor opened
+	notes string
+	err   error
 }
 
 type todoDeletedMsg struct {
-	err error
+	list  *TodoList
+	todo  api.Todo // Removed todo, restored at index if the delete fails
+	index int
+	err   error
 }
 
 type projectCreatedMsg struct {
@@ -649,12 +1601,16 @@ type projectCreatedMsg struct {
 }
 
 type projectUpdatedMsg struct {
-	project *api.Project
-	err     error
+	projectID int
+	project   *api.Project
+	previous  api.Project // Project before the edit, for undo
+	attempted api.Project // Project as the edit would have left it
+	err       error
 }
 
 type projectDeletedMsg struct {
-	err error
+	projectID int
+	err       error
 }
 
 type deleteProjectMsg struct {
@@ -663,77 +1619,164 @@ type deleteProjectMsg struct {
 
 type openProjectModalMsg struct{}
 
+type stateSavedMsg struct {
+	err error
+}
+
 type openEditProjectModalMsg struct {
 	project *api.Project
 }
 
 // Commands
 
-func (m Model) loadProjects() tea.Msg {
-	projects, err := m.apiClient.GetProjects()
-	return projectsLoadedMsg{projects: projects, err: err}
+// loadProjects fetches the projects for the board, along with every todo to
+// count the progress on the cards if the counts aren't known or are stale.
+// Changes made in todo lists keep the counts up to date meanwhile.
+func (m Model) loadProjects() tea.Cmd {
+	ctx, gen := m.loads.startProjects(m.ctx)
+	counts := m.progressStale || m.kanbanBoard == nil || m.kanbanBoard.progress == nil
+	return func() tea.Msg {
+		projects, err := m.backend.GetProjects(ctx)
+		if err != nil {
+			return projectsLoadedMsg{err: err, gen: gen}
+		}
+		if !counts {
+			return projectsLoadedMsg{projects: projects, gen: gen}
+		}
+		// Todo progress on the cards is optional; the board loads without it
+		var progress map[int]todoProgress
+		if todos, err := m.backend.GetTodos(ctx); err == nil {
+			progress = countTodoProgress(todos)
+		}
+		return projectsLoadedMsg{projects: projects, progress: progress, gen: gen}
+	}
 }
 
-func (m Model) loadTodos() tea.Msg {
-	if m.currentProject == nil {
-		return todosLoadedMsg{todos: []api.Todo{}, err: nil}
+// saveState writes a snapshot of the state file in the background
+func (m Model) saveState() tea.Cmd {
+	snapshot := m.state.Snapshot()
+	return func() tea.Msg {
+		return stateSavedMsg{err: snapshot.Save()}
 	}
-	todos, err := m.apiClient.GetTodosByProject(m.currentProject.ID)
-	return todosLoadedMsg{todos: todos, err: err}
 }
 
-func (m Model) updateProjectStatus(projectID int, status string) tea.Cmd {
+// loadAllTodos fetches every todo for the todos view
+func (m Model) loadAllTodos(view *AllTodos) tea.Cmd {
 	return func() tea.Msg {
-		project, err := m.apiClient.UpdateProjectStatus(projectID, status)
-		return projectStatusUpdatedMsg{project: project, err: err}
+		todos, err := m.backend.GetTodos(m.ctx)
+		return allTodosLoadedMsg{view: view, todos: todos, err: err}
 	}
 }
 
-func (m Model) updateProjectPriority(projectID int, priority int) tea.Cmd {
+func (m Model) loadTodos() tea.Cmd {
+	ctx, gen := m.loads.startTodos(m.ctx)
+	project := m.currentProject
+	inbox := m.inboxOpen
 	return func() tea.Msg {
-		project, err := m.apiClient.UpdateProjectPriority(projectID, priority)
-		return projectPriorityUpdatedMsg{project: project, err: err}
+		if inbox {
+			todos, err := m.backend.GetTodos(ctx)
+			return todosLoadedMsg{todos: inboxTodos(todos), err: err, gen: gen}
+		}
+		if project == nil {
+			return todosLoadedMsg{todos: []api.Todo{}, err: nil, gen: gen}
+		}
+		todos, err := m.backend.GetTodosByProject(ctx, project.ID)
+		return todosLoadedMsg{todos: todos, err: err, gen: gen}
+	}
+}
+
+func (m Model) updateProjectStatus(projectID int, status string, previous api.Project) tea.Cmd {
+	return func() tea.Msg {
+		project, err := m.backend.UpdateProjectStatus(m.ctx, projectID, status)
+		return projectStatusUpdatedMsg{projectID: projectID, project: project, previous: previous, err: err}
+	}
+}
+
+func (m Model) updateProjectPriority(projectID int, priority int, previous api.Project) tea.Cmd {
+	return func() tea.Msg {
+		project, err := m.backend.UpdateProjectPriority(m.ctx, projectID, priority)
+		return projectPriorityUpdatedMsg{projectID: projectID, project: project, previous: previous, err: err}
+	}
+}
+
+func (m Model) createTodo(list *TodoList, tempID int, description string, priority int, projectID *int) tea.Cmd {
+	return func() tea.Msg {
+		todo, err := m.backend.CreateTodo(m.ctx, description, priority, projectID)
+		return todoCreatedMsg{list: list, tempID: tempID, todo: todo, err: err}
 	}
 }
 
-func (m Model) createTodo(description string, priority int, projectID int) tea.Cmd {
+// updateTodo sends an edit of previous, which fails with a conflict if the
+// todo changed on the server since previous was read
+func (m Model) updateTodo(list *TodoList, previous api.Todo, description string, priority int, projectID *int) tea.Cmd {
+	attempted := previous
+	attempted.Description, attempted.Priority, attempted.ProjectID = description, priority, projectID
 	return func() tea.Msg {
-		todo, err := m.apiClient.CreateTodo(description, priority, &projectID)
-		return todoCreatedMsg{todo: todo, err: err}
+		todo, err := m.backend.UpdateTodo(m.ctx, previous.ID, previous.Version, description, priority, projectID)
+		return todoUpdatedMsg{kind: changeTodoUpdate, list: list, previous: previous, attempted: attempted, todo: todo, err: err}
 	}
 }
 
-func (m Model) updateTodo(id int, description string, priority int, projectID *int) tea.Cmd {
+func (m Model) updateTodoCompleted(list *TodoList, previous api.Todo, completed bool) tea.Cmd {
 	return func() tea.Msg {
-		todo, err := m.apiClient.UpdateTodo(id, description, priority, projectID)
-		return todoUpdatedMsg{todo: todo, err: err}
+		todo, err := m.backend.UpdateTodoCompleted(m.ctx, previous.ID, completed)
+		return todoUpdatedMsg{kind: changeTodoComplete, list: list, previous: previous, todo: todo, err: err}
 	}
 }
 
-func (m Model) deleteTodo(id int) tea.Cmd {
+func (m Model) updateTodoNotes(list *TodoList, previous api.Todo, notes string) tea.Cmd {
 	return func() tea.Msg {
-		err := m.apiClient.DeleteTodo(id)
-		return todoDeletedMsg{err: err}
+		todo, err := m.backend.UpdateTodoNotes(m.ctx, previous.ID, notes)
+		return todoUpdatedMsg{kind: changeTodoNotes, list: list, previous: previous, todo: todo, err: err}
+	}
+}
+
+func (m Model) deleteTodo(list *TodoList, todo api.Todo, index int) tea.Cmd {
+	return func() tea.Msg {
+		err := m.backend.DeleteTodo(m.ctx, todo.ID)
+		return todoDeletedMsg{list: list, todo: todo, index: index, err: err}
 	}
 }
 
 func (m Model) createProject(name, description, path, file, language string, priority int, status string) tea.Cmd {
 	return func() tea.Msg {
-		project, err := m.apiClient.CreateProject(name, description, path, file, language, priority, status)
+		project, err := m.backend.CreateProject(m.ctx, name, description, path, file, language, priority, status)
 		return projectCreatedMsg{project: project, err: err}
 	}
 }
 
-func (m Model) updateProject(id int, name, description, path, file, language string, priority int, status string) tea.Cmd {
+// updateProject replaces previous with updated, which fails with a conflict
+// if the project changed on the server since previous was read
+func (m Model) updateProject(previous, updated api.Project) tea.Cmd {
+	return func() tea.Msg {
+		u := updated
+		project, err := m.backend.UpdateProject(m.ctx, previous.ID, previous.Version,
+			u.Name, u.Description, u.Path, u.File, u.Language, u.Priority, u.Status)
+		return projectUpdatedMsg{projectID: previous.ID, project: project, previous: previous, attempted: updated, err: err}
+	}
+}
+
+// loadProjectConflict fetches the server's copy of a project whose edit of
+// base into mine was refused
+func (m Model) loadProjectConflict(base, mine api.Project) tea.Cmd {
+	return func() tea.Msg {
+		theirs, err := m.backend.GetProject(m.ctx, base.ID)
+		return projectConflictMsg{base: base, mine: mine, theirs: theirs, err: err}
+	}
+}
+
+// loadTodoConflict fetches the server's copy of a todo whose edit of base
+// into mine was refused
+func (m Model) loadTodoConflict(list *TodoList, base, mine api.Todo) tea.Cmd {
 	return func() tea.Msg {
-		project, err := m.apiClient.UpdateProject(id, name, description, path, file, language, priority, status)
-		return projectUpdatedMsg{project: project, err: err}
+		theirs, err := m.backend.GetTodo(m.ctx, base.ID)
+		return todoConflictMsg{list: list, base: base, mine: mine, theirs: theirs, err: err}
 	}
 }
 
 func (m Model) deleteProject(id int) tea.Cmd {
 	return func() tea.Msg {
-		err := m.apiClient.DeleteProject(id)
-		return projectDeletedMsg{err: err}
+		err := m.backend.DeleteProject(m.ctx, id)
+		return projectDeletedMsg{projectID: id, err: err}
 	}
 }
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+// newTestModel returns a model over backend that has loaded its projects
+func newTestModel(t *testing.T, backend api.Backend) Model {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.Config{
+		Workflow: config.DefaultWorkflow(),
+		Keys:     config.KeysConfig{Bindings: make(map[string][]string)},
+	}
+	m, err := NewModelWithBackend(backend, cfg)
+	if err != nil {
+		t.Fatalf("NewModelWithBackend: %v", err)
+	}
+	m, _ = update(m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = update(m, m.loadProjects()())
+	if m.viewMode != KanbanBoardView || m.kanbanBoard == nil {
+		t.Fatalf("after loading, view = %v, want the board", m.viewMode)
+	}
+	return m
+}
+
+func update(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+func TestProjectStatusRollback(t *testing.T) {
+	statuses := config.DefaultWorkflow().Statuses()
+	backend := &fakeBackend{projects: []api.Project{{ID: 1, Version: 1, Name: "pj", Status: statuses[0]}}}
+	m := newTestModel(t, backend)
+
+	// The card moves before the server answers
+	backend.fail = &api.APIError{StatusCode: 500, Message: "boom"}
+	m, cmd := update(m, progressProjectMsg{projectID: 1, status: statuses[1]})
+	if project, _ := m.kanbanBoard.FindProject(1); project.Status != statuses[1] {
+		t.Errorf("optimistic status = %q, want %q", project.Status, statuses[1])
+	}
+	if !m.kanbanBoard.IsPending(1) {
+		t.Error("project not marked pending while the update is in flight")
+	}
+
+	// The failure puts it back and says so
+	m, _ = update(m, cmd())
+	if project, _ := m.kanbanBoard.FindProject(1); project.Status != statuses[0] {
+		t.Errorf("status after failure = %q, want it rolled back to %q", project.Status, statuses[0])
+	}
+	if m.kanbanBoard.IsPending(1) {
+		t.Error("project still pending after the update failed")
+	}
+	if m.notifier.current == nil || m.notifier.current.Severity != SeverityError {
+		t.Errorf("notification = %+v, want an error", m.notifier.current)
+	}
+	if len(m.history.undo) != 0 {
+		t.Errorf("failed update recorded for undo: %+v", m.history.undo)
+	}
+
+	// A successful update sticks and can be undone
+	backend.fail = nil
+	m, cmd = update(m, progressProjectMsg{projectID: 1, status: statuses[1]})
+	m, _ = update(m, cmd())
+	if project, _ := m.kanbanBoard.FindProject(1); project.Status != statuses[1] || project.Version != 2 {
+		t.Errorf("project after success = %+v, want the server's copy", project)
+	}
+	if len(m.history.undo) != 1 {
+		t.Errorf("undo history has %d changes, want 1", len(m.history.undo))
+	}
+}
+
+func TestTodoCompleteRollback(t *testing.T) {
+	projectID := 1
+	backend := &fakeBackend{
+		projects: []api.Project{{ID: 1, Version: 1, Name: "pj", Status: config.DefaultWorkflow().Statuses()[0]}},
+		todos: []api.Todo{
+			{ID: 1, Version: 1, Description: "first", ProjectID: &projectID},
+			{ID: 2, Version: 1, Description: "gone", ProjectID: &projectID, Deleted: true},
+		},
+	}
+	m := newTestModel(t, backend)
+
+	project, _ := m.kanbanBoard.FindProject(1)
+	m.currentProject = &project
+	m, _ = update(m, m.loadTodos()())
+	if !m.showTodoList || m.todoList == nil {
+		t.Fatal("todo list not open after loading its todos")
+	}
+	if n := len(m.todoList.todos); n != 1 {
+		t.Fatalf("todo list has %d todos, want only the live one", n)
+	}
+
+	backend.fail = errors.New("connection reset")
+	m, cmd := update(m, completeTodoMsg{id: 1, completed: true})
+	if todo, _ := m.todoList.FindTodo(1); !todo.Completed || !m.todoList.pending[1] {
+		t.Errorf("optimistic todo = %+v, want completed and pending", todo)
+	}
+
+	m, _ = update(m, cmd())
+	if todo, _ := m.todoList.FindTodo(1); todo.Completed {
+		t.Error("todo still completed after the update failed")
+	}
+	if m.todoList.pending[1] {
+		t.Error("todo still pending after the update failed")
+	}
+	if m.notifier.current == nil || m.notifier.current.Severity != SeverityError {
+		t.Errorf("notification = %+v, want an error", m.notifier.current)
+	}
+}
+
+func TestUnauthorizedRollsBackBeforePrompting(t *testing.T) {
+	statuses := config.DefaultWorkflow().Statuses()
+	backend := &reauthFakeBackend{fakeBackend: &fakeBackend{
+		projects: []api.Project{{ID: 1, Version: 1, Name: "pj", Status: statuses[0]}},
+	}}
+	m := newTestModel(t, backend)
+
+	backend.fail = &api.APIError{StatusCode: 401, Message: "bad token"}
+	m, cmd := update(m, progressProjectMsg{projectID: 1, status: statuses[1]})
+	m, _ = update(m, cmd())
+	if !m.showAuthPrompt {
+		t.Fatal("rejected credentials didn't prompt for new ones")
+	}
+	if project, _ := m.kanbanBoard.FindProject(1); project.Status != statuses[0] {
+		t.Errorf("status behind the prompt = %q, want it rolled back to %q", project.Status, statuses[0])
+	}
+	if m.kanbanBoard.IsPending(1) {
+		t.Error("project still pending behind the prompt")
+	}
+
+	m, _ = update(m, cancelAuthMsg{})
+	if m.showAuthPrompt || m.viewMode != KanbanBoardView {
+		t.Errorf("after cancelling, prompt = %v and view = %v, want the board", m.showAuthPrompt, m.viewMode)
+	}
+	if project, _ := m.kanbanBoard.FindProject(1); project.Status != statuses[0] {
+		t.Errorf("status after cancelling = %q, want %q", project.Status, statuses[0])
+	}
+}
+
+func TestProgressCountedOnlyWhenStale(t *testing.T) {
+	projectID := 1
+	backend := &fakeBackend{
+		projects: []api.Project{{ID: 1, Version: 1, Name: "pj", Status: config.DefaultWorkflow().Statuses()[0]}},
+		todos:    []api.Todo{{ID: 1, Version: 1, Description: "first", ProjectID: &projectID}},
+	}
+	m := newTestModel(t, backend)
+	if backend.getTodos != 1 {
+		t.Fatalf("first load fetched todos %d times, want once", backend.getTodos)
+	}
+	if p := m.kanbanBoard.progress[1]; p.total != 1 {
+		t.Errorf("progress = %+v, want one todo", p)
+	}
+
+	// Project changes reload the board without recounting
+	m, _ = update(m, m.loadProjects()())
+	if backend.getTodos != 1 {
+		t.Errorf("reload fetched todos again: %d calls", backend.getTodos)
+	}
+	if p := m.kanbanBoard.progress[1]; p.total != 1 {
+		t.Errorf("progress after reload = %+v, want it kept", p)
+	}
+
+	// A todo added elsewhere shows up on refresh
+	backend.todos = append(backend.todos, api.Todo{ID: 2, Version: 1, Description: "second", ProjectID: &projectID})
+	m, cmd := update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if cmd == nil {
+		t.Fatal("refresh did nothing")
+	}
+	m, _ = update(m, m.loadProjects()())
+	if backend.getTodos != 2 {
+		t.Errorf("refresh fetched todos %d times in all, want 2", backend.getTodos)
+	}
+	if p := m.kanbanBoard.progress[1]; p.total != 2 {
+		t.Errorf("progress after refresh = %+v, want two todos", p)
+	}
+}
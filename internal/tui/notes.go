@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// notesMarker follows the description of a todo that has notes
+const notesMarker = "✎"
+
+// notesCache remembers the last rendering of a todo's notes, since markdown
+// is too slow to render on every frame
+type notesCache struct {
+	notes string
+	width int
+	style string
+	out   string
+}
+
+// render renders notes as markdown in the glamour style wrapped to width,
+// reusing the previous rendering when nothing changed. Notes glamour can't render are shown as
+// plain text.
+func (c *notesCache) render(notes string, width int, style string) string {
+	if c.out != "" && c.notes == notes && c.width == width && c.style == style {
+		return c.out
+	}
+
+	out := lipgloss.NewStyle().Width(width).Render(notes)
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(style),
+		glamour.WithWordWrap(width),
+	)
+	if err == nil {
+		if rendered, err := r.Render(notes); err == nil {
+			out = strings.Trim(rendered, "\n")
+		}
+	}
+
+	*c = notesCache{notes: notes, width: width, style: style, out: out}
+	return out
+}
+
+// NotesView renders the notes of the selected todo in a pane width columns
+// wide and at most height lines tall, or nothing when no todo is selected
+func (t *TodoList) NotesView(width, height int) string {
+	todo, ok := t.selected()
+	if !ok {
+		return ""
+	}
+
+	paneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.theme.Border).
+		Padding(0, 1).
+		Width(width - 2)
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.theme.Accent)
+	emptyStyle := lipgloss.NewStyle().
+		Foreground(t.theme.Muted).
+		Italic(true)
+
+	title := titleStyle.Render("✎ Notes")
+	inner := width - 4
+	if todo.Notes == "" {
+		empty := emptyStyle.Width(inner).Render(fmt.Sprintf("No notes. Press '%s' to write some, or '%s' to use $EDITOR.",
+			t.keys.Notes.Help().Key, t.keys.NotesEditor.Help().Key))
+		return paneStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", empty))
+	}
+
+	lines := strings.Split(t.notes.render(todo.Notes, inner, t.theme.Markdown), "\n")
+	// Leave room for the border and title
+	if limit := max(1, height-4); len(lines) > limit {
+		lines = append(lines[:limit-1], emptyStyle.Render("  …"))
+	}
+	return paneStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, strings.Join(lines, "\n")))
+}
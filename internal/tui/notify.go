@@ -0,0 +1,236 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Severity is the importance of a notification
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeveritySuccess
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the severity label shown in the message history
+func (s Severity) String() string {
+	switch s {
+	case SeveritySuccess:
+		return "ok"
+	case SeverityWarning:
+		return "warn"
+	case SeverityError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// color returns the color theme gives the severity
+func (s Severity) color(theme Theme) lipgloss.TerminalColor {
+	switch s {
+	case SeveritySuccess:
+		return theme.Success
+	case SeverityWarning:
+		return theme.Warning
+	case SeverityError:
+		return theme.Error
+	default:
+		return theme.Accent
+	}
+}
+
+// icon returns the glyph prefixed to toasts of this severity
+func (s Severity) icon() string {
+	switch s {
+	case SeveritySuccess:
+		return "✔"
+	case SeverityWarning:
+		return "⚠"
+	case SeverityError:
+		return "✖"
+	default:
+		return "ℹ"
+	}
+}
+
+// ttl returns how long a toast of this severity stays visible
+func (s Severity) ttl() time.Duration {
+	switch s {
+	case SeverityWarning:
+		return 5 * time.Second
+	case SeverityError:
+		return 8 * time.Second
+	default:
+		return 3 * time.Second
+	}
+}
+
+// maxHistory bounds the number of notifications kept in the history
+const maxHistory = 200
+
+// Notification is a single message shown to the user
+type Notification struct {
+	ID       int
+	Severity Severity
+	Message  string
+	Time     time.Time
+}
+
+// Notifier shows transient toasts in the status line and keeps a history
+// of every notification that can be browsed in a scrollable overlay
+type Notifier struct {
+	history       []Notification
+	current       *Notification // Toast shown in the status line, if any
+	nextID        int
+	ShowHistory   bool // Exported so model.go can route keys to the history
+	historyOffset int
+	width         int
+	height        int
+	theme         Theme
+	keys          keyMap
+}
+
+// NewNotifier creates an empty notifier whose history is navigated with keys
+func NewNotifier(theme Theme, keys keyMap) *Notifier {
+	return &Notifier{theme: theme, keys: keys}
+}
+
+// SetSize sets the notifier dimensions
+func (n *Notifier) SetSize(width, height int) {
+	n.width = width
+	n.height = height
+}
+
+// Push shows a toast and records it in the history, returning a command
+// that dismisses the toast once its time is up
+func (n *Notifier) Push(severity Severity, format string, args ...interface{}) tea.Cmd {
+	n.nextID++
+	notification := Notification{
+		ID:       n.nextID,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Time:     time.Now(),
+	}
+
+	n.history = append(n.history, notification)
+	if len(n.history) > maxHistory {
+		n.history = n.history[len(n.history)-maxHistory:]
+	}
+	n.current = &notification
+
+	id := notification.ID
+	return tea.Tick(severity.ttl(), func(time.Time) tea.Msg {
+		return dismissNotificationMsg{id: id}
+	})
+}
+
+// Error pushes an error notification describing a failed operation
+func (n *Notifier) Error(action string, err error) tea.Cmd {
+	return n.Push(SeverityError, "%s: %v", action, err)
+}
+
+// Dismiss hides the toast with the given ID if it is still showing
+func (n *Notifier) Dismiss(id int) {
+	if n.current != nil && n.current.ID == id {
+		n.current = nil
+	}
+}
+
+// ToggleHistory shows or hides the message history, starting at the newest
+func (n *Notifier) ToggleHistory() {
+	n.ShowHistory = !n.ShowHistory
+	n.historyOffset = 0
+}
+
+// Update handles keys while the message history is showing
+func (n *Notifier) Update(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, n.keys.Up):
+		if n.historyOffset < len(n.history)-1 {
+			n.historyOffset++
+		}
+	case key.Matches(msg, n.keys.Down):
+		if n.historyOffset > 0 {
+			n.historyOffset--
+		}
+	case key.Matches(msg, n.keys.Back, n.keys.Quit, n.keys.Messages):
+		n.ShowHistory = false
+	}
+}
+
+// StatusLine renders the current toast as a single line
+func (n *Notifier) StatusLine() string {
+	if n.current == nil {
+		return ""
+	}
+	style := lipgloss.NewStyle().
+		Foreground(n.current.Severity.color(n.theme)).
+		MarginLeft(2).
+		MaxWidth(n.width)
+	return style.Render(fmt.Sprintf("%s %s", n.current.Severity.icon(), n.current.Message))
+}
+
+// HistoryView renders the scrollable message history, newest first
+func (n *Notifier) HistoryView() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(n.theme.Accent).
+		MarginBottom(1)
+
+	timeStyle := lipgloss.NewStyle().
+		Foreground(n.theme.Muted)
+
+	emptyStyle := lipgloss.NewStyle().
+		Foreground(n.theme.Muted).
+		Italic(true)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(n.theme.Muted).
+		MarginTop(1)
+
+	title := titleStyle.Render(fmt.Sprintf("🔔 Messages (%d)", len(n.history)))
+
+	maxLines := n.height - 12
+	if maxLines < 5 {
+		maxLines = 5
+	}
+
+	var lines []string
+	if len(n.history) == 0 {
+		lines = append(lines, emptyStyle.Render("No messages yet"))
+	}
+	for i := len(n.history) - 1 - n.historyOffset; i >= 0 && len(lines) < maxLines; i-- {
+		notification := n.history[i]
+		severityStyle := lipgloss.NewStyle().Foreground(notification.Severity.color(n.theme))
+		lines = append(lines, fmt.Sprintf("%s %s %s",
+			timeStyle.Render(notification.Time.Format("15:04:05")),
+			severityStyle.Render(fmt.Sprintf("%-5s", notification.Severity)),
+			notification.Message,
+		))
+	}
+
+	help := helpStyle.Render(helpLine(
+		helpEntry("scroll", n.keys.Up, n.keys.Down),
+		helpEntry("close", n.keys.Back),
+	))
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		lipgloss.JoinVertical(lipgloss.Left, lines...),
+		help,
+	)
+}
+
+// dismissNotificationMsg hides a toast once its time is up
+type dismissNotificationMsg struct {
+	id int
+}
@@ -1,13 +1,16 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sean-obeirne/projectarium-tui/internal/api"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
 )
 
 // ProjectModal represents the modal for creating a new project
@@ -16,11 +19,15 @@ type ProjectModal struct {
 	focusedIndex   int
 	width          int
 	height         int
+	workflow       config.Workflow
+	theme          Theme
+	keys           keyMap
 	statusOptions  []string
 	selectedStatus int // Index of selected status
 	err            string
+	fieldErrs      map[int]string // Validation errors reported by the backend, by field
 	isEditMode     bool
-	projectID      int
+	original       api.Project // Project being edited, as it was when the modal opened
 }
 
 const (
@@ -34,8 +41,9 @@ const (
 	totalFields
 )
 
-// NewProjectModal creates a new project creation modal
-func NewProjectModal() *ProjectModal {
+// NewProjectModal creates a new project creation modal offering the
+// workflow's statuses, with fields starting at the configured defaults
+func NewProjectModal(workflow config.Workflow, defaults config.Defaults, theme Theme, keys keyMap) *ProjectModal {
 	inputs := make([]textinput.Model, totalFields)
 
 	// Name input
@@ -74,7 +82,7 @@ func NewProjectModal() *ProjectModal {
 	inputs[priorityField].Placeholder = ""
 	inputs[priorityField].CharLimit = 1
 	inputs[priorityField].Width = 40
-	inputs[priorityField].SetValue("0")
+	inputs[priorityField].SetValue(fmt.Sprintf("%d", defaults.ProjectPriority))
 
 	// Status field is not a text input - it's a selector
 	inputs[statusField] = textinput.New()
@@ -84,18 +92,20 @@ func NewProjectModal() *ProjectModal {
 	return &ProjectModal{
 		inputs:         inputs,
 		focusedIndex:   0,
-		statusOptions:  []string{"ready", "in_progress", "finished"},
-		selectedStatus: 0,
+		workflow:       workflow,
+		theme:          theme,
+		keys:           keys,
+		statusOptions:  workflow.Statuses(),
+		selectedStatus: workflow.ColumnIndex(defaults.ProjectStatus),
 		isEditMode:     false,
-		projectID:      0,
 	}
 }
 
 // NewProjectModalForEdit creates a modal pre-populated with existing project data
-func NewProjectModalForEdit(project *api.Project) *ProjectModal {
-	modal := NewProjectModal()
+func NewProjectModalForEdit(project *api.Project, workflow config.Workflow, theme Theme, keys keyMap) *ProjectModal {
+	modal := NewProjectModal(workflow, config.Defaults{}, theme, keys)
 	modal.isEditMode = true
-	modal.projectID = project.ID
+	modal.original = *project
 
 	// Populate fields with existing values
 	modal.inputs[nameField].SetValue(project.Name)
@@ -106,14 +116,38 @@ func NewProjectModalForEdit(project *api.Project) *ProjectModal {
 	modal.inputs[priorityField].SetValue(fmt.Sprintf("%d", project.Priority))
 
 	// Set selected status based on project status
-	for i, status := range modal.statusOptions {
-		if status == project.Status {
-			modal.selectedStatus = i
-			break
+	modal.selectedStatus = workflow.ColumnIndex(project.Status)
+
+	return modal
+}
+
+// apiFieldNames maps backend JSON field names to modal fields
+var apiFieldNames = map[string]int{
+	"name":        nameField,
+	"description": descriptionField,
+	"path":        pathField,
+	"file":        fileField,
+	"language":    languageField,
+	"priority":    priorityField,
+	"status":      statusField,
+}
+
+// SetValidationError shows a validation error returned by the backend,
+// attaching per-field messages to the fields they refer to
+func (m *ProjectModal) SetValidationError(err error) {
+	m.fieldErrs = make(map[int]string)
+	for name, msg := range api.FieldErrors(err) {
+		if field, ok := apiFieldNames[name]; ok {
+			m.fieldErrs[field] = msg
 		}
 	}
 
-	return modal
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.Message != "" {
+		m.err = apiErr.Message
+	} else {
+		m.err = err.Error()
+	}
 }
 
 // SetSize sets the modal dimensions
@@ -150,17 +184,17 @@ func (m ProjectModal) Update(msg tea.Msg) (ProjectModal, tea.Cmd) {
 			m.focusPrev()
 			return m, nil
 
-		case "left", "j":
-			// Navigate status options
-			if m.focusedIndex == statusField {
+		}
+
+		// Text fields take every other key, so the status selector only
+		// sees the bound movement keys while it is focused
+		if m.focusedIndex == statusField {
+			switch {
+			case key.Matches(msg, m.keys.Left):
 				if m.selectedStatus > 0 {
 					m.selectedStatus--
 				}
-			}
-
-		case "right", ";":
-			// Navigate status options
-			if m.focusedIndex == statusField {
+			case key.Matches(msg, m.keys.Right):
 				if m.selectedStatus < len(m.statusOptions)-1 {
 					m.selectedStatus++
 				}
@@ -199,6 +233,8 @@ func (m *ProjectModal) focusPrev() {
 }
 
 func (m *ProjectModal) submitProject() tea.Cmd {
+	m.fieldErrs = nil
+
 	// Validate required fields
 	name := m.inputs[nameField].Value()
 	if name == "" {
@@ -219,7 +255,7 @@ func (m *ProjectModal) submitProject() tea.Cmd {
 
 	if m.isEditMode {
 		return updateProjectCmd(
-			m.projectID,
+			m.original,
 			name,
 			m.inputs[descriptionField].Value(),
 			m.inputs[pathField].Value(),
@@ -245,35 +281,39 @@ func (m *ProjectModal) submitProject() tea.Cmd {
 func (m *ProjectModal) View() string {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("63")).
+		Foreground(m.theme.Accent).
 		MarginBottom(1)
 
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(m.theme.Text).
 		Width(20)
 
 	focusedLabelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("63")).
+		Foreground(m.theme.Accent).
 		Bold(true).
 		Width(20)
 
 	errorStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("196")).
+		Foreground(m.theme.Error).
 		MarginTop(1)
 
+	fieldErrorStyle := lipgloss.NewStyle().
+		Foreground(m.theme.Error).
+		MarginLeft(20)
+
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(m.theme.Muted).
 		MarginTop(1)
 
 	statusStyle := lipgloss.NewStyle().
 		Padding(0, 2).
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("240"))
+		BorderForeground(m.theme.Border)
 
 	selectedStatusStyle := lipgloss.NewStyle().
 		Padding(0, 2).
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("63")).
+		Border(m.theme.selectedBorder()).
+		BorderForeground(m.theme.Accent).
 		Bold(true)
 
 	// Title
@@ -311,6 +351,9 @@ func (m *ProjectModal) View() string {
 			m.inputs[i].View(),
 		)
 		formFields = append(formFields, field)
+		if msg, ok := m.fieldErrs[i]; ok {
+			formFields = append(formFields, fieldErrorStyle.Render(msg))
+		}
 	}
 
 	// Status selector
@@ -323,15 +366,7 @@ func (m *ProjectModal) View() string {
 
 	var statusButtons []string
 	for i, status := range m.statusOptions {
-		var displayText string
-		switch status {
-		case "ready":
-			displayText = "Ready"
-		case "in_progress":
-			displayText = "In Progress"
-		case "finished":
-			displayText = "Finished"
-		}
+		displayText := m.workflow.DisplayName(status)
 
 		var button string
 		if i == m.selectedStatus {
@@ -343,17 +378,26 @@ func (m *ProjectModal) View() string {
 	}
 
 	statusSelector := lipgloss.JoinHorizontal(lipgloss.Top, statusButtons...)
-	statusField := lipgloss.JoinHorizontal(
+	statusRow := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		statusLabel,
 		statusSelector,
 	)
-	formFields = append(formFields, statusField)
+	formFields = append(formFields, statusRow)
+	if msg, ok := m.fieldErrs[statusField]; ok {
+		formFields = append(formFields, fieldErrorStyle.Render(msg))
+	}
 
 	form := lipgloss.JoinVertical(lipgloss.Left, formFields...)
 
 	// Help text
-	help := helpStyle.Render("tab/↓ next • shift+tab/↑ prev • ←/j →/; navigate status • enter submit • esc cancel")
+	help := helpStyle.Render(helpLine(
+		"tab/↓ next",
+		"shift+tab/↑ prev",
+		helpEntry("navigate status", m.keys.Left, m.keys.Right),
+		"enter submit",
+		"esc cancel",
+	))
 
 	// Error message
 	var errorMsg string
@@ -383,7 +427,7 @@ type createProjectMsg struct {
 }
 
 type updateProjectMsg struct {
-	id          int
+	original    api.Project // Project the edit is based on
 	name        string
 	description string
 	path        string
@@ -410,10 +454,10 @@ func createProjectCmd(name, description, path, file, language string, priority i
 	}
 }
 
-func updateProjectCmd(id int, name, description, path, file, language string, priority int, status string) tea.Cmd {
+func updateProjectCmd(original api.Project, name, description, path, file, language string, priority int, status string) tea.Cmd {
 	return func() tea.Msg {
 		return updateProjectMsg{
-			id:          id,
+			original:    original,
 			name:        name,
 			description: description,
 			path:        path,
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// maxPickerResults bounds the projects listed by the picker at once
+const maxPickerResults = 10
+
+// inboxEntry names the picker entry that moves a todo out of its project
+const inboxEntry = "📥 Inbox (no project)"
+
+// ProjectPicker picks the project a todo moves to, fuzzy matching the typed
+// name against every project
+type ProjectPicker struct {
+	todo     api.Todo
+	projects []api.Project
+	input    textinput.Model
+	results  []pickerResult
+	selected int
+	theme    Theme
+}
+
+// pickerResult is a line of the picker: a project, or the inbox when
+// project is nil
+type pickerResult struct {
+	project *api.Project
+	match   []int
+	score   int
+}
+
+// NewProjectPicker creates a picker for moving todo, offering every project
+// but the one it is in, and the inbox unless it is already there
+func NewProjectPicker(todo api.Todo, projects []api.Project, theme Theme) *ProjectPicker {
+	ti := textinput.New()
+	ti.Prompt = "→ "
+	ti.Placeholder = "type a project name"
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.Focus()
+
+	var candidates []api.Project
+	for _, p := range projects {
+		if todo.ProjectID == nil || p.ID != *todo.ProjectID {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return byName(candidates[i], candidates[j])
+	})
+
+	p := &ProjectPicker{todo: todo, projects: candidates, input: ti, theme: theme}
+	p.refresh()
+	return p
+}
+
+// refresh recomputes the results for the typed query, best match first
+func (p *ProjectPicker) refresh() {
+	query := strings.TrimSpace(p.input.Value())
+	p.results = p.results[:0]
+	if p.todo.ProjectID != nil {
+		if _, _, ok := fuzzyMatch(query, "inbox"); query == "" || ok {
+			p.results = append(p.results, pickerResult{})
+		}
+	}
+	for i := range p.projects {
+		project := &p.projects[i]
+		if query == "" {
+			p.results = append(p.results, pickerResult{project: project})
+			continue
+		}
+		if score, positions, ok := fuzzyMatch(query, project.Name); ok {
+			p.results = append(p.results, pickerResult{project: project, match: positions, score: score})
+		}
+	}
+	if query != "" {
+		sort.SliceStable(p.results, func(i, j int) bool {
+			return p.results[i].score > p.results[j].score
+		})
+	}
+	p.selected = 0
+}
+
+// Update handles messages for the project picker. Letters go to the
+// input, so the list is navigated with the arrow keys or ctrl+n/ctrl+p.
+func (p ProjectPicker) Update(msg tea.Msg) (ProjectPicker, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return p, nil
+	}
+
+	switch keyMsg.String() {
+	case "up", "ctrl+p":
+		if p.selected > 0 {
+			p.selected--
+		}
+		return p, nil
+	case "down", "ctrl+n":
+		if p.selected < min(len(p.results), maxPickerResults)-1 {
+			p.selected++
+		}
+		return p, nil
+	case "enter":
+		if p.selected >= len(p.results) {
+			return p, nil
+		}
+		todo := p.todo
+		var projectID *int
+		if project := p.results[p.selected].project; project != nil {
+			id := project.ID
+			projectID = &id
+		}
+		return p, func() tea.Msg {
+			return moveTodoMsg{todo: todo, projectID: projectID}
+		}
+	case "esc":
+		return p, func() tea.Msg {
+			return cancelProjectPickerMsg{}
+		}
+	}
+
+	var cmd tea.Cmd
+	previous := p.input.Value()
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != previous {
+		p.refresh()
+	}
+	return p, cmd
+}
+
+// View renders the project picker
+func (p *ProjectPicker) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(p.theme.Accent).
+		MarginBottom(1)
+	todoStyle := lipgloss.NewStyle().Foreground(p.theme.Muted).MarginBottom(1)
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(p.theme.Selection)
+	emptyStyle := lipgloss.NewStyle().Foreground(p.theme.Muted).Italic(true)
+	helpStyle := lipgloss.NewStyle().
+		Foreground(p.theme.Muted).
+		MarginTop(1)
+
+	lines := []string{
+		titleStyle.Render("📦 Move Todo"),
+		todoStyle.Render(highlightMatches(p.theme, p.todo.Description, nil, 50)),
+		p.input.View(),
+		"",
+	}
+	if len(p.results) == 0 {
+		lines = append(lines, emptyStyle.Render("  No matching projects"))
+	}
+	for i, r := range p.results {
+		if i == maxPickerResults {
+			lines = append(lines, emptyStyle.Render("  …"))
+			break
+		}
+		name := inboxEntry
+		if r.project != nil {
+			name = highlightMatches(p.theme, r.project.Name, r.match, 50)
+		}
+		if i == p.selected {
+			lines = append(lines, selectedStyle.Render("▸ ")+name)
+		} else {
+			lines = append(lines, "  "+name)
+		}
+	}
+	lines = append(lines, helpStyle.Render("↑/↓ choose • enter move • esc cancel"))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// Message types for the project picker
+type openProjectPickerMsg struct {
+	todo api.Todo
+}
+
+type moveTodoMsg struct {
+	todo      api.Todo
+	projectID *int // nil moves the todo to the inbox
+}
+
+type cancelProjectPickerMsg struct{}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// SortMode is the order of the cards within each column
+type SortMode string
+
+const (
+	SortPriority SortMode = "priority" // highest priority first, then by name
+	SortName     SortMode = "name"
+	SortLanguage SortMode = "language" // grouped by language, then by priority
+	SortUpdated  SortMode = "updated"  // most recently updated first
+	SortManual   SortMode = "manual"   // the order cards were moved into
+)
+
+// sortModes are the modes in the order the sort key cycles through them
+var sortModes = []SortMode{SortPriority, SortName, SortLanguage, SortUpdated, SortManual}
+
+// parseSortMode validates a sort mode from the config or state file; empty
+// is the default, priority
+func parseSortMode(s string) (SortMode, error) {
+	if s == "" {
+		return SortPriority, nil
+	}
+	for _, mode := range sortModes {
+		if string(mode) == strings.ToLower(s) {
+			return mode, nil
+		}
+	}
+	names := make([]string, len(sortModes))
+	for i, mode := range sortModes {
+		names[i] = string(mode)
+	}
+	return "", fmt.Errorf("unknown sort mode %q (want %s)", s, strings.Join(names, ", "))
+}
+
+// next returns the mode after m in the cycle
+func (m SortMode) next() SortMode {
+	for i, mode := range sortModes {
+		if mode == m {
+			return sortModes[(i+1)%len(sortModes)]
+		}
+	}
+	return SortPriority
+}
+
+// byPriority orders by priority descending, then by name
+func byPriority(a, b api.Project) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return byName(a, b)
+}
+
+// byName orders by name ignoring case, then by ID so ties are stable
+func byName(a, b api.Project) bool {
+	an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name)
+	if an != bn {
+		return an < bn
+	}
+	return a.ID < b.ID
+}
+
+// sortProjects orders a column's projects by mode. In manual mode, order
+// lists the column's project IDs; projects missing from it (new ones, or
+// ones moved in from another column) follow in priority order.
+func sortProjects(projects []api.Project, mode SortMode, order []int) {
+	less := byPriority
+	switch mode {
+	case SortName:
+		less = byName
+	case SortLanguage:
+		less = func(a, b api.Project) bool {
+			al, bl := strings.ToLower(a.Language), strings.ToLower(b.Language)
+			switch {
+			case al == bl:
+				return byPriority(a, b)
+			case al == "" || bl == "":
+				return bl == "" // projects without a language go last
+			}
+			return al < bl
+		}
+	case SortUpdated:
+		less = func(a, b api.Project) bool {
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+			return byName(a, b)
+		}
+	case SortManual:
+		rank := make(map[int]int, len(order))
+		for i, id := range order {
+			rank[id] = i
+		}
+		less = func(a, b api.Project) bool {
+			ra, aok := rank[a.ID]
+			rb, bok := rank[b.ID]
+			switch {
+			case aok && bok:
+				return ra < rb
+			case aok != bok:
+				return aok
+			}
+			return byPriority(a, b)
+		}
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return less(projects[i], projects[j])
+	})
+}
+
+// SetSort re-sorts every column, keeping the selected project selected.
+// order is the manual order per column status, used in manual mode.
+func (b *KanbanBoard) SetSort(mode SortMode, order map[string][]int) {
+	b.order = make(map[string][]int, len(order))
+	for status, ids := range order {
+		b.order[status] = append([]int(nil), ids...)
+	}
+	b.resort(mode)
+}
+
+// SortMode returns the board's sort mode
+func (b *KanbanBoard) SortMode() SortMode {
+	return b.sortMode
+}
+
+// Order returns a copy of the manual order per column status
+func (b *KanbanBoard) Order() map[string][]int {
+	order := make(map[string][]int, len(b.order))
+	for status, ids := range b.order {
+		order[status] = append([]int(nil), ids...)
+	}
+	return order
+}
+
+// resort sorts every column by mode, keeping the selection
+func (b *KanbanBoard) resort(mode SortMode) {
+	selected := b.GetSelectedProject()
+	b.sortMode = mode
+	for i := range b.columns {
+		sortProjects(b.columns[i].Projects, mode, b.order[b.columns[i].Status])
+	}
+	b.refilter()
+	if selected == nil || !b.SelectProject(selected.ID) {
+		b.clampSelection()
+	}
+}
+
+// moveCard moves the selected card up (dir -1) or down (dir 1) past the
+// next shown card in its column. Outside manual mode, the current order
+// becomes the manual order first.
+func (b *KanbanBoard) moveCard(dir int) bool {
+	project := b.GetSelectedProject()
+	if project == nil {
+		return false
+	}
+	visible := b.visible[b.selectedCol]
+	target := b.selectedProject + dir
+	if target < 0 || target >= len(visible) {
+		return false
+	}
+	id := project.ID
+
+	if b.sortMode != SortManual {
+		for _, col := range b.columns {
+			b.recordOrder(col)
+		}
+		b.sortMode = SortManual
+	}
+
+	col := &b.columns[b.selectedCol]
+	i, j := visible[b.selectedProject], visible[target]
+	col.Projects[i], col.Projects[j] = col.Projects[j], col.Projects[i]
+	b.recordOrder(*col)
+
+	b.refilter()
+	b.SelectProject(id)
+	return true
+}
+
+// recordOrder saves a column's current order as its manual order. Projects
+// the board doesn't show, because a filter left them out, keep their place
+// after the shown ones.
+func (b *KanbanBoard) recordOrder(col ProjectColumn) {
+	ids := make([]int, 0, len(col.Projects))
+	shown := make(map[int]bool, len(col.Projects))
+	for _, p := range col.Projects {
+		ids = append(ids, p.ID)
+		shown[p.ID] = true
+	}
+	for _, id := range b.order[col.Status] {
+		if !shown[id] {
+			ids = append(ids, id)
+		}
+	}
+	b.order[col.Status] = ids
+}
+
+// boardOrderChangedMsg tells the model to persist the board's sort mode and
+// manual order
+type boardOrderChangedMsg struct{}
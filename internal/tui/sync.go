@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// syncInterval is how often an offline backend tries to reach the server
+const syncInterval = 15 * time.Second
+
+// syncTick schedules the next sync check, if the backend works offline
+func (m Model) syncTick() tea.Cmd {
+	if _, ok := m.backend.(api.Syncer); !ok {
+		return nil
+	}
+	return tea.Tick(syncInterval, func(time.Time) tea.Msg {
+		return syncTickMsg{}
+	})
+}
+
+// sync sends the changes queued while offline, if there are any or the
+// server was unreachable. Only a sync started by the tick schedules the next
+// one, so there is never more than one tick running.
+func (m Model) sync(fromTick bool) tea.Cmd {
+	syncer, ok := m.backend.(api.Syncer)
+	if !ok {
+		return nil
+	}
+	status := syncer.SyncStatus()
+	if !status.Offline && status.Pending == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		result, err := syncer.Sync(m.ctx)
+		return syncedMsg{wasOffline: status.Offline, fromTick: fromTick, result: result, err: err}
+	}
+}
+
+// settleSync reports the outcome of a sync and reloads what it changed
+func (m Model) settleSync(msg syncedMsg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if msg.fromTick {
+		cmds = append(cmds, m.syncTick())
+	}
+	for _, err := range msg.result.Dropped {
+		cmds = append(cmds, m.notifier.Error("Server rejected a change made offline", err))
+	}
+	if msg.result.Sent > 0 {
+		cmds = append(cmds, m.notifier.Push(SeveritySuccess, "Sent %d change(s) made offline", msg.result.Sent))
+	}
+
+	switch {
+	case errors.Is(msg.err, api.ErrUnauthorized):
+		// Ask for new credentials, and keep checking meanwhile
+		if _, ok := m.backend.(api.Reauthenticator); ok {
+			model, cmd := m.promptReauth(msg.err)
+			return model, tea.Batch(append(cmds, cmd)...)
+		}
+		cmds = append(cmds, m.notifier.Error("Failed to send changes made offline", msg.err))
+	case errors.Is(msg.err, api.ErrUnavailable):
+		// Still offline; the indicator says so
+	case msg.err != nil:
+		cmds = append(cmds, m.notifier.Error("Failed to send changes made offline", msg.err))
+	case msg.wasOffline && msg.result.Sent == 0:
+		cmds = append(cmds, m.notifier.Push(SeverityInfo, "Back online"))
+	}
+
+	// Changes made offline get their real IDs once sent, so views holding
+	// them are reloaded
+	if msg.result.Sent > 0 || len(msg.result.Dropped) > 0 {
+		m.progressStale = true
+		if m.viewMode == KanbanBoardView {
+			cmds = append(cmds, m.loadProjects())
+		}
+		if m.showTodoList {
+			cmds = append(cmds, m.loadTodos())
+		}
+		if m.viewMode == AllTodosView && m.allTodos != nil {
+			cmds = append(cmds, m.loadAllTodos(m.allTodos))
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// syncIndicator shows that the backend can't reach the server, or has
+// changes waiting to be sent
+func (m Model) syncIndicator() string {
+	syncer, ok := m.backend.(api.Syncer)
+	if !ok {
+		return ""
+	}
+	var text string
+	switch status := syncer.SyncStatus(); {
+	case status.Offline:
+		text = fmt.Sprintf("⚠ offline · %d pending", status.Pending)
+	case status.Pending > 0:
+		text = fmt.Sprintf("%s%d pending", pendingMarker, status.Pending)
+	default:
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(m.theme.Warning).
+		Bold(true).
+		MarginLeft(2).
+		Render(text)
+}
+
+// syncTickMsg asks for a sync check
+type syncTickMsg struct{}
+
+// syncedMsg reports the result of a sync
+type syncedMsg struct {
+	wasOffline bool
+	fromTick   bool // Started by the tick, which is rescheduled once it's done
+	result     api.SyncResult
+	err        error
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sean-obeirne/projectarium-tui/internal/api"
+)
+
+// offlineFakeBackend is a fakeBackend that can't reach its server
+type offlineFakeBackend struct {
+	*fakeBackend
+	syncs int
+}
+
+func (f *offlineFakeBackend) SyncStatus() api.SyncStatus {
+	return api.SyncStatus{Offline: true}
+}
+
+func (f *offlineFakeBackend) Sync(ctx context.Context) (api.SyncResult, error) {
+	f.syncs++
+	return api.SyncResult{}, api.ErrUnavailable
+}
+
+func TestOnlyTickSyncsReschedule(t *testing.T) {
+	backend := &offlineFakeBackend{fakeBackend: &fakeBackend{}}
+	m := newTestModel(t, backend)
+
+	// A refresh checks the server without starting another tick chain
+	msg, ok := m.sync(false)().(syncedMsg)
+	if !ok {
+		t.Fatal("sync didn't report back")
+	}
+	if _, cmd := update(m, msg); cmd != nil {
+		t.Error("a sync from refreshing scheduled another tick")
+	}
+
+	// The tick's own sync keeps the chain going
+	m, cmd := update(m, syncTickMsg{})
+	if cmd == nil {
+		t.Fatal("tick didn't sync")
+	}
+	if _, cmd = update(m, cmd()); cmd == nil {
+		t.Error("a sync from the tick didn't schedule the next one")
+	}
+	if backend.syncs != 2 {
+		t.Errorf("backend synced %d times, want 2", backend.syncs)
+	}
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
+)
+
+// Theme is the palette the whole UI is drawn with. Colors are named after
+// the role they play so that themes can remap them.
+type Theme struct {
+	Accent     lipgloss.TerminalColor // titles, focus and informational messages
+	Text       lipgloss.TerminalColor // labels and body text
+	HeaderText lipgloss.TerminalColor // text on column header backgrounds
+	Muted      lipgloss.TerminalColor // help, hints and secondary details
+	Border     lipgloss.TerminalColor // unfocused borders
+	Selection  lipgloss.TerminalColor // the selected todo
+	Success    lipgloss.TerminalColor
+	Warning    lipgloss.TerminalColor
+	Error      lipgloss.TerminalColor
+	// Columns are accents for columns without a configured color
+	Columns []lipgloss.TerminalColor
+	// Priority colors card borders and todo markers for priorities 0-3
+	Priority [4]lipgloss.TerminalColor
+	// PrioritySelected colors the selected card's border by priority
+	PrioritySelected [4]lipgloss.TerminalColor
+	// Monochrome themes draw no colors at all, marking the selection with
+	// thick borders and reversed headers instead
+	Monochrome bool
+	// Markdown is the glamour style todo notes are rendered in: dark, light
+	// or notty
+	Markdown string
+}
+
+var darkTheme = Theme{
+	Accent:     lipgloss.Color("63"),
+	Text:       lipgloss.Color("15"),
+	HeaderText: lipgloss.Color("15"),
+	Muted:      lipgloss.Color("241"),
+	Border:     lipgloss.Color("240"),
+	Selection:  lipgloss.Color("51"),
+	Success:    lipgloss.Color("70"),
+	Warning:    lipgloss.Color("214"),
+	Error:      lipgloss.Color("196"),
+	Columns:    colors("63", "214", "70", "205", "39", "141"),
+	Priority:   [4]lipgloss.TerminalColor{lipgloss.Color("240"), lipgloss.Color("70"), lipgloss.Color("214"), lipgloss.Color("196")},
+	PrioritySelected: [4]lipgloss.TerminalColor{
+		lipgloss.Color("245"), lipgloss.Color("120"), lipgloss.Color("227"), lipgloss.Color("210"),
+	},
+	Markdown: "dark",
+}
+
+var lightTheme = Theme{
+	Accent:     lipgloss.Color("26"),
+	Text:       lipgloss.Color("0"),
+	HeaderText: lipgloss.Color("15"),
+	Muted:      lipgloss.Color("243"),
+	Border:     lipgloss.Color("250"),
+	Selection:  lipgloss.Color("31"),
+	Success:    lipgloss.Color("28"),
+	Warning:    lipgloss.Color("130"),
+	Error:      lipgloss.Color("160"),
+	Columns:    colors("26", "130", "28", "162", "31", "91"),
+	Priority:   [4]lipgloss.TerminalColor{lipgloss.Color("250"), lipgloss.Color("28"), lipgloss.Color("130"), lipgloss.Color("160")},
+	PrioritySelected: [4]lipgloss.TerminalColor{
+		lipgloss.Color("240"), lipgloss.Color("34"), lipgloss.Color("166"), lipgloss.Color("196"),
+	},
+	Markdown: "light",
+}
+
+// highContrastTheme sticks to the 16 basic colors, which terminals keep
+// legible under their own palettes
+var highContrastTheme = Theme{
+	Accent:     lipgloss.Color("14"),
+	Text:       lipgloss.Color("15"),
+	HeaderText: lipgloss.Color("0"),
+	Muted:      lipgloss.Color("7"),
+	Border:     lipgloss.Color("7"),
+	Selection:  lipgloss.Color("11"),
+	Success:    lipgloss.Color("10"),
+	Warning:    lipgloss.Color("11"),
+	Error:      lipgloss.Color("9"),
+	Columns:    colors("12", "11", "10", "13", "14", "9"),
+	Priority:   [4]lipgloss.TerminalColor{lipgloss.Color("7"), lipgloss.Color("10"), lipgloss.Color("11"), lipgloss.Color("9")},
+	PrioritySelected: [4]lipgloss.TerminalColor{
+		lipgloss.Color("15"), lipgloss.Color("15"), lipgloss.Color("15"), lipgloss.Color("15"),
+	},
+	Markdown: "dark",
+}
+
+// noColorTheme follows https://no-color.org
+var noColorTheme = Theme{
+	Accent:           lipgloss.NoColor{},
+	Text:             lipgloss.NoColor{},
+	HeaderText:       lipgloss.NoColor{},
+	Muted:            lipgloss.NoColor{},
+	Border:           lipgloss.NoColor{},
+	Selection:        lipgloss.NoColor{},
+	Success:          lipgloss.NoColor{},
+	Warning:          lipgloss.NoColor{},
+	Error:            lipgloss.NoColor{},
+	Columns:          []lipgloss.TerminalColor{lipgloss.NoColor{}},
+	Priority:         [4]lipgloss.TerminalColor{lipgloss.NoColor{}, lipgloss.NoColor{}, lipgloss.NoColor{}, lipgloss.NoColor{}},
+	PrioritySelected: [4]lipgloss.TerminalColor{lipgloss.NoColor{}, lipgloss.NoColor{}, lipgloss.NoColor{}, lipgloss.NoColor{}},
+	Monochrome:       true,
+	Markdown:         "notty",
+}
+
+// themes are the built-in themes selectable with theme.name
+var themes = map[string]Theme{
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+	"no-color":      noColorTheme,
+}
+
+// newTheme resolves the configured theme. Without one, NO_COLOR in the
+// environment selects the no-color theme.
+func newTheme(cfg config.ThemeConfig) (Theme, error) {
+	name := cfg.Name
+	if cfg.Palette != nil && cfg.Palette.Base != "" {
+		name = cfg.Palette.Base
+	}
+	if name == "" {
+		name = "dark"
+		if os.Getenv("NO_COLOR") != "" {
+			name = "no-color"
+		}
+	}
+
+	t, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (want %s)", name, strings.Join(themeNames(), ", "))
+	}
+	if cfg.Palette != nil {
+		t = t.withPalette(*cfg.Palette)
+	}
+	return t, nil
+}
+
+// withPalette returns a copy of t with the colors set in a theme file
+func (t Theme) withPalette(p config.ThemePalette) Theme {
+	set := func(dst *lipgloss.TerminalColor, c string) {
+		if c != "" {
+			*dst = lipgloss.Color(c)
+		}
+	}
+	set(&t.Accent, p.Accent)
+	set(&t.Text, p.Text)
+	set(&t.HeaderText, p.HeaderText)
+	set(&t.Muted, p.Muted)
+	set(&t.Border, p.Border)
+	set(&t.Selection, p.Selection)
+	set(&t.Success, p.Success)
+	set(&t.Warning, p.Warning)
+	set(&t.Error, p.Error)
+	if len(p.Columns) > 0 {
+		t.Columns = colors(p.Columns...)
+	}
+	for i, c := range p.Priority {
+		set(&t.Priority[i], c)
+	}
+	for i, c := range p.PrioritySelected {
+		set(&t.PrioritySelected[i], c)
+	}
+	// A palette only makes sense in color
+	t.Monochrome = false
+	return t
+}
+
+// themeNames returns the names of the built-in themes
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colors converts color strings to terminal colors
+func colors(cs ...string) []lipgloss.TerminalColor {
+	out := make([]lipgloss.TerminalColor, len(cs))
+	for i, c := range cs {
+		out[i] = lipgloss.Color(c)
+	}
+	return out
+}
+
+// columnColor returns the accent of the i-th column, preferring the color
+// configured in the workflow
+func (t Theme) columnColor(i int, configured string) lipgloss.TerminalColor {
+	if configured != "" && !t.Monochrome {
+		return lipgloss.Color(configured)
+	}
+	return t.Columns[i%len(t.Columns)]
+}
+
+// priorityColor returns the color for a priority, clamped to 0-3
+func (t Theme) priorityColor(priority int) lipgloss.TerminalColor {
+	return t.Priority[max(0, min(priority, 3))]
+}
+
+// prioritySelectedColor returns the selected card border for a priority
+func (t Theme) prioritySelectedColor(priority int) lipgloss.TerminalColor {
+	return t.PrioritySelected[max(0, min(priority, 3))]
+}
+
+// selectedBorder is the border of selected cards and options; monochrome
+// themes can't rely on color to show the selection
+func (t Theme) selectedBorder() lipgloss.Border {
+	if t.Monochrome {
+		return lipgloss.ThickBorder()
+	}
+	return lipgloss.RoundedBorder()
+}
@@ -3,6 +3,8 @@ package tui
 import (
 	"fmt"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -16,6 +18,7 @@ const (
 	NormalMode TodoInputMode = iota
 	AddingMode
 	EditingMode
+	NotesMode
 )
 
 // TodoList represents the todo list view for a project
@@ -23,28 +26,189 @@ type TodoList struct {
 	todos         []api.Todo
 	selectedIndex int
 	projectName   string
-	projectID     int
+	projectID     *int // nil for the inbox of todos without a project
 	width         int
 	height        int
 	InputMode     TodoInputMode // Exported so model.go can check it
 	textInput     textinput.Model
+	notesInput    textarea.Model
 	editingTodoID int
+	pending       map[int]bool // todos with changes not yet confirmed by the API
+	lastTempID    int          // last placeholder ID given to an unsaved todo
+	newPriority   int          // priority given to new todos
+	hideDone      bool         // completed todos are left out of the list
+	visible       []int        // indices into todos of the shown todos
+	notes         notesCache   // rendering of the selected todo's notes
+	theme         Theme
+	keys          keyMap
 }
 
-// NewTodoList creates a new todo list view
-func NewTodoList(todos []api.Todo, projectName string, projectID int) *TodoList {
+// NewTodoList creates a new todo list view for a project, or for the inbox
+// when projectID is nil; new todos start at newPriority and completed todos
+// are hidden if hideDone is set. Deleted todos are left out.
+func NewTodoList(todos []api.Todo, projectName string, projectID *int, newPriority int, hideDone bool, theme Theme, keys keyMap) *TodoList {
+	live := make([]api.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !todo.Deleted {
+			live = append(live, todo)
+		}
+	}
+
 	ti := textinput.New()
 	ti.Placeholder = "Enter todo description..."
 	ti.CharLimit = 200
 	ti.Width = 40
 
-	return &TodoList{
-		todos:         todos,
+	ta := textarea.New()
+	ta.Placeholder = "Notes in markdown..."
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = false
+	ta.SetWidth(44)
+	ta.SetHeight(8)
+
+	t := &TodoList{
+		todos:         live,
 		selectedIndex: 0,
 		projectName:   projectName,
 		projectID:     projectID,
 		InputMode:     NormalMode,
 		textInput:     ti,
+		notesInput:    ta,
+		pending:       make(map[int]bool),
+		newPriority:   newPriority,
+		hideDone:      hideDone,
+		theme:         theme,
+		keys:          keys,
+	}
+	t.refresh()
+	return t
+}
+
+// refresh recomputes which todos are shown and keeps the selection in range
+func (t *TodoList) refresh() {
+	t.visible = t.visible[:0]
+	for i, todo := range t.todos {
+		if t.hideDone && todo.Completed {
+			continue
+		}
+		t.visible = append(t.visible, i)
+	}
+	if t.selectedIndex >= len(t.visible) {
+		t.selectedIndex = max(0, len(t.visible)-1)
+	}
+}
+
+// belongs reports whether a todo is in the list's project, or in no project
+// for the inbox
+func (t *TodoList) belongs(todo api.Todo) bool {
+	return sameProject(todo.ProjectID, t.projectID)
+}
+
+// sameProject reports whether two project references are the same project,
+// or both no project
+func sameProject(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// inboxTodos returns the todos without a project
+func inboxTodos(todos []api.Todo) []api.Todo {
+	inbox := []api.Todo{}
+	for _, todo := range todos {
+		if todo.ProjectID == nil && !todo.Deleted {
+			inbox = append(inbox, todo)
+		}
+	}
+	return inbox
+}
+
+// selected returns the selected todo, if any is shown
+func (t *TodoList) selected() (api.Todo, bool) {
+	if t.selectedIndex >= len(t.visible) {
+		return api.Todo{}, false
+	}
+	return t.todos[t.visible[t.selectedIndex]], true
+}
+
+// Progress returns how many of the list's todos are done, out of how many
+func (t *TodoList) Progress() (done, total int) {
+	return countProgress(t.todos)
+}
+
+// countProgress counts the completed todos among those not deleted
+func countProgress(todos []api.Todo) (done, total int) {
+	for _, todo := range todos {
+		if todo.Deleted {
+			continue
+		}
+		total++
+		if todo.Completed {
+			done++
+		}
+	}
+	return done, total
+}
+
+// AddPendingTodo appends a todo that has not been saved yet under a negative
+// placeholder ID, which is returned so the saved todo can replace it later
+func (t *TodoList) AddPendingTodo(todo api.Todo) int {
+	t.lastTempID--
+	todo.ID = t.lastTempID
+	t.todos = append(t.todos, todo)
+	t.pending[todo.ID] = true
+	t.refresh()
+	return todo.ID
+}
+
+// FindTodo returns a copy of the todo with the given ID, if it is in the list
+func (t *TodoList) FindTodo(id int) (api.Todo, bool) {
+	for _, todo := range t.todos {
+		if todo.ID == id {
+			return todo, true
+		}
+	}
+	return api.Todo{}, false
+}
+
+// ReplaceTodo replaces the todo with the given ID, reporting whether it was found
+func (t *TodoList) ReplaceTodo(id int, todo api.Todo) bool {
+	for i := range t.todos {
+		if t.todos[i].ID == id {
+			t.todos[i] = todo
+			t.refresh()
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveTodo removes the todo with the given ID, returning it and its index
+func (t *TodoList) RemoveTodo(id int) (api.Todo, int, bool) {
+	for i, todo := range t.todos {
+		if todo.ID == id {
+			t.todos = append(t.todos[:i], t.todos[i+1:]...)
+			t.refresh()
+			return todo, i, true
+		}
+	}
+	return api.Todo{}, 0, false
+}
+
+// InsertTodo puts a todo back at the given index, e.g. when a delete is rolled back
+func (t *TodoList) InsertTodo(index int, todo api.Todo) {
+	index = max(0, min(index, len(t.todos)))
+	t.todos = append(t.todos[:index], append([]api.Todo{todo}, t.todos[index:]...)...)
+	t.refresh()
+}
+
+// SetPending marks whether a todo has changes not yet confirmed by the API
+func (t *TodoList) SetPending(id int, pending bool) {
+	if pending {
+		t.pending[id] = true
+	} else {
+		delete(t.pending, id)
 	}
 }
 
@@ -60,6 +224,28 @@ func (t TodoList) Update(msg tea.Msg) (TodoList, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Notes take enter as a newline, so they are saved with ctrl+s
+		if t.InputMode == NotesMode {
+			switch msg.String() {
+			case "ctrl+s":
+				var cmd tea.Cmd
+				if todo, ok := t.FindTodo(t.editingTodoID); ok && todo.Notes != t.notesInput.Value() {
+					cmd = updateTodoNotesCmd(todo.ID, t.notesInput.Value())
+				}
+				t.InputMode = NormalMode
+				t.notesInput.Reset()
+				t.notesInput.Blur()
+				return t, cmd
+			case "esc":
+				t.InputMode = NormalMode
+				t.notesInput.Reset()
+				t.notesInput.Blur()
+				return t, nil
+			}
+			t.notesInput, cmd = t.notesInput.Update(msg)
+			return t, cmd
+		}
+
 		// Handle input mode first - check for Enter/Esc BEFORE passing to textinput
 		if t.InputMode == AddingMode || t.InputMode == EditingMode {
 			switch msg.String() {
@@ -69,10 +255,9 @@ func (t TodoList) Update(msg tea.Msg) (TodoList, tea.Cmd) {
 				if description != "" {
 					var cmd tea.Cmd
 					if t.InputMode == AddingMode {
-						cmd = createTodoCmd(description, 0, t.projectID)
+						cmd = createTodoCmd(description, t.newPriority, t.projectID)
 					} else if t.InputMode == EditingMode {
-						if t.selectedIndex < len(t.todos) {
-							todo := t.todos[t.selectedIndex]
+						if todo, ok := t.FindTodo(t.editingTodoID); ok {
 							cmd = updateTodoCmd(todo.ID, description, todo.Priority, todo.ProjectID)
 						}
 					}
@@ -100,50 +285,81 @@ func (t TodoList) Update(msg tea.Msg) (TodoList, tea.Cmd) {
 		}
 
 		// Normal mode key handling
-		switch msg.String() {
-		case "up", "l":
+		switch {
+		case key.Matches(msg, t.keys.Up):
 			if t.selectedIndex > 0 {
 				t.selectedIndex--
 			}
-		case "down", "k":
-			if t.selectedIndex < len(t.todos)-1 {
+		case key.Matches(msg, t.keys.Down):
+			if t.selectedIndex < len(t.visible)-1 {
 				t.selectedIndex++
 			}
-		case "a":
+		case key.Matches(msg, t.keys.Add):
 			// Start adding a new todo
 			t.InputMode = AddingMode
 			t.textInput.Focus()
 			t.textInput.SetValue("")
 			return t, textinput.Blink
-		case "e":
+		case key.Matches(msg, t.keys.Edit):
 			// Start editing selected todo
-			if len(t.todos) > 0 && t.selectedIndex < len(t.todos) {
+			if todo, ok := t.selected(); ok {
 				t.InputMode = EditingMode
-				t.editingTodoID = t.todos[t.selectedIndex].ID
-				t.textInput.SetValue(t.todos[t.selectedIndex].Description)
+				t.editingTodoID = todo.ID
+				t.textInput.SetValue(todo.Description)
 				t.textInput.Focus()
 				return t, textinput.Blink
 			}
-		case "d", "x":
+		case key.Matches(msg, t.keys.Notes):
+			// Write notes for the selected todo in place
+			if todo, ok := t.selected(); ok {
+				t.InputMode = NotesMode
+				t.editingTodoID = todo.ID
+				t.notesInput.SetValue(todo.Notes)
+				return t, t.notesInput.Focus()
+			}
+		case key.Matches(msg, t.keys.NotesEditor):
+			// Write notes for the selected todo in $EDITOR
+			if todo, ok := t.selected(); ok {
+				return t, func() tea.Msg {
+					return editNotesMsg{todo: todo}
+				}
+			}
+		case key.Matches(msg, t.keys.Delete):
 			// Delete selected todo
-			if len(t.todos) > 0 && t.selectedIndex < len(t.todos) {
-				todoID := t.todos[t.selectedIndex].ID
-				return t, deleteTodoCmd(todoID)
+			if todo, ok := t.selected(); ok {
+				return t, deleteTodoCmd(todo.ID)
+			}
+		case key.Matches(msg, t.keys.Complete):
+			// Mark selected todo done, or open again
+			if todo, ok := t.selected(); ok {
+				return t, completeTodoCmd(todo.ID, !todo.Completed)
+			}
+		case key.Matches(msg, t.keys.Move):
+			// Pick another project for the selected todo
+			if todo, ok := t.selected(); ok {
+				return t, func() tea.Msg {
+					return openProjectPickerMsg{todo: todo}
+				}
+			}
+		case key.Matches(msg, t.keys.ShowDone):
+			t.hideDone = !t.hideDone
+			t.refresh()
+			hide := t.hideDone
+			return t, func() tea.Msg {
+				return hideDoneMsg{hide: hide}
 			}
-		case "+", "=":
+		case key.Matches(msg, t.keys.PriorityUp):
 			// Increase priority
-			if len(t.todos) > 0 && t.selectedIndex < len(t.todos) {
-				todo := t.todos[t.selectedIndex]
+			if todo, ok := t.selected(); ok {
 				newPriority := todo.Priority + 1
 				if newPriority > 3 {
 					newPriority = 3
 				}
 				return t, updateTodoCmd(todo.ID, todo.Description, newPriority, todo.ProjectID)
 			}
-		case "-", "_":
+		case key.Matches(msg, t.keys.PriorityDown):
 			// Decrease priority
-			if len(t.todos) > 0 && t.selectedIndex < len(t.todos) {
-				todo := t.todos[t.selectedIndex]
+			if todo, ok := t.selected(); ok {
 				newPriority := todo.Priority - 1
 				if newPriority < 0 {
 					newPriority = 0
@@ -160,41 +376,59 @@ func (t *TodoList) View() string {
 	// Styles
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("63")).
+		Foreground(t.theme.Accent).
 		MarginBottom(1)
 
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("214"))
+		Foreground(t.theme.Warning)
 
 	todoItemStyle := lipgloss.NewStyle().
 		Padding(0, 1)
 
 	selectedTodoStyle := lipgloss.NewStyle().
 		Padding(0, 1).
-		Foreground(lipgloss.Color("51")).
+		Foreground(t.theme.Selection).
 		Bold(true)
 
 	emptyStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(t.theme.Muted).
 		Italic(true).
 		MarginLeft(2).
 		MarginTop(1)
 
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(t.theme.Muted).
 		MarginTop(1)
 
+	pendingStyle := lipgloss.NewStyle().
+		Foreground(t.theme.Muted)
+
+	notesMarkerStyle := lipgloss.NewStyle().
+		Foreground(t.theme.Muted)
+
+	doneStyle := lipgloss.NewStyle().
+		Foreground(t.theme.Muted).
+		Strikethrough(true)
+
 	inputPromptStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("63")).
+		Foreground(t.theme.Accent).
 		Bold(true).
 		MarginTop(1)
 
 	// Title
 	title := titleStyle.Render(fmt.Sprintf("📝 Todos for: %s", t.projectName))
+	if t.projectID == nil {
+		title = titleStyle.Render("📥 Inbox")
+	}
 
 	// Header
-	header := headerStyle.Render(fmt.Sprintf("\nTotal todos: %d\n", len(t.todos)))
+	done, total := t.Progress()
+	headerText := fmt.Sprintf("Done: %d/%d", done, total)
+	if t.hideDone && done > 0 {
+		headerText += fmt.Sprintf(" (%d hidden)", done)
+	}
+	header := headerStyle.Render("\n" + headerText + "\n")
 
 	// Input prompt if in input mode
 	var inputPrompt string
@@ -202,34 +436,39 @@ func (t *TodoList) View() string {
 		inputPrompt = inputPromptStyle.Render("Add new todo:")
 	} else if t.InputMode == EditingMode {
 		inputPrompt = inputPromptStyle.Render("Edit todo:")
+	} else if t.InputMode == NotesMode {
+		inputPrompt = inputPromptStyle.Render("Notes (markdown):")
 	}
 
 	// Todos
 	var todoViews []string
-	if len(t.todos) == 0 {
-		todoViews = append(todoViews, emptyStyle.Render("No todos yet! Press 'a' to add one."))
-	} else {
-		for i, todo := range t.todos {
+	switch {
+	case len(t.todos) == 0 && t.projectID == nil:
+		todoViews = append(todoViews, emptyStyle.Render(fmt.Sprintf("Inbox is empty! Press '%s' to capture a todo.", t.keys.Add.Help().Key)))
+	case len(t.todos) == 0:
+		todoViews = append(todoViews, emptyStyle.Render(fmt.Sprintf("No todos yet! Press '%s' to add one.", t.keys.Add.Help().Key)))
+	case len(t.visible) == 0:
+		todoViews = append(todoViews, emptyStyle.Render(fmt.Sprintf("All done! Press '%s' to show completed todos.", t.keys.ShowDone.Help().Key)))
+	default:
+		for i, idx := range t.visible {
+			todo := t.todos[idx]
 			// Priority indicator
-			var priorityIndicator string
-			var priorityColor lipgloss.Color
-			switch todo.Priority {
-			case 0:
-				priorityIndicator = "○" // Low
-				priorityColor = lipgloss.Color("240")
-			case 1:
-				priorityIndicator = "◐" // Medium-Low
-				priorityColor = lipgloss.Color("70")
-			case 2:
-				priorityIndicator = "◑" // Medium-High
-				priorityColor = lipgloss.Color("214")
-			default:
-				priorityIndicator = "●" // High
-				priorityColor = lipgloss.Color("196")
+			indicator := priorityIndicator(todo.Priority)
+
+			priorityStyle := lipgloss.NewStyle().Foreground(t.theme.priorityColor(todo.Priority))
+			description := todo.Description
+			if todo.Completed {
+				indicator = "✔"
+				priorityStyle = lipgloss.NewStyle().Foreground(t.theme.Success)
+				description = doneStyle.Render(description)
+			}
+			todoText := fmt.Sprintf("%s %s", priorityStyle.Render(indicator), description)
+			if todo.Notes != "" {
+				todoText += " " + notesMarkerStyle.Render(notesMarker)
+			}
+			if t.pending[todo.ID] {
+				todoText += " " + pendingStyle.Render(pendingMarker)
 			}
-
-			priorityStyle := lipgloss.NewStyle().Foreground(priorityColor)
-			todoText := fmt.Sprintf("%s %s", priorityStyle.Render(priorityIndicator), todo.Description)
 
 			style := todoItemStyle
 			if i == t.selectedIndex {
@@ -248,7 +487,26 @@ func (t *TodoList) View() string {
 	// Help text
 	var help string
 	if t.InputMode == NormalMode {
-		help = helpStyle.Render("↑/l ↓/k navigate • a add • e edit • d delete • +/- priority • esc close")
+		k := t.keys
+		showDone := "hide done"
+		if t.hideDone {
+			showDone = "show done"
+		}
+		help = helpStyle.Render(helpLine(
+			helpEntry("navigate", k.Up, k.Down),
+			helpEntry("add", k.Add),
+			helpEntry("edit", k.Edit),
+			helpEntry("notes", k.Notes, k.NotesEditor),
+			helpEntry("delete", k.Delete),
+			helpEntry("done", k.Complete),
+			helpEntry("move", k.Move),
+			helpEntry(showDone, k.ShowDone),
+			helpEntry("priority", k.PriorityUp, k.PriorityDown),
+			helpEntry("undo/redo", k.Undo, k.Redo),
+			helpEntry("close", k.Back),
+		))
+	} else if t.InputMode == NotesMode {
+		help = helpStyle.Render("ctrl+s save • esc cancel")
 	} else {
 		help = helpStyle.Render("enter submit • esc cancel")
 	}
@@ -257,7 +515,9 @@ func (t *TodoList) View() string {
 	var sections []string
 	sections = append(sections, title, header, todos)
 
-	if t.InputMode != NormalMode {
+	if t.InputMode == NotesMode {
+		sections = append(sections, "", inputPrompt, t.notesInput.View())
+	} else if t.InputMode != NormalMode {
 		sections = append(sections, "", inputPrompt, t.textInput.View())
 	}
 
@@ -267,11 +527,24 @@ func (t *TodoList) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
+// priorityIndicator is the glyph marking a todo's priority
+func priorityIndicator(priority int) string {
+	switch priority {
+	case 0:
+		return "○" // Low
+	case 1:
+		return "◐" // Medium-Low
+	case 2:
+		return "◑" // Medium-High
+	}
+	return "●" // High
+}
+
 // Message types for todo operations
 type createTodoMsg struct {
 	description string
 	priority    int
-	projectID   int
+	projectID   *int
 }
 
 type updateTodoMsg struct {
@@ -285,8 +558,29 @@ type deleteTodoMsg struct {
 	id int
 }
 
+type updateTodoNotesMsg struct {
+	id    int
+	notes string
+}
+
+// editNotesMsg asks the model to open a todo's notes in $EDITOR
+type editNotesMsg struct {
+	todo api.Todo
+}
+
+type completeTodoMsg struct {
+	id        int
+	completed bool
+}
+
+// hideDoneMsg tells the model whether completed todos are hidden, so the
+// choice carries over to the next todo list opened
+type hideDoneMsg struct {
+	hide bool
+}
+
 // Command functions
-func createTodoCmd(description string, priority int, projectID int) tea.Cmd {
+func createTodoCmd(description string, priority int, projectID *int) tea.Cmd {
 	return func() tea.Msg {
 		return createTodoMsg{
 			description: description,
@@ -307,6 +601,18 @@ func updateTodoCmd(id int, description string, priority int, projectID *int) tea
 	}
 }
 
+func completeTodoCmd(id int, completed bool) tea.Cmd {
+	return func() tea.Msg {
+		return completeTodoMsg{id: id, completed: completed}
+	}
+}
+
+func updateTodoNotesCmd(id int, notes string) tea.Cmd {
+	return func() tea.Msg {
+		return updateTodoNotesMsg{id: id, notes: notes}
+	}
+}
+
 func deleteTodoCmd(id int) tea.Cmd {
 	return func() tea.Msg {
 		return deleteTodoMsg{id: id}
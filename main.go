@@ -1,17 +1,65 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sean-obeirne/projectarium-tui/internal/cli"
+	"github.com/sean-obeirne/projectarium-tui/internal/config"
 	"github.com/sean-obeirne/projectarium-tui/internal/tui"
 )
 
 func main() {
+	var flags config.Flags
+	flags.Register(flag.CommandLine)
+	flag.Usage = func() {
+		cli.Usage(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Refuse to start with a config that doesn't mean what the user thinks
+	cfg, err := config.Load(flags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, w := range cfg.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+
+	// Subcommands run without the TUI, for scripts
+	if cli.IsCommand(flag.Args()) {
+		backend, err := cfg.Backend()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := cli.Run(ctx, backend, cfg, flag.Args(), os.Stdout, os.Stderr)
+		stop()
+		os.Exit(code)
+	}
+	if flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "pj-tui: unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	model, err := tui.NewModel(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	// Create the Bubble Tea program
 	p := tea.NewProgram(
-		tui.NewModel(),
+		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)